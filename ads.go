@@ -2,6 +2,9 @@ package ads
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/akrennmair/slice"
 	"github.com/hysios/x/providers"
@@ -19,20 +22,28 @@ const (
 )
 
 type Asset struct {
-	AccountID      string
-	AccountName    string
-	AdcreativeID   string
-	AdcreativeName string
-	AssetID        string
-	Name           string
-	PageType       PageType
-	SubType        string
-	Texts          []string
-	SubAssets      []*SubAsset
-	Signature      string
-	Version        string
+	// Provider is the name of the provider the asset was listed by
+	Provider         string
+	AccountID        string
+	AccountName      string
+	AdcreativeID     string
+	AdcreativeName   string
+	AssetID          string
+	Name             string
+	PageType         PageType
+	SubType          string
+	Texts            []string
+	SubAssets        []*SubAsset
+	Width            int
+	Height           int
+	Signature        string
+	Version          string
+	PHash            string
+	CreatedTime      time.Time
+	LastModifiedTime time.Time
 }
 
+//go:generate stringer -type=SubAssetType
 type SubAssetType int
 
 const (
@@ -42,11 +53,57 @@ const (
 	SATPageUrl
 )
 
+// ParsePageType parses the name of a PageType as returned by String, a plain
+// number is accepted too
+func ParsePageType(s string) (PageType, error) {
+	for i := PTUnknown; i <= PTText; i++ {
+		if i.String() == s {
+			return i, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(s); err == nil {
+		return PageType(n), nil
+	}
+	return PTUnknown, fmt.Errorf("invalid page type %q", s)
+}
+
+// ParseSubAssetType parses the name of a SubAssetType as returned by String,
+// a plain number is accepted too
+func ParseSubAssetType(s string) (SubAssetType, error) {
+	for i := SATUnknown; i <= SATPageUrl; i++ {
+		if i.String() == s {
+			return i, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(s); err == nil {
+		return SubAssetType(n), nil
+	}
+	return SATUnknown, fmt.Errorf("invalid sub asset type %q", s)
+}
+
 type SubAsset struct {
 	Type SubAssetType
 	Url  string
 }
 
+// ImageUrl returns the url of the image used for perceptual hashing, the
+// preview of an image or the cover image of a video, frames of the video
+// itself are not decoded
+func (a *Asset) ImageUrl() string {
+	if a.PageType != PTImage && a.PageType != PTVideo {
+		return ""
+	}
+
+	for _, sub := range a.SubAssets {
+		if sub.Type == SATImage && sub.Url != "" {
+			return sub.Url
+		}
+	}
+	return ""
+}
+
 // PrimaryUrl returns the primary url of the asset
 func (a *Asset) PrimaryUrl() string {
 	if len(a.SubAssets) == 0 {
@@ -70,10 +127,66 @@ type AdcreativeMatchFunc func(adcreative Map) (process bool)
 
 type GetAdcreatives interface {
 	Assets() ([]*Asset, error)
+	// SetAdcreativesFunc adds a func every processed adcreative must match,
+	// see And
 	SetAdcreativesFunc(match AdcreativeMatchFunc)
+	// ClearAdcreativesFuncs removes all funcs, including the NotDeleted
+	// default, so every adcreative is processed
+	ClearAdcreativesFuncs()
 	OnlyAdcreatives(on bool)
 }
 
+// IncrementalAdcreatives is implemented by providers able to list only the
+// assets modified since a watermark, the returned watermark is the latest
+// modification time seen and should be passed to the next call
+type IncrementalAdcreatives interface {
+	AssetsSince(since time.Time) (assets []*Asset, watermark time.Time, err error)
+}
+
+// Condition compares an adcreative field with literal values, Operator is one
+// of == != < <= > >= in. Numbers are formatted as decimals.
+type Condition struct {
+	Field    string
+	Operator string
+	Values   []string
+}
+
+// PushdownAdcreatives is implemented by providers able to filter adcreatives
+// in their list calls. The conditions are hints for the conditions a
+// provider supports, the listed adcreatives are still matched by the match
+// funcs.
+type PushdownAdcreatives interface {
+	PushDown(conds []Condition)
+}
+
+// Account is an advertiser account managed by an agency or business manager
+type Account struct {
+	ID     string
+	Name   string
+	Status string
+	// Balance is the total of the fund balances in cents
+	Balance int64
+}
+
+// AccountLister is implemented by providers opened with an agency or business
+// manager account, listing the advertiser accounts it manages
+type AccountLister interface {
+	Accounts() ([]*Account, error)
+}
+
+// NamedAccount is implemented by providers looking up the name of their
+// account, a name already known, such as the name listed by the agency,
+// saves the lookup
+type NamedAccount interface {
+	SetAccountName(name string)
+}
+
+// Configurable is implemented by providers accepting settings, such as the API
+// version, from a config profile
+type Configurable interface {
+	Configure(settings map[string]string) error
+}
+
 func Open(provider string, accountId string, accessToken string, debug bool) (GetAdcreatives, error) {
 	ctor, ok := advProviders.Lookup(provider)
 	if !ok {
@@ -88,3 +201,14 @@ var advProviders providers.Provider[string, func(string, string, bool) (GetAdcre
 func RegisterProvider(name string, f func(string, string, bool) (GetAdcreatives, error)) {
 	advProviders.Register(name, f)
 }
+
+// ProviderNames returns the names of the registered providers in sorted order
+func ProviderNames() []string {
+	var names []string
+	advProviders.Range(func(name string, _ func(string, string, bool) (GetAdcreatives, error)) bool {
+		names = append(names, name)
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,417 @@
+// Package baidu lists the creatives of a Baidu Marketing (百度营销) user with
+// the JSON API. Search creatives are exported as text assets with their
+// display and destination urls, feed creatives as the images and videos of
+// their material.
+package baidu
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+	"go.uber.org/zap"
+)
+
+// batchSize is the number of ids of a get call
+const batchSize = 100
+
+type Config struct {
+	OnlyAdcreatives bool
+	// Endpoint is the base url of the JSON API, DefaultEndpoint when empty
+	Endpoint string
+	// Products are the products listed, search and feed
+	Products []string
+}
+
+// BaiduAdcreatives lists the creatives of a user, the account id is the user
+// name
+type BaiduAdcreatives struct {
+	UserName       string
+	Config         Config
+	client         *Client
+	adcreativesFns []ads.AdcreativeMatchFunc
+	accountName    *string
+	log            *zap.SugaredLogger
+}
+
+func NewAdcreatives(accountId string, accessToken string, debug bool) (*BaiduAdcreatives, error) {
+	if accountId == "" {
+		return nil, fmt.Errorf("baidu: no user name")
+	}
+	b := &BaiduAdcreatives{
+		UserName: accountId,
+		Config:   Config{Products: []string{"search", "feed"}},
+		client:   &Client{UserName: accountId, AccessToken: accessToken, Debug: debug},
+		log:      zap.S().With("provider", "BAIDU"),
+	}
+
+	b.SetAdcreativesFunc(ads.NotDeleted)
+
+	return b, nil
+}
+
+var (
+	searchFields = []string{
+		"creativeId", "adgroupId", "title", "description1", "description2",
+		"pcDestinationUrl", "pcDisplayUrl", "mobileDestinationUrl", "mobileDisplayUrl",
+		"pause", "status",
+	}
+	feedFields = []string{
+		"creativeFeedId", "adgroupFeedId", "creativeFeedName", "materialstyle",
+		"material", "pause", "status", "addtime", "modtime",
+	}
+)
+
+// Assets lists the text assets of search creatives and the image and video
+// assets of feed creatives
+func (b *BaiduAdcreatives) Assets() (assets []*ads.Asset, err error) {
+	ctx := context.Background()
+
+	if slices.Contains(b.Config.Products, "search") {
+		search, err := b.searchAssets(ctx)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, search...)
+	}
+	if slices.Contains(b.Config.Products, "feed") {
+		feed, err := b.feedAssets(ctx)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, feed...)
+	}
+
+	name := b.AccountName()
+	for _, asset := range assets {
+		asset.AccountName = name
+	}
+	return assets, nil
+}
+
+// searchAssets lists the search creatives of all campaigns as text assets
+func (b *BaiduAdcreatives) searchAssets(ctx context.Context) (assets []*ads.Asset, err error) {
+	log := b.log.With("product", "search")
+	campaigns, err := b.client.Call(ctx, "sms/service/CampaignService/getCampaign", map[string]interface{}{
+		"campaignFields": []string{"campaignId", "campaignName"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	httpapi.PrintJson(log, "campaigns", campaigns)
+
+	var ids []string
+	for _, campaign := range campaigns {
+		ids = append(ids, campaign.Get("campaignId").String())
+	}
+
+	var creatives []ads.Map
+	for _, batch := range httpapi.Batches(ids, batchSize) {
+		objs, err := b.client.Call(ctx, "sms/service/CreativeService/getCreative", map[string]interface{}{
+			"creativeFields": searchFields,
+			"ids":            toInts(batch),
+			// ids are campaign ids
+			"idType": 3,
+		})
+		if err != nil {
+			return nil, err
+		}
+		creatives = append(creatives, objs...)
+	}
+	creatives = httpapi.Process(creatives, normalize, b.adcreativesFns)
+	httpapi.PrintJson(log, "creatives", creatives)
+
+	for _, creative := range creatives {
+		var texts []string
+		for _, key := range []string{"title", "description1", "description2"} {
+			if text := creative.Get(key).String(); text != "" {
+				texts = append(texts, text)
+			}
+		}
+
+		var (
+			subs []*ads.SubAsset
+			urls []string
+		)
+		for _, key := range []string{"pcDestinationUrl", "pcDisplayUrl", "mobileDestinationUrl", "mobileDisplayUrl"} {
+			url := creative.Get(key).String()
+			if url == "" || slices.Contains(urls, url) {
+				continue
+			}
+			urls = append(urls, url)
+			subs = append(subs, &ads.SubAsset{
+				Type: ads.SATPageUrl,
+				Url:  url,
+			})
+		}
+
+		id := creative.Get("creativeId").String()
+		assets = append(assets, &ads.Asset{
+			AccountID:      b.UserName,
+			Name:           creative.Get("title").String(),
+			AssetID:        id,
+			AdcreativeID:   id,
+			AdcreativeName: creative.Get("title").String(),
+			PageType:       ads.PTText,
+			SubType:        "SEARCH",
+			Texts:          texts,
+			SubAssets:      subs,
+		})
+	}
+	return assets, nil
+}
+
+// feedAssets lists the feed creatives of all adgroups as image and video
+// assets
+func (b *BaiduAdcreatives) feedAssets(ctx context.Context) (assets []*ads.Asset, err error) {
+	log := b.log.With("product", "feed")
+	campaigns, err := b.client.Call(ctx, "feed/v1/CampaignFeedService/getCampaignFeed", map[string]interface{}{
+		"campaignFeedFields": []string{"campaignFeedId", "campaignFeedName"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	httpapi.PrintJson(log, "campaigns", campaigns)
+
+	var campaignIds []string
+	for _, campaign := range campaigns {
+		campaignIds = append(campaignIds, campaign.Get("campaignFeedId").String())
+	}
+
+	var adgroupIds []string
+	for _, batch := range httpapi.Batches(campaignIds, batchSize) {
+		adgroups, err := b.client.Call(ctx, "feed/v1/AdgroupFeedService/getAdgroupFeed", map[string]interface{}{
+			"adgroupFeedFields": []string{"adgroupFeedId", "campaignFeedId"},
+			"ids":               toInts(batch),
+			// ids are campaign ids
+			"idType": 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, adgroup := range adgroups {
+			adgroupIds = append(adgroupIds, adgroup.Get("adgroupFeedId").String())
+		}
+	}
+
+	var creatives []ads.Map
+	for _, batch := range httpapi.Batches(adgroupIds, batchSize) {
+		objs, err := b.client.Call(ctx, "feed/v1/CreativeFeedService/getCreativeFeed", map[string]interface{}{
+			"creativeFeedFields": feedFields,
+			"ids":                toInts(batch),
+			// ids are adgroup ids
+			"idType": 2,
+		})
+		if err != nil {
+			return nil, err
+		}
+		creatives = append(creatives, objs...)
+	}
+	creatives = httpapi.Process(creatives, normalize, b.adcreativesFns)
+	httpapi.PrintJson(log, "creatives", creatives)
+
+	imageIndexs := make(map[string]bool)
+	videoIndexs := make(map[string]bool)
+	for _, creative := range creatives {
+		material, err := materialOf(creative)
+		if err != nil {
+			log.Warnw("invalid creative material", "creative", creative.Get("creativeFeedId").String(), "error", err)
+			continue
+		}
+
+		id := creative.Get("creativeFeedId").String()
+		name := creative.Get("creativeFeedName").String()
+		created := parseTime(creative, "addtime")
+		modified := parseTime(creative, "modtime")
+
+		for i, picture := range material.Get("pictures").ObjxMapSlice() {
+			url := picture.Get("image").String()
+			if url == "" || imageIndexs[url] {
+				continue
+			}
+			imageIndexs[url] = true
+
+			assets = append(assets, &ads.Asset{
+				AccountID:      b.UserName,
+				Name:           name,
+				AssetID:        id + "_" + strconv.Itoa(i),
+				AdcreativeID:   id,
+				AdcreativeName: name,
+				PageType:       ads.PTImage,
+				SubType:        "FEED",
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATImage,
+						Url:  url,
+					},
+				},
+				Width:            httpapi.IntValue(picture, "width"),
+				Height:           httpapi.IntValue(picture, "height"),
+				CreatedTime:      created,
+				LastModifiedTime: modified,
+			})
+		}
+
+		video := material.Get("video").ObjxMap()
+		if videoId := video.Get("videoid").String(); videoId != "" && !videoIndexs[videoId] {
+			videoIndexs[videoId] = true
+			assets = append(assets, &ads.Asset{
+				AccountID:      b.UserName,
+				Name:           name,
+				AssetID:        videoId,
+				AdcreativeID:   id,
+				AdcreativeName: name,
+				PageType:       ads.PTVideo,
+				SubType:        "FEED",
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATImage,
+						Url:  video.Get("poster").String(),
+					},
+					{
+						Type: ads.SATVideo,
+						Url:  video.Get("url").String(),
+					},
+				},
+				Width:            httpapi.IntValue(video, "width"),
+				Height:           httpapi.IntValue(video, "height"),
+				CreatedTime:      created,
+				LastModifiedTime: modified,
+			})
+		}
+	}
+	return assets, nil
+}
+
+// materialOf returns the material of a feed creative, sent as a JSON string
+func materialOf(creative ads.Map) (ads.Map, error) {
+	v := creative.Get("material")
+	if v.IsStr() {
+		return ads.FromJSON([]byte(v.Str()))
+	}
+	return v.ObjxMap(), nil
+}
+
+// AccountName returns the name on the business license of the user, or the
+// user name
+func (b *BaiduAdcreatives) AccountName() string {
+	if b.accountName != nil {
+		return *b.accountName
+	}
+
+	name := b.UserName
+	infos, err := b.client.Call(context.Background(), "sms/service/AccountService/getAccountInfo", map[string]interface{}{
+		"accountFields": []string{"userId", "userName", "liceName"},
+	})
+	if err != nil {
+		b.log.Warnw("get account info error", "account", b.UserName, "error", err)
+	}
+	for _, info := range infos {
+		if lice := info.Get("liceName").String(); lice != "" {
+			name = lice
+		}
+	}
+
+	b.accountName = &name
+	return name
+}
+
+// SetAdcreativesFunc ...
+func (b *BaiduAdcreatives) SetAdcreativesFunc(match ads.AdcreativeMatchFunc) {
+	b.adcreativesFns = append(b.adcreativesFns, match)
+}
+
+// ClearAdcreativesFuncs ...
+func (b *BaiduAdcreatives) ClearAdcreativesFuncs() {
+	b.adcreativesFns = nil
+}
+
+// OnlyAdcreatives has no effect, all materials are read from creatives
+func (b *BaiduAdcreatives) OnlyAdcreatives(on bool) {
+	b.Config.OnlyAdcreatives = on
+}
+
+// Configure applies provider settings: endpoint replaces the url of the JSON
+// API and products, a comma separated list of search and feed, selects the
+// creatives listed
+func (b *BaiduAdcreatives) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		switch key {
+		case "endpoint":
+			b.Config.Endpoint, b.client.Endpoint = value, value
+		case "products":
+			var products []string
+			for _, product := range strings.Split(value, ",") {
+				product = strings.TrimSpace(product)
+				if product != "search" && product != "feed" {
+					return fmt.Errorf("baidu: unknown product %q", product)
+				}
+				products = append(products, product)
+			}
+			b.Config.Products = products
+		default:
+			return fmt.Errorf("baidu: unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// normalize sets the configured_status, created_time and last_modified_time
+// fields of GDT adcreatives from a creative
+func normalize(creative ads.Map) {
+	if pause := creative.Get("pause"); pause.IsBool() {
+		status := "AD_STATUS_NORMAL"
+		if pause.Bool() {
+			status = "AD_STATUS_SUSPEND"
+		}
+		creative.Set("configured_status", status)
+	}
+	if t := parseTime(creative, "addtime"); !t.IsZero() {
+		creative.Set("created_time", t.Unix())
+	}
+	if t := parseTime(creative, "modtime"); !t.IsZero() {
+		creative.Set("last_modified_time", t.Unix())
+	}
+}
+
+var (
+	_ ads.GetAdcreatives = (*BaiduAdcreatives)(nil)
+	_ ads.Configurable   = (*BaiduAdcreatives)(nil)
+)
+
+func init() {
+	ads.RegisterProvider("BAIDU", func(accountId string, accessToken string, debug bool) (ads.GetAdcreatives, error) {
+		return NewAdcreatives(accountId, accessToken, debug)
+	})
+	ads.DescribeProvider(ads.ProviderInfo{
+		Name:         "BAIDU",
+		Description:  "Baidu Marketing (百度营销) search and feed creatives",
+		Capabilities: []ads.Capability{ads.CapDownload},
+		PageTypes:    []ads.PageType{ads.PTVideo, ads.PTImage, ads.PTText},
+		Credentials:  []string{"access_token"},
+		Settings: []ads.Setting{
+			{Name: "endpoint", Description: "base url of the API", Default: DefaultEndpoint},
+			{Name: "products", Description: "comma separated list of search and feed", Default: "search,feed"},
+		},
+	})
+}
+
+// parseTime reads a time of the API
+func parseTime(obj ads.Map, key string) time.Time {
+	return httpapi.ParseTime(obj, key, httpapi.DateTime, httpapi.CST)
+}
+
+func toInts(ids []string) []int64 {
+	ints := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+			ints = append(ints, n)
+		}
+	}
+	return ints
+}
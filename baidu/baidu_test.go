@@ -0,0 +1,178 @@
+package baidu
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/apitest"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+)
+
+// server serves the data of the JSON API paths for the request bodies
+func server(t *testing.T, routes map[string]apitest.Route) *httptest.Server {
+	for path, route := range routes {
+		path, route := path, route
+		routes[path] = func(r *apitest.Request) interface{} {
+			header, _ := r.Body["header"].(apitest.Object)
+			if header["userName"] != "user" || header["accessToken"] != "token" {
+				t.Errorf("%s: header %v", path, header)
+			}
+			return route(r)
+		}
+	}
+	return apitest.Server(t, apitest.Config{
+		Auth: func(r *http.Request) bool { return r.Method == http.MethodPost },
+		Envelope: func(data interface{}) interface{} {
+			return apitest.Object{
+				"header": apitest.Object{"status": 0, "desc": "success"},
+				"body":   apitest.Object{"data": data},
+			}
+		},
+	}, routes)
+}
+
+// body is the body of a request
+func body(r *apitest.Request) apitest.Object {
+	b, _ := r.Body["body"].(apitest.Object)
+	return b
+}
+
+func open(t *testing.T, srv *httptest.Server, products string) *BaiduAdcreatives {
+	return apitest.Open(t, NewAdcreatives, "user", srv, map[string]string{"products": products})
+}
+
+func TestSearchAssets(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/sms/service/CampaignService/getCampaign": func(*apitest.Request) interface{} {
+			return []apitest.Object{{"campaignId": 1}, {"campaignId": 2}}
+		},
+		"/sms/service/CreativeService/getCreative": func(r *apitest.Request) interface{} {
+			if b := body(r); b["idType"] != float64(3) || len(b["ids"].([]interface{})) != 2 {
+				t.Errorf("creatives of %v", b)
+			}
+			return []apitest.Object{
+				{
+					"creativeId": 11, "title": "title a", "description1": "description a", "pause": false,
+					"pcDestinationUrl": "https://example.com/a", "pcDisplayUrl": "https://example.com/a",
+					"mobileDestinationUrl": "https://m.example.com/a", "mobileDisplayUrl": "https://example.com/a",
+				},
+				{"creativeId": 12, "title": "title b", "description2": "description b", "pause": true},
+			}
+		},
+		"/sms/service/AccountService/getAccountInfo": func(*apitest.Request) interface{} {
+			return []apitest.Object{{"userName": "user", "liceName": "company"}}
+		},
+	})
+
+	assets, err := open(t, srv, "search").Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := apitest.Index(t, assets, "user", "company")
+	if got.Len() != 2 {
+		t.Errorf("listed %d assets, want 2", got.Len())
+	}
+
+	a := got.Get("11", ads.PTText)
+	if len(a.Texts) != 2 || a.Texts[0] != "title a" || a.Texts[1] != "description a" || a.SubType != "SEARCH" || a.AdcreativeID != "11" {
+		t.Errorf("text %+v", a)
+	}
+	if len(a.SubAssets) != 2 || a.SubAssets[0].Url != "https://example.com/a" || a.SubAssets[1].Url != "https://m.example.com/a" {
+		t.Errorf("urls %+v", a.SubAssets)
+	}
+	if a := got.Get("12", ads.PTText); len(a.Texts) != 2 || a.Texts[1] != "description b" || len(a.SubAssets) != 0 {
+		t.Errorf("text %+v", a)
+	}
+}
+
+func TestFeedAssets(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/feed/v1/CampaignFeedService/getCampaignFeed": func(*apitest.Request) interface{} {
+			return []apitest.Object{{"campaignFeedId": 1}}
+		},
+		"/feed/v1/AdgroupFeedService/getAdgroupFeed": func(r *apitest.Request) interface{} {
+			if b := body(r); b["idType"] != float64(1) {
+				t.Errorf("adgroups of %v", b)
+			}
+			return []apitest.Object{{"adgroupFeedId": 2, "campaignFeedId": 1}}
+		},
+		"/feed/v1/CreativeFeedService/getCreativeFeed": func(r *apitest.Request) interface{} {
+			if b := body(r); b["idType"] != float64(2) {
+				t.Errorf("creatives of %v", b)
+			}
+			return []apitest.Object{
+				{
+					"creativeFeedId": 21, "creativeFeedName": "feed a", "pause": false,
+					"addtime": "2024-03-01 10:00:00", "modtime": "2024-03-02 10:00:00",
+					"material": `{"pictures": [{"image": "https://example.com/1.jpg", "width": 1280, "height": 720}, {"image": "https://example.com/2.jpg"}],
+						"video": {"videoid": "v1", "poster": "https://example.com/v1.jpg", "url": "https://example.com/v1.mp4", "width": 720, "height": 1280}}`,
+				},
+				{
+					"creativeFeedId": 22, "creativeFeedName": "feed b",
+					"material": apitest.Object{
+						"pictures": []apitest.Object{{"image": "https://example.com/1.jpg"}, {"image": "https://example.com/3.jpg", "width": "640", "height": "480"}},
+						"video":    apitest.Object{"videoid": "v1", "poster": "https://example.com/v1.jpg", "url": "https://example.com/v1.mp4"},
+					},
+				},
+				{"creativeFeedId": 23, "creativeFeedName": "invalid", "material": "{"},
+			}
+		},
+		"/sms/service/AccountService/getAccountInfo": func(*apitest.Request) interface{} {
+			return []apitest.Object{}
+		},
+	})
+
+	assets, err := open(t, srv, "feed").Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := apitest.Index(t, assets, "user", "user")
+	if got.Len() != 4 {
+		t.Errorf("listed %d assets, want 4", got.Len())
+	}
+
+	image := got.Get("21_0", ads.PTImage)
+	if image.PrimaryUrl() != "https://example.com/1.jpg" || image.Width != 1280 || image.Height != 720 || image.SubType != "FEED" ||
+		!image.CreatedTime.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, httpapi.CST)) ||
+		!image.LastModifiedTime.Equal(time.Date(2024, 3, 2, 10, 0, 0, 0, httpapi.CST)) {
+		t.Errorf("image %+v", image)
+	}
+	got.Get("21_1", ads.PTImage)
+	if got.Has("22_0", ads.PTImage) {
+		t.Error("image of a listed url listed again")
+	}
+	if image := got.Get("22_1", ads.PTImage); image.PrimaryUrl() != "https://example.com/3.jpg" || image.Width != 640 || image.AdcreativeID != "22" {
+		t.Errorf("image %+v", image)
+	}
+
+	video := got.Get("v1", ads.PTVideo)
+	if video.ImageUrl() != "https://example.com/v1.jpg" || video.PrimaryUrl() != "https://example.com/v1.mp4" ||
+		video.Height != 1280 || video.AdcreativeID != "21" {
+		t.Errorf("video %+v", video)
+	}
+}
+
+func TestError(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/sms/service/CampaignService/getCampaign": func(*apitest.Request) interface{} {
+			return apitest.Status{Code: http.StatusOK, Body: apitest.Object{
+				"header": apitest.Object{"status": 2, "desc": "failure", "failures": []apitest.Object{
+					{"code": 8206, "message": "access token invalid", "position": "header"},
+				}},
+			}}
+		},
+	})
+
+	_, err := open(t, srv, "search").Assets()
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v is not an API error", err)
+	}
+	if apiErr.Status != 2 || len(apiErr.Failures) != 1 || apiErr.Failures[0].Code != 8206 || apiErr.Failures[0].Message != "access token invalid" {
+		t.Errorf("error %+v", apiErr)
+	}
+}
@@ -0,0 +1,108 @@
+package baidu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+)
+
+// DefaultEndpoint is the JSON API of Baidu Marketing
+const DefaultEndpoint = "https://api.baidu.com/json"
+
+// Error is a failed call, with the failures of the response header
+type Error struct {
+	Status   int
+	Desc     string
+	Failures []Failure
+}
+
+// Failure is a failure of a call
+type Failure struct {
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	Position string `json:"position"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("baidu: status %d: %s", e.Status, e.Desc)
+	for _, f := range e.Failures {
+		msg += fmt.Sprintf(", %d %s", f.Code, f.Message)
+	}
+	return msg
+}
+
+// Client calls the API as a user with an access token
+type Client struct {
+	Endpoint    string
+	UserName    string
+	AccessToken string
+	HTTPClient  *http.Client
+	// Debug logs the requests and responses at debug level
+	Debug bool
+}
+
+// Call calls the method at path, such as
+// sms/service/CreativeService/getCreative, and returns the data of the
+// response body
+func (c *Client) Call(ctx context.Context, path string, body map[string]interface{}) ([]ads.Map, error) {
+	b, err := json.Marshal(map[string]interface{}{
+		"header": map[string]interface{}{
+			"userName":    c.UserName,
+			"accessToken": c.AccessToken,
+		},
+		"body": body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/"+strings.TrimPrefix(path, "/"), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json;charset=utf-8")
+
+	rspBody, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Header struct {
+			Status   int       `json:"status"`
+			Desc     string    `json:"desc"`
+			Failures []Failure `json:"failures"`
+		} `json:"header"`
+		Body json.RawMessage `json:"body"`
+	}
+	if err := json.Unmarshal(rspBody, &r); err != nil {
+		return nil, fmt.Errorf("baidu: %s: %w", path, err)
+	}
+	// status 1 is a partial success, its data is kept
+	if r.Header.Status != 0 && r.Header.Status != 1 {
+		return nil, &Error{Status: r.Header.Status, Desc: r.Header.Desc, Failures: r.Header.Failures}
+	}
+	if len(r.Body) == 0 || string(r.Body) == "null" {
+		return nil, nil
+	}
+
+	data, err := ads.FromJSON(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("baidu: %s: %w", path, err)
+	}
+	return data.Get("data").ObjxMapSlice(), nil
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	return httpapi.Doer{Name: "baidu", HTTPClient: c.HTTPClient, Debug: c.Debug}.Do(req)
+}
@@ -0,0 +1,359 @@
+package catalog
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS assets (
+	account_id   TEXT    NOT NULL,
+	page_type    TEXT    NOT NULL,
+	asset_id     TEXT    NOT NULL,
+	account_name TEXT    NOT NULL DEFAULT '',
+	name         TEXT    NOT NULL DEFAULT '',
+	sub_type     TEXT    NOT NULL DEFAULT '',
+	signature    TEXT    NOT NULL DEFAULT '',
+	version      TEXT    NOT NULL DEFAULT '',
+	primary_url  TEXT    NOT NULL DEFAULT '',
+	data         TEXT    NOT NULL,
+	first_seen   INTEGER NOT NULL,
+	last_seen    INTEGER NOT NULL,
+	removed_at   INTEGER,
+	PRIMARY KEY (account_id, page_type, asset_id)
+);
+
+CREATE TABLE IF NOT EXISTS asset_creatives (
+	account_id      TEXT    NOT NULL,
+	page_type       TEXT    NOT NULL,
+	asset_id        TEXT    NOT NULL,
+	adcreative_id   TEXT    NOT NULL,
+	adcreative_name TEXT    NOT NULL DEFAULT '',
+	first_seen      INTEGER NOT NULL,
+	last_seen       INTEGER NOT NULL,
+	PRIMARY KEY (account_id, page_type, asset_id, adcreative_id)
+);
+
+CREATE TABLE IF NOT EXISTS syncs (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id TEXT    NOT NULL,
+	synced_at  INTEGER NOT NULL,
+	added      INTEGER NOT NULL,
+	updated    INTEGER NOT NULL,
+	unchanged  INTEGER NOT NULL,
+	removed    INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS watermarks (
+	account_id TEXT    NOT NULL PRIMARY KEY,
+	watermark  INTEGER NOT NULL
+);
+`
+
+// Catalog is a local SQLite store of synced assets
+type Catalog struct {
+	db *sql.DB
+}
+
+// Entry is a catalogued asset with its sync history
+type Entry struct {
+	*ads.Asset
+	Adcreatives map[string]string // adcreative id => name
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	RemovedAt   time.Time // zero while the asset is still present
+}
+
+// Removed reports whether the asset was missing from a later sync
+func (e *Entry) Removed() bool {
+	return !e.RemovedAt.IsZero()
+}
+
+// SyncResult summarizes the changes applied by a sync
+type SyncResult struct {
+	AccountID string
+	Added     int
+	Updated   int
+	Unchanged int
+	Removed   int
+}
+
+// Open opens or creates the catalog database at path
+func Open(path string) (*Catalog, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// sqlite only allows a single writer
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init catalog schema: %w", err)
+	}
+
+	return &Catalog{db: db}, nil
+}
+
+// Close closes the underlying database
+func (c *Catalog) Close() error {
+	return c.db.Close()
+}
+
+// Sync upserts the complete asset list of an account seen at time at, assets
+// of the account missing from the list are marked as removed.
+func (c *Catalog) Sync(accountID string, assets []*ads.Asset, at time.Time) (*SyncResult, error) {
+	return c.sync(accountID, assets, at, true)
+}
+
+// Upsert inserts or updates assets without marking missing ones as removed,
+// for partial syncs.
+func (c *Catalog) Upsert(accountID string, assets []*ads.Asset, at time.Time) (*SyncResult, error) {
+	return c.sync(accountID, assets, at, false)
+}
+
+func (c *Catalog) sync(accountID string, assets []*ads.Asset, at time.Time, full bool) (result *SyncResult, err error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var (
+		now  = at.Unix()
+		seen = make(map[string]bool)
+	)
+
+	result = &SyncResult{AccountID: accountID}
+	for _, asset := range assets {
+		if asset.AccountID == "" {
+			asset.AccountID = accountID
+		}
+
+		// creative links are tracked separately, keep them out of the
+		// stored data so an asset shared by creatives compares equal
+		stored := *asset
+		stored.AdcreativeID, stored.AdcreativeName = "", ""
+
+		pageType := asset.PageType.String()
+		b, err := json.Marshal(&stored)
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			data      string
+			removedAt sql.NullInt64
+			key       = asset.AccountID + "/" + pageType + "/" + asset.AssetID
+		)
+		err = tx.QueryRow(`SELECT data, removed_at FROM assets WHERE account_id = ? AND page_type = ? AND asset_id = ?`,
+			asset.AccountID, pageType, asset.AssetID).Scan(&data, &removedAt)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			_, err = tx.Exec(`INSERT INTO assets
+				(account_id, page_type, asset_id, account_name, name, sub_type, signature, version, primary_url, data, first_seen, last_seen)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				asset.AccountID, pageType, asset.AssetID, asset.AccountName, asset.Name, asset.SubType,
+				asset.Signature, asset.Version, asset.PrimaryUrl(), string(b), now, now)
+			if err != nil {
+				return nil, err
+			}
+			result.Added++
+		case err != nil:
+			return nil, err
+		default:
+			_, err = tx.Exec(`UPDATE assets SET
+				account_name = ?, name = ?, sub_type = ?, signature = ?, version = ?, primary_url = ?, data = ?,
+				last_seen = ?, removed_at = NULL
+				WHERE account_id = ? AND page_type = ? AND asset_id = ?`,
+				asset.AccountName, asset.Name, asset.SubType, asset.Signature, asset.Version, asset.PrimaryUrl(), string(b),
+				now, asset.AccountID, pageType, asset.AssetID)
+			if err != nil {
+				return nil, err
+			}
+
+			// the same asset may be listed once per adcreative
+			switch {
+			case seen[key]:
+			case removedAt.Valid:
+				result.Added++
+			case data != string(b):
+				result.Updated++
+			default:
+				result.Unchanged++
+			}
+		}
+		seen[key] = true
+
+		if asset.AdcreativeID != "" && asset.AdcreativeID != "0" {
+			_, err = tx.Exec(`INSERT INTO asset_creatives
+				(account_id, page_type, asset_id, adcreative_id, adcreative_name, first_seen, last_seen)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (account_id, page_type, asset_id, adcreative_id)
+				DO UPDATE SET adcreative_name = excluded.adcreative_name, last_seen = excluded.last_seen`,
+				asset.AccountID, pageType, asset.AssetID, asset.AdcreativeID, asset.AdcreativeName, now, now)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if full {
+		if result.Removed, err = markRemoved(tx, accountID, seen, now); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO syncs (account_id, synced_at, added, updated, unchanged, removed) VALUES (?, ?, ?, ?, ?, ?)`,
+		accountID, now, result.Added, result.Updated, result.Unchanged, result.Removed)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, tx.Commit()
+}
+
+// markRemoved marks the assets of the account missing from the seen keys
+// removed at now. The keys of the sync are compared rather than last_seen,
+// two syncs within a second share their timestamp.
+func markRemoved(tx *sql.Tx, accountID string, seen map[string]bool, now int64) (int, error) {
+	rows, err := tx.Query(`SELECT page_type, asset_id FROM assets WHERE account_id = ? AND removed_at IS NULL`, accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	var missing [][2]string
+	for rows.Next() {
+		var pageType, assetID string
+		if err := rows.Scan(&pageType, &assetID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if !seen[accountID+"/"+pageType+"/"+assetID] {
+			missing = append(missing, [2]string{pageType, assetID})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, key := range missing {
+		_, err := tx.Exec(`UPDATE assets SET removed_at = ? WHERE account_id = ? AND page_type = ? AND asset_id = ?`,
+			now, accountID, key[0], key[1])
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(missing), nil
+}
+
+// Watermark returns the incremental sync watermark of an account, zero when
+// the account was never synced incrementally
+func (c *Catalog) Watermark(accountID string) (time.Time, error) {
+	var watermark int64
+	err := c.db.QueryRow(`SELECT watermark FROM watermarks WHERE account_id = ?`, accountID).Scan(&watermark)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(watermark, 0), nil
+}
+
+// SetWatermark stores the incremental sync watermark of an account
+func (c *Catalog) SetWatermark(accountID string, watermark time.Time) error {
+	_, err := c.db.Exec(`INSERT INTO watermarks (account_id, watermark) VALUES (?, ?)
+		ON CONFLICT (account_id) DO UPDATE SET watermark = excluded.watermark`,
+		accountID, watermark.Unix())
+	return err
+}
+
+// Entries returns the catalogued assets of an account, all accounts when
+// accountID is empty
+func (c *Catalog) Entries(accountID string, includeRemoved bool) ([]*Entry, error) {
+	query := `SELECT account_id, page_type, asset_id, data, first_seen, last_seen, removed_at FROM assets WHERE 1 = 1`
+	var args []interface{}
+	if accountID != "" {
+		query += ` AND account_id = ?`
+		args = append(args, accountID)
+	}
+	if !includeRemoved {
+		query += ` AND removed_at IS NULL`
+	}
+	query += ` ORDER BY account_id, first_seen, page_type, asset_id`
+
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		entries []*Entry
+		index   = make(map[string]*Entry)
+	)
+	for rows.Next() {
+		var (
+			accID, pageType, assetID, data string
+			firstSeen, lastSeen            int64
+			removedAt                      sql.NullInt64
+		)
+		if err := rows.Scan(&accID, &pageType, &assetID, &data, &firstSeen, &lastSeen, &removedAt); err != nil {
+			return nil, err
+		}
+
+		var asset ads.Asset
+		if err := json.Unmarshal([]byte(data), &asset); err != nil {
+			return nil, fmt.Errorf("decode asset %s/%s: %w", accID, assetID, err)
+		}
+
+		entry := &Entry{
+			Asset:       &asset,
+			Adcreatives: make(map[string]string),
+			FirstSeen:   time.Unix(firstSeen, 0),
+			LastSeen:    time.Unix(lastSeen, 0),
+		}
+		if removedAt.Valid {
+			entry.RemovedAt = time.Unix(removedAt.Int64, 0)
+		}
+		entries = append(entries, entry)
+		index[accID+"/"+pageType+"/"+assetID] = entry
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	links, err := c.db.Query(`SELECT account_id, page_type, asset_id, adcreative_id, adcreative_name
+		FROM asset_creatives ORDER BY last_seen DESC, adcreative_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer links.Close()
+
+	for links.Next() {
+		var accID, pageType, assetID, adcreativeID, adcreativeName string
+		if err := links.Scan(&accID, &pageType, &assetID, &adcreativeID, &adcreativeName); err != nil {
+			return nil, err
+		}
+		if entry, ok := index[accID+"/"+pageType+"/"+assetID]; ok {
+			entry.Adcreatives[adcreativeID] = adcreativeName
+			if entry.AdcreativeID == "" {
+				entry.AdcreativeID, entry.AdcreativeName = adcreativeID, adcreativeName
+			}
+		}
+	}
+
+	return entries, links.Err()
+}
@@ -0,0 +1,163 @@
+package catalog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+)
+
+func open(t *testing.T) *Catalog {
+	t.Helper()
+
+	cat, err := Open(filepath.Join(t.TempDir(), "ads.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cat.Close() })
+	return cat
+}
+
+func image(id, url string) *ads.Asset {
+	return &ads.Asset{
+		AccountID:    "1001",
+		AssetID:      id,
+		AdcreativeID: "c" + id,
+		PageType:     ads.PTImage,
+		SubAssets:    []*ads.SubAsset{{Type: ads.SATImage, Url: url}},
+	}
+}
+
+func result(t *testing.T, r *SyncResult, err error, added, updated, unchanged, removed int) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Added != added || r.Updated != updated || r.Unchanged != unchanged || r.Removed != removed {
+		t.Errorf("result = %+v, want added %d updated %d unchanged %d removed %d", r, added, updated, unchanged, removed)
+	}
+}
+
+func entries(t *testing.T, cat *Catalog, includeRemoved bool) map[string]*Entry {
+	t.Helper()
+
+	list, err := cat.Entries("1001", includeRemoved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[string]*Entry)
+	for _, e := range list {
+		m[e.AssetID] = e
+	}
+	return m
+}
+
+func TestSync(t *testing.T) {
+	cat := open(t)
+	at := time.Unix(1700000000, 0)
+
+	r, err := cat.Sync("1001", []*ads.Asset{image("1", "a.jpg"), image("2", "b.jpg")}, at)
+	result(t, r, err, 2, 0, 0, 0)
+
+	// the second sync runs within the same second
+	r, err = cat.Sync("1001", []*ads.Asset{image("1", "a2.jpg")}, at)
+	result(t, r, err, 0, 1, 0, 1)
+
+	got := entries(t, cat, true)
+	if len(got) != 2 || got["1"].Removed() || !got["2"].Removed() {
+		t.Fatalf("entries = %v", got)
+	}
+	if url := got["1"].PrimaryUrl(); url != "a2.jpg" {
+		t.Errorf("url = %q", url)
+	}
+	if _, ok := got["1"].Adcreatives["c1"]; !ok || got["1"].AdcreativeID != "c1" {
+		t.Errorf("adcreatives = %v", got["1"].Adcreatives)
+	}
+	if len(entries(t, cat, false)) != 1 {
+		t.Error("removed asset listed")
+	}
+
+	// a reappearing asset is added again
+	later := at.Add(time.Hour)
+	r, err = cat.Sync("1001", []*ads.Asset{image("1", "a2.jpg"), image("2", "b.jpg")}, later)
+	result(t, r, err, 1, 0, 1, 0)
+
+	got = entries(t, cat, false)
+	if len(got) != 2 || got["2"].Removed() {
+		t.Fatalf("entries = %v", got)
+	}
+	if !got["2"].FirstSeen.Equal(at) || !got["2"].LastSeen.Equal(later) {
+		t.Errorf("asset 2 seen %v to %v", got["2"].FirstSeen, got["2"].LastSeen)
+	}
+}
+
+func TestSyncSharedAsset(t *testing.T) {
+	cat := open(t)
+
+	shared := image("1", "a.jpg")
+	other := image("1", "a.jpg")
+	other.AdcreativeID = "c2"
+	r, err := cat.Sync("1001", []*ads.Asset{shared, other}, time.Unix(1700000000, 0))
+	result(t, r, err, 1, 0, 0, 0)
+
+	if e := entries(t, cat, false)["1"]; len(e.Adcreatives) != 2 {
+		t.Errorf("adcreatives = %v", e.Adcreatives)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	cat := open(t)
+	at := time.Unix(1700000000, 0)
+
+	r, err := cat.Sync("1001", []*ads.Asset{image("1", "a.jpg"), image("2", "b.jpg")}, at)
+	result(t, r, err, 2, 0, 0, 0)
+
+	r, err = cat.Upsert("1001", []*ads.Asset{image("2", "b2.jpg"), image("3", "c.jpg")}, at.Add(time.Minute))
+	result(t, r, err, 1, 1, 0, 0)
+
+	got := entries(t, cat, true)
+	if len(got) != 3 {
+		t.Fatalf("entries = %v", got)
+	}
+	for id, e := range got {
+		if e.Removed() {
+			t.Errorf("asset %s removed by an upsert", id)
+		}
+	}
+}
+
+func TestSyncAccounts(t *testing.T) {
+	cat := open(t)
+	at := time.Unix(1700000000, 0)
+
+	other := image("9", "z.jpg")
+	other.AccountID = "2002"
+	if _, err := cat.Sync("2002", []*ads.Asset{other}, at); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := cat.Sync("1001", nil, at)
+	result(t, r, err, 0, 0, 0, 0)
+	if list, err := cat.Entries("2002", false); err != nil || len(list) != 1 {
+		t.Errorf("entries of the other account = %v, %v", list, err)
+	}
+}
+
+func TestWatermark(t *testing.T) {
+	cat := open(t)
+
+	w, err := cat.Watermark("1001")
+	if err != nil || !w.IsZero() {
+		t.Fatalf("watermark = %v, %v", w, err)
+	}
+
+	want := time.Unix(1700000000, 0)
+	if err := cat.SetWatermark("1001", want); err != nil {
+		t.Fatal(err)
+	}
+	if w, err := cat.Watermark("1001"); err != nil || !w.Equal(want) {
+		t.Errorf("watermark = %v, %v", w, err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+)
+
+// runAccounts
+func runAccounts(args []string) int {
+	var (
+		fs  = newFlagSet("accounts")
+		src sourceFlags
+	)
+	src.register(fs)
+
+	log, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if err := src.setup(); err != nil {
+		log.Error(err)
+		return exitUsage
+	}
+
+	creds, err := src.credentials()
+	if err != nil {
+		log.Error(err)
+		return exitError
+	}
+
+	// status and balance are known for accounts discovered from an agency
+	for _, cred := range creds {
+		var status, balance string
+		if acc := cred.Account; acc != nil {
+			status, balance = acc.Status, fmt.Sprintf("%.2f", float64(acc.Balance)/100)
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\n", src.provider, cred.AccountID, cred.AccountName, status, balance)
+	}
+	return exitOK
+}
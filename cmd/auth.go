@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnhuaxi/ads/credentials"
+	"github.com/hnhuaxi/ads/gdt/oauth"
+	"github.com/hysios/x/utils"
+)
+
+// runAuth authorizes an account with the OAuth code flow and saves its token
+func runAuth(args []string) int {
+	fs := newFlagSet("auth")
+	var (
+		clientID     = fs.String("client_id", "", "app client id, defaults to $GDT_CLIENT_ID")
+		clientSecret = fs.String("client_secret", "", "app client secret or secret:NAME, defaults to $GDT_CLIENT_SECRET or the secret GDT/client_secret")
+		secrets      = fs.String("secrets", "", "secret store of secret:NAME values, defaults to $ADS_SECRETS or "+credentials.DefaultFile()+" if it exists")
+		redirectURL  = fs.String("redirect_uri", "http://localhost:8080/callback", "callback registered for the app, served on its host")
+		paste        = fs.Bool("paste", false, "paste the redirected url instead of serving the callback")
+		tokenFile    = fs.String("token_file", oauth.DefaultTokenFile(), "file the tokens are saved to")
+		account      = fs.String("account", "", "key of the saved token, defaults to the authorizing account id")
+		timeout      = fs.Duration("timeout", 5*time.Minute, "how long to wait for the callback")
+		debug        = fs.Bool("debug", false, "debug mode")
+	)
+
+	log, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+
+	id, err := strconv.ParseInt(utils.Default(*clientID, os.Getenv("GDT_CLIENT_ID")), 10, 64)
+	if err != nil {
+		log.Error("no valid client id given, use -client_id or $GDT_CLIENT_ID")
+		return exitUsage
+	}
+	secret, err := resolveSecret(openSecrets(secretsLocation(*secrets, "")),
+		utils.Default(*clientSecret, os.Getenv("GDT_CLIENT_SECRET"), secretPrefix+"GDT/client_secret"))
+	if errors.Is(err, credentials.ErrNotFound) {
+		log.Error("no client secret given, use -client_secret, $GDT_CLIENT_SECRET or the secret GDT/client_secret")
+		return exitUsage
+	}
+	if err != nil {
+		log.Error(err)
+		return exitError
+	}
+	conf := &oauth.Config{
+		ClientID:     id,
+		ClientSecret: secret,
+		RedirectURL:  *redirectURL,
+		Debug:        *debug,
+	}
+
+	redirect, err := url.Parse(conf.RedirectURL)
+	if err != nil || (!*paste && redirect.Scheme != "http") {
+		log.Errorf("invalid redirect_uri %q, serving the callback needs a http url, or use -paste", conf.RedirectURL)
+		return exitUsage
+	}
+
+	state := randomState()
+	fmt.Fprintf(os.Stderr, "open this url to authorize the app:\n\n  %s\n\n", conf.AuthCodeURL(state))
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var code string
+	if *paste {
+		code, err = pasteCode(state)
+	} else {
+		code, err = serveCallback(ctx, redirect, state)
+	}
+	if err != nil {
+		log.Errorf("authorize error: %v", err)
+		return exitError
+	}
+
+	token, err := conf.Exchange(ctx, code)
+	if err != nil {
+		log.Errorf("exchange code error: %v", err)
+		return exitError
+	}
+
+	key := utils.Default(*account, token.AccountID)
+	if key == "" {
+		log.Error("the token names no account, use -account")
+		return exitUsage
+	}
+	if err := oauth.NewFileStore(*tokenFile).Save(key, token); err != nil {
+		log.Errorf("save token error: %v", err)
+		return exitError
+	}
+
+	log.Infow("saved token", "account", key, "file", *tokenFile, "expiry", token.Expiry, "refresh_expiry", token.RefreshExpiry)
+	return exitOK
+}
+
+func randomState() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// serveCallback serves the redirect url until it receives a code or the
+// error of a denied authorization
+func serveCallback(ctx context.Context, redirect *url.URL, state string) (string, error) {
+	addr := redirect.Host
+	if redirect.Port() == "" {
+		addr = net.JoinHostPort(redirect.Hostname(), "80")
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	done := make(chan result, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(utils.Default(redirect.Path, "/"), func(w http.ResponseWriter, r *http.Request) {
+		code, err := callbackCode(r.URL.Query(), state)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			// requests of stale pages and the browser are ignored
+			var denied *deniedError
+			if !errors.As(err, &denied) {
+				return
+			}
+		} else {
+			fmt.Fprintln(w, "authorized, you can close this page")
+		}
+
+		select {
+		case done <- result{code, err}:
+		default:
+		}
+	})
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	select {
+	case res := <-done:
+		return res.code, res.err
+	case <-ctx.Done():
+		return "", errors.New("timed out waiting for the callback")
+	}
+}
+
+// pasteCode reads the redirected url, or the bare code, from stdin
+func pasteCode(state string) (string, error) {
+	fmt.Fprint(os.Stderr, "paste the url the browser was redirected to: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+
+	line = strings.TrimSpace(line)
+	u, err := url.Parse(line)
+	if err != nil || u.RawQuery == "" {
+		return line, nil
+	}
+	return callbackCode(u.Query(), state)
+}
+
+// deniedError is the error of a callback of a denied authorization
+type deniedError struct {
+	Code        string
+	Description string
+}
+
+func (e *deniedError) Error() string {
+	if e.Description == "" {
+		return "authorization denied: " + e.Code
+	}
+	return "authorization denied: " + e.Code + ": " + e.Description
+}
+
+func callbackCode(query url.Values, state string) (string, error) {
+	if query.Get("state") != state {
+		return "", errors.New("state mismatch")
+	}
+	if e := query.Get("error"); e != "" {
+		return "", &deniedError{Code: e, Description: query.Get("error_description")}
+	}
+	code := query.Get("authorization_code")
+	if code == "" {
+		code = query.Get("code")
+	}
+	if code == "" {
+		return "", errors.New("no authorization code in the callback")
+	}
+	return code, nil
+}
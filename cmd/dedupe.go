@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hnhuaxi/ads"
+)
+
+// runDedupe
+func runDedupe(args []string) int {
+	var (
+		fs  = newFlagSet("dedupe")
+		src inputFlags
+	)
+	src.register(fs)
+	var (
+		threshold = fs.Int("threshold", 6, "max hamming distance between near-duplicate images")
+		output    = fs.String("output", "-", "write report to file, - for stdout")
+	)
+
+	log, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if err := src.setup(); err != nil {
+		log.Error(err)
+		return exitUsage
+	}
+
+	assets, err := src.load(log)
+	if err != nil {
+		log.Errorf("get assets error: %v", err)
+		return exitError
+	}
+
+	hashed := ads.HashAssets(nil, assets)
+	log.Infow("hash assets", "hashed", hashed)
+
+	clusters := ads.ClusterDuplicates(assets, *threshold)
+	if err := writeFile(*output, func(w io.Writer) error {
+		return writeDuplicates(w, clusters)
+	}); err != nil {
+		log.Errorf("write duplicates report error: %v", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// writeDuplicates
+func writeDuplicates(w io.Writer, clusters []*ads.DuplicateCluster) error {
+	fmt.Fprintf(w, "%d near-duplicate clusters\n", len(clusters))
+	for i, c := range clusters {
+		fmt.Fprintf(w, "\ncluster %d (%d assets)\n", i+1, len(c.Assets))
+		for j, asset := range c.Assets {
+			_, err := fmt.Fprintf(w, "  distance=%-2d account=%s asset=%s type=%s phash=%s name=%q url=%s\n",
+				c.Distances[j], asset.AccountID, asset.AssetID, asset.PageType, asset.PHash, asset.Name, asset.ImageUrl())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
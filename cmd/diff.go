@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hnhuaxi/ads"
+)
+
+// runDiff
+func runDiff(args []string) int {
+	fs := newFlagSet("diff")
+	var (
+		format = fs.String("format", "text", "change set format, text or json")
+		output = fs.String("output", "-", "write change set to file, - for stdout")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		return exitUsage
+	}
+
+	old, err := ads.ReadAssetsFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read %s: %v\n", fs.Arg(0), err)
+		return exitError
+	}
+
+	new, err := ads.ReadAssetsFile(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read %s: %v\n", fs.Arg(1), err)
+		return exitError
+	}
+
+	changes := ads.Diff(old, new)
+	err = writeFile(*output, func(w io.Writer) error {
+		if *format == "json" {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(changes)
+		}
+		return writeChanges(w, changes)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write changes: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// writeChanges
+func writeChanges(w io.Writer, changes []*ads.Change) error {
+	var counts = make(map[ads.ChangeKind]int)
+	for _, c := range changes {
+		counts[c.Kind]++
+
+		switch c.Kind {
+		case ads.ChangeAdded:
+			fmt.Fprintf(w, "+ %s %q\n", c.Key, c.New.Name)
+		case ads.ChangeRemoved:
+			fmt.Fprintf(w, "- %s %q\n", c.Key, c.Old.Name)
+		case ads.ChangeModified:
+			fmt.Fprintf(w, "~ %s %q\n", c.Key, c.New.Name)
+			for _, f := range c.Fields {
+				fmt.Fprintf(w, "    %s: %q => %q\n", f.Field, f.Old, f.New)
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d modified\n",
+		counts[ads.ChangeAdded], counts[ads.ChangeRemoved], counts[ads.ChangeModified])
+	return err
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hnhuaxi/ads"
+)
+
+type downloadJob struct {
+	Url      string
+	Filename string // without extension
+}
+
+// runDownload
+func runDownload(args []string) int {
+	var (
+		fs  = newFlagSet("download")
+		src inputFlags
+	)
+	src.register(fs)
+	var (
+		dir      = fs.String("dir", "downloads", "download directory")
+		types    = fs.String("types", "image,video", "comma separated sub asset types to download, image and/or video")
+		parallel = fs.Int("parallel", 4, "concurrent downloads")
+		force    = fs.Bool("force", false, "download files that already exist")
+	)
+
+	log, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if err := src.setup(); err != nil {
+		log.Error(err)
+		return exitUsage
+	}
+
+	wanted := make(map[ads.SubAssetType]bool)
+	for _, typ := range strings.Split(*types, ",") {
+		switch strings.TrimSpace(typ) {
+		case "image":
+			wanted[ads.SATImage] = true
+		case "video":
+			wanted[ads.SATVideo] = true
+		default:
+			log.Errorf("unknown download type %q", typ)
+			return exitUsage
+		}
+	}
+
+	assets, err := src.load(log)
+	if err != nil {
+		log.Errorf("get assets error: %v", err)
+		return exitError
+	}
+
+	var (
+		jobs = make(chan *downloadJob)
+		seen = make(map[string]bool)
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		fail int
+	)
+
+	for i := 0; i < max(*parallel, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if err := download(job, *force); err != nil {
+					log.Warnw("download error", "url", job.Url, "error", err)
+					mu.Lock()
+					fail++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	var total int
+	for _, asset := range assets {
+		for i, sub := range asset.SubAssets {
+			if !wanted[sub.Type] || sub.Url == "" || seen[sub.Url] {
+				continue
+			}
+			seen[sub.Url] = true
+			total++
+
+			name := fmt.Sprintf("%s-%d", sanitize(asset.AssetID), i)
+			jobs <- &downloadJob{
+				Url:      sub.Url,
+				Filename: filepath.Join(*dir, sanitize(asset.AccountID), strings.ToLower(strings.TrimPrefix(sub.Type.String(), "SAT")), name),
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	log.Infow("download finished", "files", total, "failed", fail)
+	if fail > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+// download saves the url of a job unless a file for it already exists
+func download(job *downloadJob, force bool) error {
+	if !force {
+		if matches, _ := filepath.Glob(job.Filename + ".*"); len(matches) > 0 {
+			return nil
+		}
+	}
+
+	resp, err := http.Get(job.Url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(job.Filename), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(job.Filename), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), job.Filename+extension(job.Url, resp.Header.Get("Content-Type")))
+}
+
+// extension guesses the file extension from the url path or content type
+func extension(rawUrl, contentType string) string {
+	if u, err := url.Parse(rawUrl); err == nil {
+		if ext := path.Ext(u.Path); len(ext) > 1 && len(ext) <= 5 {
+			return strings.ToLower(ext)
+		}
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ".bin"
+}
+
+func sanitize(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, name)
+}
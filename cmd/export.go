@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/catalog"
+	"github.com/hysios/x/utils"
+)
+
+// runExport
+func runExport(args []string) int {
+	var (
+		fs  = newFlagSet("export")
+		src sourceFlags
+	)
+	src.register(fs)
+	var (
+		output      = fs.String("output", "-", "output file, - for stdout")
+		format      = fs.String("format", "", "output format, one of "+strings.Join(ads.ExportFormats(), ", ")+", defaults to the output file extension or csv")
+		catalogFile = fs.String("catalog", "", "export the assets of a sqlite catalog instead of the provider")
+		columns     = fs.String("columns", "", "comma separated columns, defaults to all fields, available: "+strings.Join(columnNames(), ","))
+		explode     = fs.Bool("explode", false, "write one csv row per sub asset")
+		delimiter   = fs.String("delimiter", ",", "csv field delimiter, use tab for tab separated")
+		bom         = fs.Bool("bom", false, "write a UTF-8 byte order mark so Excel detects the encoding")
+		phash       = fs.Bool("phash", false, "compute perceptual hashes of images and video cover images")
+	)
+
+	log, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if err := src.setup(); err != nil {
+		log.Error(err)
+		return exitUsage
+	}
+
+	// the profile output settings apply to the flags not given
+	if p := src.profile; p != nil {
+		set := make(map[string]bool)
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		out := p.Output
+		if !set["output"] && out.File != "" {
+			*output = out.File
+		}
+		if !set["format"] && out.Format != "" {
+			*format = out.Format
+		}
+		if !set["columns"] && len(out.Columns) > 0 {
+			*columns = strings.Join(out.Columns, ",")
+		}
+		if !set["delimiter"] && out.Delimiter != "" {
+			*delimiter = out.Delimiter
+		}
+		*explode = *explode || out.Explode
+		*bom = *bom || out.BOM
+	}
+
+	if *format == "" {
+		*format = utils.Default(strings.TrimPrefix(filepath.Ext(*output), "."), "csv")
+	}
+
+	opts := ads.ExportOptions{
+		Explode: *explode,
+		BOM:     *bom,
+	}
+	if *columns != "" {
+		opts.Columns = strings.Split(*columns, ",")
+	}
+	switch d := []rune(*delimiter); {
+	case *delimiter == "tab" || *delimiter == `\t`:
+		opts.Comma = '\t'
+	case len(d) == 1:
+		opts.Comma = d[0]
+	default:
+		log.Errorf("invalid delimiter %q", *delimiter)
+		return exitUsage
+	}
+
+	if _, err := ads.NewExporter(*format, io.Discard, opts); err != nil {
+		log.Error(err)
+		return exitUsage
+	}
+
+	var assets []*ads.Asset
+	if *catalogFile != "" {
+		assets, err = catalogAssets(*catalogFile, src.accounts)
+	} else {
+		var ids []string
+		if ids, err = src.accountIDs(); err != nil {
+			log.Error(err)
+			return exitError
+		}
+		if len(ids) == 0 {
+			log.Error("no accounts given, use -account, -agency, -config or $GDT_ACCOUNT_ID")
+			return exitUsage
+		}
+		assets, err = src.fetch(log)
+	}
+	if err != nil {
+		log.Errorf("get assets error: %v", err)
+		return exitError
+	}
+
+	if *phash {
+		hashed := ads.HashAssets(nil, assets)
+		log.Infow("hash assets", "hashed", hashed)
+	}
+
+	if err := writeFile(*output, func(w io.Writer) error {
+		exporter, err := ads.NewExporter(*format, w, opts)
+		if err != nil {
+			return err
+		}
+		if err := exporter.Write(assets); err != nil {
+			return err
+		}
+		return exporter.Close()
+	}); err != nil {
+		log.Errorf("write output error: %v", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func columnNames() []string {
+	var names []string
+	for _, c := range ads.Columns() {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// catalogAssets returns the present assets of a catalog, of all accounts when
+// none are given
+func catalogAssets(filename string, accounts []string) ([]*ads.Asset, error) {
+	cat, err := catalog.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer cat.Close()
+
+	if len(accounts) == 0 {
+		accounts = []string{""}
+	}
+
+	var assets []*ads.Asset
+	for _, accId := range accounts {
+		entries, err := cat.Entries(accId, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			assets = append(assets, entry.Asset)
+		}
+	}
+	return assets, nil
+}
+
+// writeFile calls write with the created file, or stdout for -
+func writeFile(filename string, write func(w io.Writer) error) error {
+	if filename == "-" || filename == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
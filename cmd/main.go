@@ -1,108 +1,141 @@
 package main
 
 import (
-	"encoding/csv"
-	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"os"
 
-	"github.com/fatih/structs"
-	"github.com/hnhuaxi/ads"
-	"github.com/hysios/x/utils"
-
+	_ "github.com/hnhuaxi/ads/baidu"
+	_ "github.com/hnhuaxi/ads/file"
 	_ "github.com/hnhuaxi/ads/gdt"
+	_ "github.com/hnhuaxi/ads/googleads"
+	_ "github.com/hnhuaxi/ads/kuaishou"
+	_ "github.com/hnhuaxi/ads/meta"
+	_ "github.com/hnhuaxi/ads/mock"
+	_ "github.com/hnhuaxi/ads/oceanengine"
+	_ "github.com/hnhuaxi/ads/parquet"
+	"github.com/hnhuaxi/ads/redact"
+	_ "github.com/hnhuaxi/ads/xiaohongshu"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-type arrayFlags []string
-
-func (i *arrayFlags) String() string {
-	return "accounts list"
-}
+// exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
 
-func (i *arrayFlags) Set(value string) error {
-	*i = append(*i, value)
-	return nil
+type command struct {
+	Name  string
+	Args  string
+	Short string
+	Run   func(args []string) int
 }
 
-var (
-	accessToken     = flag.String("access_token", "", "access token")
-	debug           = flag.Bool("debug", false, "debug mode")
-	provider        = flag.String("provider", "GDT", "provider")
-	verbose         = flag.Bool("verbose", false, "verbose")
-	onlyAdcreatives = flag.Bool("only_adcreatives", false, "only adcreatives")
-	csvFile         = flag.String("output", "", "output to csv file")
-)
+var commands []*command
 
-var accounts arrayFlags
+func init() {
+	commands = []*command{
+		{"sync", "[flags]", "sync account assets into a sqlite catalog", runSync},
+		{"export", "[flags]", "export account assets to a file", runExport},
+		{"diff", "[flags] old new", "diff two exported snapshots", runDiff},
+		{"download", "[flags]", "download image and video files of assets", runDownload},
+		{"dedupe", "[flags]", "report near-duplicate images by perceptual hash", runDedupe},
+		{"accounts", "[flags]", "list the accounts to sync", runAccounts},
+		{"auth", "[flags]", "authorize a GDT account with OAuth and save its token", runAuth},
+		{"secrets", "[flags] set|get|delete name | list", "manage the tokens and app secrets of the encrypted secret store", runSecrets},
+		{"providers", "[flags] [name...]", "list the registered providers, or describe the named ones with their settings", runProviders},
+	}
+}
 
 func main() {
-	flag.Var(&accounts, "account", "ad account id.")
-	flag.Parse()
-
-	var (
-		token  = utils.Default(*accessToken, os.Getenv("GDT_ACCESS_TOKEN"))
-		output *csv.Writer
-	)
+	os.Exit(run(os.Args[1:]))
+}
 
-	log := setLogger(*verbose)
-	if len(accounts) == 0 {
-		accounts = append(accounts, os.Getenv("GDT_ACCOUNT_ID"))
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return exitUsage
 	}
-	log.Infow("list accounts", "accounts", accounts)
 
-	if *csvFile != "" {
-		file, err := os.Create(*csvFile)
-		if err != nil {
-			log.Fatalf("create csv file error: %v", err)
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			if cmd := lookupCommand(args[1]); cmd != nil {
+				return cmd.Run([]string{"-h"})
+			}
 		}
-		defer file.Close()
-		output = csv.NewWriter(file)
-		writeHeader(output, &ads.Asset{})
-		defer output.Flush()
+		usage()
+		return exitOK
 	}
 
-	for _, accId := range accounts {
-		get, err := ads.Open(*provider, accId, token, *debug)
-		if err != nil {
-			log.Fatalf("open provider error: %v", err)
-			return
-		}
+	cmd := lookupCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "ads: unknown command %q\n\n", args[0])
+		usage()
+		return exitUsage
+	}
 
-		if *onlyAdcreatives {
-			get.OnlyAdcreatives(true)
-		}
+	return cmd.Run(args[1:])
+}
 
-		assets, err := get.Assets()
-		if err != nil {
-			log.Fatalf("get assets error: %v", err)
-			return
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
 		}
+	}
+	return nil
+}
 
-		for _, asset := range assets {
-			log.With("asset", asset).Info("asset")
-		}
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: ads <command> [flags]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.Name, cmd.Short)
+	}
+	fmt.Fprintf(os.Stderr, "\nrun 'ads help <command>' for the flags of a command\n")
+}
 
-		if *csvFile != "" {
-			writeTo(output, assets)
-		}
+// newFlagSet creates the flag set of a subcommand with its usage text
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		cmd := lookupCommand(name)
+		fmt.Fprintf(fs.Output(), "usage: ads %s %s\n\n%s\n\nflags:\n", cmd.Name, cmd.Args, cmd.Short)
+		fs.PrintDefaults()
 	}
+	return fs
+}
+
+// parseFlags parses args with the common -verbose flag and sets up logging
+func parseFlags(fs *flag.FlagSet, args []string) (*zap.SugaredLogger, error) {
+	verbose := fs.Bool("verbose", false, "verbose logging")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return setLogger(*verbose), nil
+}
+
+// exitCode maps a flag parse error to the exit code, -h is not an error
+func exitCode(err error) int {
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	return exitUsage
 }
 
 func setLogger(verbose bool) *zap.SugaredLogger {
 	atom := zap.NewAtomicLevel()
 	encoderCfg := zap.NewProductionEncoderConfig()
 
-	logger := zap.New(zapcore.NewCore(
+	logger := zap.New(redact.Core(zapcore.NewCore(
 		zapcore.NewJSONEncoder(encoderCfg),
-		zapcore.Lock(os.Stdout),
+		zapcore.Lock(os.Stderr),
 		atom,
-	))
+	)))
 	defer logger.Sync()
-	logger.Info("info logging enabled")
 
 	if verbose {
 		atom.SetLevel(zap.DebugLevel)
@@ -111,47 +144,7 @@ func setLogger(verbose bool) *zap.SugaredLogger {
 	return logger.Sugar()
 }
 
-// writeHeader
-func writeHeader(w *csv.Writer, asset *ads.Asset) error {
-	if w == nil {
-		return errors.New("csv writer is nil")
-	}
-
-	s := structs.New(asset)
-	rows := make([]string, 0, len(s.Names()))
-	for _, name := range s.Names() {
-		rows = append(rows, name)
-	}
-
-	w.Write(rows)
-	return nil
-
-}
-
-// writeTo
-func writeTo(w *csv.Writer, assets []*ads.Asset) error {
-	if w == nil {
-		return errors.New("csv writer is nil")
-	}
-
-	for _, asset := range assets {
-		s := structs.New(asset)
-		rows := make([]string, 0, len(s.Values()))
-		for i, value := range s.Values() {
-			if i == 7 {
-				j, _ := json.Marshal(value)
-				rows = append(rows, string(j))
-			} else {
-				rows = append(rows, fmt.Sprint(value))
-			}
-		}
-
-		w.Write(rows)
-	}
-	return nil
-}
-
 func init() {
-	logger, _ := zap.NewProduction()
+	logger, _ := zap.NewProduction(zap.WrapCore(redact.Core))
 	zap.ReplaceGlobals(logger)
 }
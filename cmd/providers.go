@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hysios/x/utils"
+)
+
+// runProviders
+func runProviders(args []string) int {
+	var (
+		fs     = newFlagSet("providers")
+		format = fs.String("format", "text", "output format, text or json")
+	)
+	if _, err := parseFlags(fs, args); err != nil {
+		return exitCode(err)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		return exitUsage
+	}
+
+	infos := ads.Providers()
+	if fs.NArg() > 0 {
+		infos = nil
+		for _, name := range fs.Args() {
+			info, ok := ads.LookupProvider(strings.ToUpper(name))
+			if !ok {
+				fmt.Fprintf(os.Stderr, "provider %s not found\n", name)
+				return exitUsage
+			}
+			infos = append(infos, info)
+		}
+	}
+
+	var err error
+	switch {
+	case *format == "json":
+		err = writeProvidersJSON(os.Stdout, infos)
+	case fs.NArg() > 0:
+		err = describeProviders(os.Stdout, infos)
+	default:
+		err = writeProviders(os.Stdout, infos)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write providers: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// writeProviders writes a line per provider with its capabilities, page
+// types, credentials and description
+func writeProviders(w io.Writer, infos []ads.ProviderInfo) error {
+	for _, info := range infos {
+		_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", info.Name, capabilities(info), pageTypes(info),
+			strings.Join(info.Credentials, ","), info.Description)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeProviders writes the description of every provider with its
+// settings
+func describeProviders(w io.Writer, infos []ads.ProviderInfo) error {
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s: %s\n", info.Name, info.Description)
+		fmt.Fprintf(w, "  capabilities: %s\n", capabilities(info))
+		fmt.Fprintf(w, "  page types:   %s\n", pageTypes(info))
+		fmt.Fprintf(w, "  credentials:  %s\n", utils.Default(strings.Join(info.Credentials, ","), "none"))
+		if len(info.Settings) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "  settings:\n")
+		width := 0
+		for _, s := range info.Settings {
+			width = max(width, len(s.Name))
+		}
+		for _, s := range info.Settings {
+			desc := s.Description
+			if s.Default != "" {
+				desc += fmt.Sprintf(" (default %s)", s.Default)
+			}
+			if s.Required {
+				desc += " (required)"
+			}
+			if _, err := fmt.Fprintf(w, "    %-*s  %s\n", width, s.Name, desc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeProvidersJSON writes the providers as a json array, page types by
+// name
+func writeProvidersJSON(w io.Writer, infos []ads.ProviderInfo) error {
+	type setting struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Default     string `json:"default,omitempty"`
+		Required    bool   `json:"required,omitempty"`
+	}
+	type provider struct {
+		Name         string           `json:"name"`
+		Description  string           `json:"description"`
+		Capabilities []ads.Capability `json:"capabilities"`
+		PageTypes    []string         `json:"page_types"`
+		Credentials  []string         `json:"credentials"`
+		Settings     []setting        `json:"settings"`
+	}
+
+	out := make([]provider, 0, len(infos))
+	for _, info := range infos {
+		p := provider{
+			Name:         info.Name,
+			Description:  info.Description,
+			Capabilities: append([]ads.Capability{}, info.Capabilities...),
+			PageTypes:    []string{},
+			Credentials:  append([]string{}, info.Credentials...),
+			Settings:     []setting{},
+		}
+		for _, pt := range info.PageTypes {
+			p.PageTypes = append(p.PageTypes, pt.String())
+		}
+		for _, s := range info.Settings {
+			p.Settings = append(p.Settings, setting(s))
+		}
+		out = append(out, p)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func capabilities(info ads.ProviderInfo) string {
+	var names []string
+	for _, c := range info.Capabilities {
+		names = append(names, string(c))
+	}
+	return strings.Join(names, ",")
+}
+
+func pageTypes(info ads.ProviderInfo) string {
+	var names []string
+	for _, pt := range info.PageTypes {
+		names = append(names, pt.String())
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hnhuaxi/ads/credentials"
+	"github.com/hnhuaxi/ads/redact"
+	"github.com/hysios/x/utils"
+	"golang.org/x/term"
+)
+
+// secretPrefix marks tokens and settings looked up in the secret store
+const secretPrefix = "secret:"
+
+// secretsLocation returns the store given by -secrets, the profile or
+// $ADS_SECRETS, or the default encrypted file when it exists
+func secretsLocation(flag, profile string) string {
+	location := utils.Default(flag, profile, os.Getenv("ADS_SECRETS"))
+	if location == "" {
+		if _, err := os.Stat(credentials.DefaultFile()); err == nil {
+			location = credentials.DefaultFile()
+		}
+	}
+	return location
+}
+
+// openSecrets returns the environment store followed by the store at
+// location, opened on the first lookup so the passphrase is only asked when
+// a secret is needed
+func openSecrets(location string) credentials.Store {
+	chain := credentials.Chain{credentials.EnvStore{}}
+	if location != "" {
+		chain = append(chain, &lazyStore{location: location})
+	}
+	return chain
+}
+
+type lazyStore struct {
+	location string
+	once     sync.Once
+	store    credentials.Store
+	err      error
+}
+
+func (s *lazyStore) Get(name string) (string, error) {
+	s.once.Do(func() {
+		s.store, s.err = credentials.Open(s.location, func() ([]byte, error) {
+			return readPassphrase(false)
+		})
+	})
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.store.Get(name)
+}
+
+// resolveSecret returns value, or the secret it references as secret:NAME.
+// Resolved values are registered for redaction.
+func resolveSecret(store credentials.Store, value string) (string, error) {
+	if name, ok := strings.CutPrefix(value, secretPrefix); ok {
+		secret, err := store.Get(name)
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", name, err)
+		}
+		value = secret
+	}
+	redact.Register(value)
+	return value, nil
+}
+
+// readPassphrase reads the passphrase of the encrypted store from
+// $ADS_PASSPHRASE or the terminal, confirm asks twice for new stores
+func readPassphrase(confirm bool) ([]byte, error) {
+	if pass := os.Getenv("ADS_PASSPHRASE"); pass != "" {
+		return []byte(pass), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("no terminal to read the passphrase, set $ADS_PASSPHRASE")
+	}
+
+	fmt.Fprint(os.Stderr, "passphrase: ")
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(pass, again) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return pass, nil
+}
+
+// readSecretValue reads a secret from the terminal without echo, or from
+// piped stdin
+func readSecretValue(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "value of %s: ", name)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(b), err
+	}
+
+	b, err := io.ReadAll(bufio.NewReader(os.Stdin))
+	return strings.TrimRight(string(b), "\r\n"), err
+}
+
+// runSecrets manages the secrets of an encrypted store
+func runSecrets(args []string) int {
+	fs := newFlagSet("secrets")
+	file := fs.String("file", "", "encrypted secrets file, defaults to $ADS_SECRETS or "+credentials.DefaultFile())
+
+	log, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	location := utils.Default(*file, os.Getenv("ADS_SECRETS"), credentials.DefaultFile())
+	path := strings.TrimPrefix(location, "encrypted:")
+	if strings.Contains(path, ":") {
+		log.Errorf("%s is not an encrypted secrets file", location)
+		return exitUsage
+	}
+
+	_, statErr := os.Stat(path)
+	pass, err := readPassphrase(os.IsNotExist(statErr) && args[0] == "set")
+	if err != nil {
+		log.Error(err)
+		return exitError
+	}
+	store, err := credentials.OpenEncrypted(path, pass)
+	if err != nil {
+		log.Error(err)
+		return exitError
+	}
+
+	needName := func() bool {
+		if len(args) != 2 {
+			fs.Usage()
+			return false
+		}
+		return true
+	}
+
+	switch args[0] {
+	case "set":
+		if !needName() {
+			return exitUsage
+		}
+		// the value is never an argument, which would leave it in the shell
+		// history
+		var value string
+		if value, err = readSecretValue(args[1]); err != nil {
+			log.Error(err)
+			return exitError
+		}
+		if value == "" {
+			log.Error("empty secret value")
+			return exitUsage
+		}
+		err = store.Set(args[1], value)
+	case "get":
+		if !needName() {
+			return exitUsage
+		}
+		var value string
+		if value, err = store.Get(args[1]); err == nil {
+			fmt.Println(value)
+		}
+	case "delete":
+		if !needName() {
+			return exitUsage
+		}
+		err = store.Delete(args[1])
+	case "list":
+		var names []string
+		names, err = store.Names()
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	default:
+		log.Errorf("unknown secrets command %q", args[0])
+		return exitUsage
+	}
+
+	if err != nil {
+		log.Errorf("secrets %s: %v", strings.Join(args, " "), err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,527 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/config"
+	"github.com/hnhuaxi/ads/credentials"
+	"github.com/hnhuaxi/ads/filter"
+	"github.com/hysios/x/utils"
+	"go.uber.org/zap"
+)
+
+type arrayFlags []string
+
+func (i *arrayFlags) String() string {
+	return "accounts list"
+}
+
+func (i *arrayFlags) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
+// sourceFlags selects the provider and accounts assets are fetched from,
+// flags win over the settings of a config profile
+type sourceFlags struct {
+	provider        string
+	accessToken     string
+	accounts        arrayFlags
+	debug           bool
+	onlyAdcreatives bool
+	configFile      string
+	profileName     string
+	concurrency     int
+	tokenFile       string
+	agencies        arrayFlags
+	include         arrayFlags
+	exclude         arrayFlags
+	secrets         string
+	filter          string
+	assetFilter     string
+	includeDeleted  bool
+	activeOnly      bool
+
+	profile    *config.Profile
+	creds      []*config.Credential
+	store      credentials.Store
+	filterExpr *filter.Expr
+	matchAsset filter.AssetMatchFunc
+}
+
+func (s *sourceFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.provider, "provider", "", "provider, defaults to the profile provider or GDT")
+	fs.StringVar(&s.accessToken, "access_token", "", "access token or secret:NAME, defaults to the profile tokens, $GDT_ACCESS_TOKEN or the secret <provider>/<account id>")
+	fs.Var(&s.accounts, "account", "ad account id, repeatable, defaults to the profile accounts or $GDT_ACCOUNT_ID")
+	fs.BoolVar(&s.debug, "debug", false, "debug mode")
+	fs.BoolVar(&s.onlyAdcreatives, "only_adcreatives", false, "only adcreatives")
+	fs.StringVar(&s.configFile, "config", "", "yaml, json or toml config file with account profiles, defaults to $ADS_CONFIG")
+	fs.StringVar(&s.profileName, "profile", "", "config profile, defaults to the default_profile of the config")
+	fs.IntVar(&s.concurrency, "concurrency", 0, "accounts fetched at once, defaults to the profile concurrency or 1")
+	fs.Var(&s.agencies, "agency", "agency or business manager account whose accounts are synced, repeatable")
+	fs.Var(&s.include, "include", "only accounts whose id or name match the glob pattern, repeatable")
+	fs.Var(&s.exclude, "exclude", "skip accounts whose id or name match the glob pattern, repeatable")
+	fs.StringVar(&s.filter, "filter", "", `adcreative filter, e.g. 'configured_status == "AD_STATUS_NORMAL" && created_time > "2024-01-01"', defaults to the profile filter. Providers may push its conditions down to their adcreative list calls, images and videos are not filtered`)
+	fs.BoolVar(&s.includeDeleted, "include_deleted", false, "also process deleted adcreatives")
+	fs.BoolVar(&s.activeOnly, "active_only", false, "only process adcreatives not deleted with configured_status AD_STATUS_NORMAL")
+	fs.StringVar(&s.assetFilter, "asset_filter", "", `asset filter on the export columns, e.g. 'type == "image" && width >= 1080', defaults to the profile asset_filter`)
+	fs.StringVar(&s.secrets, "secrets", "", "secret store, an encrypted file, env:PREFIX or file:DIR, defaults to the profile secrets, $ADS_SECRETS or "+credentials.DefaultFile()+" if it exists")
+	fs.StringVar(&s.tokenFile, "token_file", "", "refresh the OAuth tokens saved by ads auth in this file, needs $GDT_CLIENT_ID and $GDT_CLIENT_SECRET")
+}
+
+// setup loads the config profile and fills the flags not given
+func (s *sourceFlags) setup() error {
+	filename := utils.Default(s.configFile, os.Getenv("ADS_CONFIG"))
+	switch {
+	case filename != "":
+		cfg, err := config.Load(filename)
+		if err != nil {
+			return err
+		}
+		if s.profile, err = cfg.Profile(s.profileName); err != nil {
+			return err
+		}
+	case s.profileName != "":
+		return errors.New("-profile needs a -config file")
+	}
+
+	if p := s.profile; p != nil {
+		s.provider = utils.Default(s.provider, p.Provider)
+		s.debug = s.debug || p.Debug
+		s.onlyAdcreatives = s.onlyAdcreatives || p.OnlyAdcreatives
+		s.concurrency = utils.Default(s.concurrency, p.Concurrency)
+		s.filter = utils.Default(s.filter, p.Filter)
+		s.assetFilter = utils.Default(s.assetFilter, p.AssetFilter)
+	}
+	s.provider = utils.Default(s.provider, "GDT")
+	s.concurrency = max(s.concurrency, 1)
+
+	var profileSecrets string
+	if s.profile != nil {
+		profileSecrets = s.profile.Secrets
+	}
+	s.store = openSecrets(secretsLocation(s.secrets, profileSecrets))
+
+	var err error
+	if s.filter != "" {
+		if s.filterExpr, err = filter.Parse(s.filter); err != nil {
+			return err
+		}
+	}
+	if s.assetFilter != "" {
+		if s.matchAsset, err = filter.Assets(s.assetFilter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// credentials returns the accounts to sync with their tokens. -account flags
+// select accounts of the profile or new ones using the -access_token token,
+// the accounts of -agency flags and of profile agencies listing no accounts
+// are discovered through the provider. -include and -exclude filter them all.
+func (s *sourceFlags) credentials() ([]*config.Credential, error) {
+	if s.creds != nil {
+		return s.creds, nil
+	}
+
+	var (
+		creds    = s.listedCredentials()
+		agencies []*config.Agency
+		seen     = make(map[string]bool)
+	)
+	for _, id := range s.agencies {
+		agencies = append(agencies, &config.Agency{ID: id})
+	}
+	if s.profile != nil && len(s.accounts) == 0 {
+		for _, agency := range s.profile.Agencies {
+			if len(agency.Accounts) == 0 {
+				agencies = append(agencies, agency)
+			}
+		}
+	}
+
+	for _, cred := range creds {
+		seen[cred.AccountID] = true
+	}
+	for _, agency := range agencies {
+		found, err := s.discover(agency)
+		if err != nil {
+			return nil, fmt.Errorf("list accounts of agency %s: %w", agency.ID, err)
+		}
+		for _, cred := range found {
+			if !seen[cred.AccountID] {
+				seen[cred.AccountID] = true
+				creds = append(creds, cred)
+			}
+		}
+	}
+
+	s.creds = filterCredentials(creds, s.include, s.exclude)
+	return s.creds, nil
+}
+
+// listedCredentials returns the accounts given by flags, the profile or the
+// environment
+func (s *sourceFlags) listedCredentials() []*config.Credential {
+	var profileCreds []*config.Credential
+	if s.profile != nil {
+		profileCreds = s.profile.Credentials()
+	}
+
+	token := utils.Default(s.accessToken, os.Getenv("GDT_ACCESS_TOKEN"))
+	if len(s.accounts) == 0 && (len(profileCreds) > 0 || len(s.agencies) > 0) {
+		if s.accessToken != "" {
+			for _, cred := range profileCreds {
+				cred.AccessToken = s.accessToken
+			}
+		}
+		return profileCreds
+	}
+
+	ids := []string(s.accounts)
+	if len(ids) == 0 {
+		if id := os.Getenv("GDT_ACCOUNT_ID"); id != "" {
+			ids = []string{id}
+		}
+	}
+
+	var creds []*config.Credential
+	for _, id := range ids {
+		cred := &config.Credential{AccountID: id, AccessToken: token}
+		for _, c := range profileCreds {
+			if c.AccountID == id {
+				cred.AccountName = c.AccountName
+				cred.AgencyID = c.AgencyID
+				cred.AccessToken = utils.Default(s.accessToken, c.AccessToken)
+			}
+		}
+		creds = append(creds, cred)
+	}
+	return creds
+}
+
+// discover lists the accounts managed by an agency, they share its token
+func (s *sourceFlags) discover(agency *config.Agency) ([]*config.Credential, error) {
+	token := utils.Default(s.accessToken, agency.AccessToken)
+	if s.profile != nil {
+		token = utils.Default(token, s.profile.AccessToken)
+	}
+	token = utils.Default(token, os.Getenv("GDT_ACCESS_TOKEN"))
+	token, err := s.token(&config.Credential{AccountID: agency.ID, AccessToken: token})
+	if err != nil {
+		return nil, err
+	}
+
+	get, err := s.open(&config.Credential{AccountID: agency.ID, AccessToken: token, AgencyID: agency.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := get.(ads.AccountLister)
+	if !ok {
+		return nil, fmt.Errorf("provider %s cannot list managed accounts", s.provider)
+	}
+	accounts, err := lister.Accounts()
+	if err != nil {
+		return nil, err
+	}
+
+	var creds []*config.Credential
+	for _, acc := range accounts {
+		creds = append(creds, &config.Credential{
+			AccountID:   acc.ID,
+			AccountName: acc.Name,
+			AccessToken: token,
+			AgencyID:    agency.ID,
+			Account:     acc,
+		})
+	}
+	return filterCredentials(creds, agency.Include, agency.Exclude), nil
+}
+
+// filterCredentials keeps the accounts whose id or name match an include
+// pattern, all without patterns, and no exclude pattern
+func filterCredentials(creds []*config.Credential, include, exclude []string) []*config.Credential {
+	matchAny := func(patterns []string, cred *config.Credential) bool {
+		for _, pattern := range patterns {
+			for _, value := range []string{cred.AccountID, cred.AccountName} {
+				if ok, _ := path.Match(pattern, value); ok && value != "" {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var kept []*config.Credential
+	for _, cred := range creds {
+		if len(include) > 0 && !matchAny(include, cred) {
+			continue
+		}
+		if matchAny(exclude, cred) {
+			continue
+		}
+		kept = append(kept, cred)
+	}
+	return kept
+}
+
+// accountIDs returns the ids of the accounts to sync
+func (s *sourceFlags) accountIDs() ([]string, error) {
+	creds, err := s.credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, cred := range creds {
+		ids = append(ids, cred.AccountID)
+	}
+	return ids, nil
+}
+
+// token resolves the secret:NAME token of an account, an account without a
+// token takes the secret <provider>/<account id>, or that of its agency.
+// Providers described without an access_token credential need none.
+func (s *sourceFlags) token(cred *config.Credential) (string, error) {
+	if cred.AccessToken != "" {
+		return resolveSecret(s.store, cred.AccessToken)
+	}
+	if info, ok := ads.LookupProvider(s.provider); ok && !info.NeedsToken() {
+		return "", nil
+	}
+
+	names := []string{s.provider + "/" + cred.AccountID}
+	if cred.AgencyID != "" && cred.AgencyID != cred.AccountID {
+		names = append(names, s.provider+"/"+cred.AgencyID)
+	}
+	for _, name := range names {
+		token, err := resolveSecret(s.store, secretPrefix+name)
+		if err == nil {
+			return token, nil
+		}
+		if !errors.Is(err, credentials.ErrNotFound) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("no access token for account %s, use -access_token or the secret %s", cred.AccountID, names[0])
+}
+
+// open opens the provider of an account with the profile settings and filters
+func (s *sourceFlags) open(cred *config.Credential) (ads.GetAdcreatives, error) {
+	token, err := s.token(cred)
+	if err != nil {
+		return nil, err
+	}
+	get, err := ads.Open(s.provider, cred.AccountID, token, s.debug)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.onlyAdcreatives {
+		get.OnlyAdcreatives(true)
+	}
+	if named, ok := get.(ads.NamedAccount); ok && cred.AccountName != "" {
+		named.SetAccountName(cred.AccountName)
+	}
+
+	settings, err := s.settings()
+	if err != nil {
+		return nil, err
+	}
+	if cred.AgencyID != "" && settings["token_file"] != "" {
+		settings["token_key"] = cred.AgencyID
+	}
+	if len(settings) > 0 {
+		conf, ok := get.(ads.Configurable)
+		if !ok {
+			return nil, fmt.Errorf("provider %s takes no settings", s.provider)
+		}
+		if err := conf.Configure(settings); err != nil {
+			return nil, err
+		}
+	}
+	if s.includeDeleted {
+		get.ClearAdcreativesFuncs()
+	}
+	if s.activeOnly {
+		get.SetAdcreativesFunc(ads.ActiveOnly)
+	}
+	if p := s.profile; p != nil && len(p.Filters) > 0 {
+		get.SetAdcreativesFunc(matchFields(p.Filters))
+	}
+	if s.filterExpr != nil {
+		get.SetAdcreativesFunc(s.filterExpr.Adcreatives())
+		if pd, ok := get.(ads.PushdownAdcreatives); ok {
+			pd.PushDown(s.filterExpr.Conditions())
+		}
+	}
+	return get, nil
+}
+
+// settings returns the provider settings of the profile and the -token_file
+// flag, with their secret:NAME values resolved
+func (s *sourceFlags) settings() (map[string]string, error) {
+	settings := make(map[string]string)
+	if s.profile != nil {
+		settings = s.profile.ProviderSettings()
+	}
+
+	if s.tokenFile != "" {
+		settings["token_file"] = s.tokenFile
+		if _, ok := settings["client_id"]; !ok {
+			settings["client_id"] = os.Getenv("GDT_CLIENT_ID")
+		}
+		if _, ok := settings["client_secret"]; !ok {
+			settings["client_secret"] = os.Getenv("GDT_CLIENT_SECRET")
+		}
+	}
+
+	for key, value := range settings {
+		resolved, err := resolveSecret(s.store, value)
+		if err != nil {
+			return nil, fmt.Errorf("setting %s: %w", key, err)
+		}
+		settings[key] = resolved
+	}
+	return settings, nil
+}
+
+// matchFields keeps the adcreatives whose fields equal the filter values
+func matchFields(filters map[string]string) ads.AdcreativeMatchFunc {
+	return func(adcreative ads.Map) bool {
+		for field, value := range filters {
+			if adcreative.Get(field).String() != value {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// forEachAccount calls fn for every account, with up to -concurrency calls
+// running at once, and returns the first error
+func (s *sourceFlags) forEachAccount(creds []*config.Credential, fn func(i int, cred *config.Credential) error) error {
+	var (
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, max(s.concurrency, 1))
+		errMu sync.Mutex
+		first error
+	)
+	for i, cred := range creds {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, cred *config.Credential) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := fn(i, cred); err != nil {
+				errMu.Lock()
+				if first == nil {
+					first = err
+				}
+				errMu.Unlock()
+			}
+		}(i, cred)
+	}
+	wg.Wait()
+	return first
+}
+
+// fetch lists the assets of all accounts
+func (s *sourceFlags) fetch(log *zap.SugaredLogger) ([]*ads.Asset, error) {
+	creds, err := s.credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]*ads.Asset, len(creds))
+	err = s.forEachAccount(creds, func(i int, cred *config.Credential) error {
+		get, err := s.open(cred)
+		if err != nil {
+			return err
+		}
+
+		assets, err := get.Assets()
+		if err != nil {
+			return fmt.Errorf("account %s: %w", cred.AccountID, err)
+		}
+		tagAssets(assets, s.provider, cred)
+		assets = s.filterAssets(assets)
+
+		log.Infow("list assets", "account", cred.AccountID, "assets", len(assets))
+		for _, asset := range assets {
+			log.With("asset", asset).Debug("asset")
+		}
+		results[i] = assets
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var all []*ads.Asset
+	for _, assets := range results {
+		all = append(all, assets...)
+	}
+	return all, nil
+}
+
+// filtered reports whether the filters drop assets, the assets dropped are
+// not missing from the account
+func (s *sourceFlags) filtered() bool {
+	return s.filterExpr != nil || s.matchAsset != nil || s.activeOnly
+}
+
+// filterAssets keeps the assets matching -asset_filter
+func (s *sourceFlags) filterAssets(assets []*ads.Asset) []*ads.Asset {
+	if s.matchAsset == nil {
+		return assets
+	}
+	return s.matchAsset.Filter(assets)
+}
+
+// tagAssets tags the assets with the provider and names them after the
+// configured account name when the provider did not
+func tagAssets(assets []*ads.Asset, provider string, cred *config.Credential) {
+	for _, asset := range assets {
+		if asset.Provider == "" {
+			asset.Provider = provider
+		}
+		if asset.AccountName == "" {
+			asset.AccountName = cred.AccountName
+		}
+	}
+}
+
+// inputFlags reads assets from an exported snapshot or from the provider
+type inputFlags struct {
+	sourceFlags
+	input string
+}
+
+func (s *inputFlags) register(fs *flag.FlagSet) {
+	s.sourceFlags.register(fs)
+	fs.StringVar(&s.input, "input", "", "read assets from an exported csv, json or jsonl snapshot instead of the provider")
+}
+
+// load
+func (s *inputFlags) load(log *zap.SugaredLogger) ([]*ads.Asset, error) {
+	if s.input != "" {
+		assets, err := ads.ReadAssetsFile(s.input)
+		if err != nil {
+			return nil, err
+		}
+		return s.filterAssets(assets), nil
+	}
+	return s.fetch(log)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/catalog"
+	"github.com/hnhuaxi/ads/config"
+)
+
+// runSync
+func runSync(args []string) int {
+	var (
+		fs  = newFlagSet("sync")
+		src sourceFlags
+	)
+	src.register(fs)
+	var (
+		catalogFile = fs.String("catalog", "ads.db", "sqlite catalog file")
+		incremental = fs.Bool("incremental", false, "only sync assets modified since the last catalog sync")
+		phash       = fs.Bool("phash", false, "compute perceptual hashes of images and video cover images")
+	)
+
+	log, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+
+	if err := src.setup(); err != nil {
+		log.Error(err)
+		return exitUsage
+	}
+	// the account of a snapshot is its path, the catalog would key the assets
+	// and watermarks of the snapshot's accounts by the path
+	if src.provider == "FILE" {
+		log.Error("the FILE provider cannot be synced, sync the provider the snapshot was exported from")
+		return exitUsage
+	}
+
+	creds, err := src.credentials()
+	if err != nil {
+		log.Error(err)
+		return exitError
+	}
+	if len(creds) == 0 {
+		log.Error("no accounts given, use -account, -agency, -config or $GDT_ACCOUNT_ID")
+		return exitUsage
+	}
+
+	cat, err := catalog.Open(*catalogFile)
+	if err != nil {
+		log.Errorf("open catalog error: %v", err)
+		return exitError
+	}
+	defer cat.Close()
+
+	// accounts are fetched concurrently, the catalog is written one at a time
+	var mu sync.Mutex
+	err = src.forEachAccount(creds, func(_ int, cred *config.Credential) error {
+		accId := cred.AccountID
+		get, err := src.open(cred)
+		if err != nil {
+			return fmt.Errorf("open provider error: %w", err)
+		}
+
+		var (
+			assets    []*ads.Asset
+			watermark time.Time
+			partial   bool
+		)
+		if inc, ok := get.(ads.IncrementalAdcreatives); ok && *incremental {
+			var since time.Time
+			mu.Lock()
+			since, err = cat.Watermark(accId)
+			mu.Unlock()
+			if err != nil {
+				return fmt.Errorf("load watermark error: %w", err)
+			}
+			assets, watermark, err = inc.AssetsSince(since)
+			partial = !since.IsZero()
+			log.Infow("incremental sync", "account", accId, "since", since, "watermark", watermark)
+		} else {
+			if *incremental {
+				log.Warnw("incremental sync unavailable, syncing everything", "account", accId)
+			}
+			assets, err = get.Assets()
+		}
+		if err != nil {
+			return fmt.Errorf("get assets of account %s error: %w", accId, err)
+		}
+		tagAssets(assets, src.provider, cred)
+		assets = src.filterAssets(assets)
+
+		if *phash {
+			hashed := ads.HashAssets(nil, assets)
+			log.Infow("hash assets", "account", accId, "hashed", hashed)
+		}
+
+		for _, asset := range assets {
+			log.With("asset", asset).Debug("asset")
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		var result *catalog.SyncResult
+		if partial || src.filtered() {
+			result, err = cat.Upsert(accId, assets, time.Now())
+		} else {
+			result, err = cat.Sync(accId, assets, time.Now())
+		}
+		if err != nil {
+			return fmt.Errorf("sync catalog error: %w", err)
+		}
+
+		if !watermark.IsZero() {
+			if err := cat.SetWatermark(accId, watermark); err != nil {
+				return fmt.Errorf("save watermark error: %w", err)
+			}
+		}
+
+		log.Infow("sync catalog", "account", accId, "added", result.Added, "updated", result.Updated,
+			"unchanged", result.Unchanged, "removed", result.Removed)
+		return nil
+	})
+	if err != nil {
+		log.Error(err)
+		return exitError
+	}
+	return exitOK
+}
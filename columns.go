@@ -0,0 +1,217 @@
+package ads
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column is an exported asset column. Get receives the sub asset of the row
+// in explode mode, nil otherwise. Set parses the exported value back into the
+// asset and is nil for derived columns.
+type Column struct {
+	Name string
+	Get  func(a *Asset, sub *SubAsset) string
+	Set  func(a *Asset, value string) error
+}
+
+var (
+	columns       []*Column
+	columnIndex   = make(map[string]*Column)
+	defaultColumn []string
+)
+
+// RegisterColumn adds a column to the schema, columns with a Set func are
+// part of the default column list
+func RegisterColumn(c *Column) {
+	if _, exists := columnIndex[c.Name]; exists {
+		panic("ads: column " + c.Name + " registered twice")
+	}
+
+	columns = append(columns, c)
+	columnIndex[c.Name] = c
+	if c.Set != nil {
+		defaultColumn = append(defaultColumn, c.Name)
+	}
+}
+
+// Columns returns all registered columns in registration order
+func Columns() []*Column {
+	return columns
+}
+
+// LookupColumn finds a column by name, case insensitive
+func LookupColumn(name string) (*Column, bool) {
+	if c, ok := columnIndex[name]; ok {
+		return c, true
+	}
+	for _, c := range columns {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// DefaultColumns returns the names of the columns exported when none are
+// selected, they hold every Asset field so the export can be read back
+func DefaultColumns() []string {
+	return defaultColumn
+}
+
+// SelectColumns resolves column names, the default columns when names is empty
+func SelectColumns(names []string) ([]*Column, error) {
+	if len(names) == 0 {
+		names = defaultColumn
+	}
+
+	selected := make([]*Column, 0, len(names))
+	for _, name := range names {
+		c, ok := LookupColumn(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+func stringColumn(name string, field func(a *Asset) *string) *Column {
+	return &Column{
+		Name: name,
+		Get: func(a *Asset, _ *SubAsset) string {
+			return *field(a)
+		},
+		Set: func(a *Asset, value string) error {
+			*field(a) = value
+			return nil
+		},
+	}
+}
+
+func intColumn(name string, field func(a *Asset) *int) *Column {
+	return &Column{
+		Name: name,
+		Get: func(a *Asset, _ *SubAsset) string {
+			if n := *field(a); n != 0 {
+				return strconv.Itoa(n)
+			}
+			return ""
+		},
+		Set: func(a *Asset, value string) (err error) {
+			if value == "" {
+				return nil
+			}
+			*field(a), err = strconv.Atoi(value)
+			return err
+		},
+	}
+}
+
+func timeColumn(name string, field func(a *Asset) *time.Time) *Column {
+	return &Column{
+		Name: name,
+		Get: func(a *Asset, _ *SubAsset) string {
+			if t := *field(a); !t.IsZero() {
+				return t.Format(time.RFC3339)
+			}
+			return ""
+		},
+		Set: func(a *Asset, value string) error {
+			if value == "" {
+				return nil
+			}
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return err
+			}
+			*field(a) = t
+			return nil
+		},
+	}
+}
+
+func jsonColumn(name string, field func(a *Asset) interface{}) *Column {
+	return &Column{
+		Name: name,
+		Get: func(a *Asset, _ *SubAsset) string {
+			b, _ := json.Marshal(field(a))
+			return string(b)
+		},
+		Set: func(a *Asset, value string) error {
+			if value == "" || value == "null" {
+				return nil
+			}
+			return json.Unmarshal([]byte(value), field(a))
+		},
+	}
+}
+
+func init() {
+	RegisterColumn(stringColumn("Provider", func(a *Asset) *string { return &a.Provider }))
+	RegisterColumn(stringColumn("AccountID", func(a *Asset) *string { return &a.AccountID }))
+	RegisterColumn(stringColumn("AccountName", func(a *Asset) *string { return &a.AccountName }))
+	RegisterColumn(stringColumn("AdcreativeID", func(a *Asset) *string { return &a.AdcreativeID }))
+	RegisterColumn(stringColumn("AdcreativeName", func(a *Asset) *string { return &a.AdcreativeName }))
+	RegisterColumn(stringColumn("AssetID", func(a *Asset) *string { return &a.AssetID }))
+	RegisterColumn(stringColumn("Name", func(a *Asset) *string { return &a.Name }))
+	RegisterColumn(&Column{
+		Name: "PageType",
+		Get: func(a *Asset, _ *SubAsset) string {
+			return a.PageType.String()
+		},
+		Set: func(a *Asset, value string) (err error) {
+			a.PageType, err = ParsePageType(value)
+			return
+		},
+	})
+	RegisterColumn(stringColumn("SubType", func(a *Asset) *string { return &a.SubType }))
+	RegisterColumn(jsonColumn("Texts", func(a *Asset) interface{} { return &a.Texts }))
+	RegisterColumn(jsonColumn("SubAssets", func(a *Asset) interface{} { return &a.SubAssets }))
+	RegisterColumn(intColumn("Width", func(a *Asset) *int { return &a.Width }))
+	RegisterColumn(intColumn("Height", func(a *Asset) *int { return &a.Height }))
+	RegisterColumn(stringColumn("Signature", func(a *Asset) *string { return &a.Signature }))
+	RegisterColumn(stringColumn("Version", func(a *Asset) *string { return &a.Version }))
+	RegisterColumn(stringColumn("PHash", func(a *Asset) *string { return &a.PHash }))
+	RegisterColumn(timeColumn("CreatedTime", func(a *Asset) *time.Time { return &a.CreatedTime }))
+	RegisterColumn(timeColumn("LastModifiedTime", func(a *Asset) *time.Time { return &a.LastModifiedTime }))
+
+	// derived columns
+	RegisterColumn(&Column{
+		Name: "PrimaryUrl",
+		Get: func(a *Asset, _ *SubAsset) string {
+			return a.PrimaryUrl()
+		},
+	})
+	RegisterColumn(&Column{
+		Name: "SubAssetIndex",
+		Get: func(a *Asset, sub *SubAsset) string {
+			for i, s := range a.SubAssets {
+				if s == sub {
+					return strconv.Itoa(i)
+				}
+			}
+			return ""
+		},
+	})
+	RegisterColumn(&Column{
+		Name: "SubAssetType",
+		Get: func(a *Asset, sub *SubAsset) string {
+			if sub == nil {
+				return ""
+			}
+			return sub.Type.String()
+		},
+	})
+	RegisterColumn(&Column{
+		Name: "SubAssetUrl",
+		Get: func(a *Asset, sub *SubAsset) string {
+			if sub == nil {
+				return ""
+			}
+			return sub.Url
+		},
+	})
+}
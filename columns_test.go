@@ -0,0 +1,52 @@
+package ads
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectColumns(t *testing.T) {
+	columns, err := SelectColumns(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range columns {
+		if c.Name != DefaultColumns()[i] || c.Set == nil {
+			t.Errorf("default column %d %s", i, c.Name)
+		}
+	}
+	for _, name := range []string{"PrimaryUrl", "SubAssetIndex", "SubAssetType", "SubAssetUrl"} {
+		if c, ok := LookupColumn(name); !ok || c.Set != nil {
+			t.Errorf("derived column %s", name)
+		}
+	}
+
+	if columns, err := SelectColumns([]string{" AssetID", "Width "}); err != nil || columns[0].Name != "AssetID" || columns[1].Name != "Width" {
+		t.Errorf("columns %v, %v", columns, err)
+	}
+	if _, err := SelectColumns([]string{"AssetID", "Nope"}); err == nil || err.Error() != `unknown column "Nope"` {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestColumnValues(t *testing.T) {
+	for _, asset := range append(exportAssets(), &Asset{}) {
+		var got Asset
+		for _, name := range DefaultColumns() {
+			c, _ := LookupColumn(name)
+			if err := c.Set(&got, c.Get(asset, nil)); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		if !reflect.DeepEqual(&got, asset) {
+			t.Errorf("columns set\n%s\nwant\n%s", dump([]*Asset{&got}), dump([]*Asset{asset}))
+		}
+	}
+
+	for name, value := range map[string]string{"Width": "wide", "CreatedTime": "yesterday", "Texts": "[", "PageType": "PTNope"} {
+		c, _ := LookupColumn(name)
+		if err := c.Set(&Asset{}, value); err == nil {
+			t.Errorf("%s set to %q", name, value)
+		}
+	}
+}
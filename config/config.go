@@ -0,0 +1,260 @@
+// Package config loads the profiles of the ads command from a YAML, JSON or
+// TOML file. A profile names the provider, the accounts with their tokens and
+// the defaults of the export output:
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    provider: GDT
+//	    access_token: ${GDT_ACCESS_TOKEN}
+//	    secrets: ${HOME}/.config/ads/secrets.json
+//	    version: v3
+//	    concurrency: 4
+//	    filters:
+//	      configured_status: AD_STATUS_NORMAL
+//	    filter: created_time >= "2024-01-01"
+//	    asset_filter: type in ["image", "video"] && width >= 720
+//	    accounts:
+//	      - id: "123"
+//	      - id: "456"
+//	        access_token: secret:shop
+//	    agencies:
+//	      - id: "900"
+//	        access_token: ${AGENCY_TOKEN:-}
+//	        accounts: ["789"]
+//	      - id: "901"
+//	        exclude: ["test-*"]
+//	    output:
+//	      format: xlsx
+//	      file: assets.xlsx
+//
+// String values may reference environment variables as ${VAR} or
+// ${VAR:-default}. Tokens written as secret:NAME are read from the secret
+// store, accounts without any token take the secret <provider>/<account id>.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hnhuaxi/ads"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a set of named profiles
+type Config struct {
+	DefaultProfile string              `yaml:"default_profile" toml:"default_profile"`
+	Profiles       map[string]*Profile `yaml:"profiles" toml:"profiles"`
+
+	filename string
+	root     *yaml.Node
+}
+
+// Profile selects a provider, its accounts and settings
+type Profile struct {
+	Name string `yaml:"-" toml:"-"`
+
+	Provider string `yaml:"provider" toml:"provider"`
+	// AccessToken is used by accounts and agencies without their own token
+	AccessToken string `yaml:"access_token" toml:"access_token"`
+	// Version is the provider API version, passed as the version setting
+	Version string `yaml:"version" toml:"version"`
+	// Settings are passed to providers implementing ads.Configurable
+	Settings map[string]string `yaml:"settings" toml:"settings"`
+	Accounts []*Account        `yaml:"accounts" toml:"accounts"`
+	Agencies []*Agency         `yaml:"agencies" toml:"agencies"`
+	// Filters keeps adcreatives whose field, a dotted path, equals the value
+	Filters map[string]string `yaml:"filters" toml:"filters"`
+	// Filter and AssetFilter are filter expressions over adcreatives and
+	// assets, see the filter package
+	Filter          string `yaml:"filter" toml:"filter"`
+	AssetFilter     string `yaml:"asset_filter" toml:"asset_filter"`
+	OnlyAdcreatives bool   `yaml:"only_adcreatives" toml:"only_adcreatives"`
+	// Concurrency is the number of accounts fetched at once, 0 means 1
+	Concurrency int    `yaml:"concurrency" toml:"concurrency"`
+	Debug       bool   `yaml:"debug" toml:"debug"`
+	Output      Output `yaml:"output" toml:"output"`
+	// Secrets is the secret store tokens written as secret:NAME are read
+	// from, see the credentials package
+	Secrets string `yaml:"secrets" toml:"secrets"`
+}
+
+// Account is an ad account with an optional token of its own
+type Account struct {
+	ID          string `yaml:"id" toml:"id"`
+	Name        string `yaml:"name" toml:"name"`
+	AccessToken string `yaml:"access_token" toml:"access_token"`
+}
+
+// Agency is an agency credential shared by the accounts it manages, the
+// accounts are discovered through the provider when none are listed
+type Agency struct {
+	ID          string   `yaml:"id" toml:"id"`
+	Name        string   `yaml:"name" toml:"name"`
+	AccessToken string   `yaml:"access_token" toml:"access_token"`
+	Accounts    []string `yaml:"accounts" toml:"accounts"`
+	// Include and Exclude are glob patterns filtering discovered accounts by
+	// id or name
+	Include []string `yaml:"include" toml:"include"`
+	Exclude []string `yaml:"exclude" toml:"exclude"`
+}
+
+// Output holds the export defaults, flags given on the command line win
+type Output struct {
+	Format    string   `yaml:"format" toml:"format"`
+	File      string   `yaml:"file" toml:"file"`
+	Columns   []string `yaml:"columns" toml:"columns"`
+	Explode   bool     `yaml:"explode" toml:"explode"`
+	Delimiter string   `yaml:"delimiter" toml:"delimiter"`
+	BOM       bool     `yaml:"bom" toml:"bom"`
+}
+
+// Credential is the token an account is fetched with
+type Credential struct {
+	AccountID   string
+	AccountName string
+	AccessToken string
+	// AgencyID is set for accounts listed under an agency
+	AgencyID string
+	// Account is the listing of accounts discovered from an agency
+	Account *ads.Account
+}
+
+// Load reads a config file, the format is taken from the extension and
+// defaults to YAML, which also reads JSON
+func Load(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(filename, data, strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// Parse decodes, interpolates and validates a config, filename is only used
+// in error messages
+func Parse(filename string, data []byte, format string) (*Config, error) {
+	cfg := &Config{filename: filename}
+
+	switch strings.ToLower(format) {
+	case "toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			if perr, ok := err.(toml.ParseError); ok {
+				return nil, &Error{File: filename, Line: perr.Position.Line, Msg: perr.Message}
+			}
+			return nil, &Error{File: filename, Msg: err.Error()}
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			var errs Errors
+			for _, key := range undecoded {
+				errs = append(errs, &Error{File: filename, Path: key.String(), Msg: "unknown field"})
+			}
+			return nil, errs
+		}
+	default:
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, yamlError(filename, err)
+		}
+		cfg.root = &root
+
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil {
+			return nil, yamlError(filename, err)
+		}
+	}
+
+	for name, p := range cfg.Profiles {
+		if p == nil {
+			p = &Profile{}
+			cfg.Profiles[name] = p
+		}
+		p.Name = name
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ProfileNames returns the sorted profile names
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns a profile by name, an empty name selects the default
+// profile, or the only one
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		if len(c.Profiles) != 1 {
+			return nil, fmt.Errorf("%s: no default_profile, select one of %s", c.filename, strings.Join(c.ProfileNames(), ", "))
+		}
+		name = c.ProfileNames()[0]
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown profile %q, defined: %s", c.filename, name, strings.Join(c.ProfileNames(), ", "))
+	}
+	return p, nil
+}
+
+// Credentials resolves the token of every account, an account token wins over
+// its agency token which wins over the profile token
+func (p *Profile) Credentials() []*Credential {
+	var creds []*Credential
+	for _, acc := range p.Accounts {
+		creds = append(creds, &Credential{
+			AccountID:   acc.ID,
+			AccountName: acc.Name,
+			AccessToken: firstNonEmpty(acc.AccessToken, p.AccessToken),
+		})
+	}
+	for _, agency := range p.Agencies {
+		for _, id := range agency.Accounts {
+			creds = append(creds, &Credential{
+				AccountID:   id,
+				AccessToken: firstNonEmpty(agency.AccessToken, p.AccessToken),
+				AgencyID:    agency.ID,
+			})
+		}
+	}
+	return creds
+}
+
+// ProviderSettings returns the settings passed to the provider, with the API
+// version under the version key
+func (p *Profile) ProviderSettings() map[string]string {
+	settings := make(map[string]string, len(p.Settings)+1)
+	for key, value := range p.Settings {
+		settings[key] = value
+	}
+	if p.Version != "" {
+		settings["version"] = p.Version
+	}
+	return settings
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/hnhuaxi/ads/mock"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("ADS_TOKEN", "token")
+	t.Setenv("ADS_ACCOUNT", "123")
+	t.Setenv("ADS_EMPTY", "")
+	t.Setenv("ADS_FORMAT", "csv")
+	t.Setenv("ADS_DELIMITER", ";")
+	t.Setenv("ADS_COLUMN", "AssetID")
+	t.Setenv("ADS_WIDTH", "720")
+	t.Setenv("ADS_EXCLUDE", "test-*")
+
+	cfg, err := Parse("ads.yaml", []byte(`
+profiles:
+  prod:
+    provider: MOCK
+    access_token: ${ADS_TOKEN}
+    version: ${ADS_VERSION:-v3}
+    settings:
+      seed: ${ADS_EMPTY:-42}
+    filter: adcreative_id = "${ADS_ACCOUNT}"
+    asset_filter: width >= ${ADS_WIDTH}
+    accounts:
+      - id: ${ADS_ACCOUNT}
+        access_token: ${ADS_ACCOUNT_TOKEN:-secret:shop}
+    agencies:
+      - id: "900"
+        accounts: ["${ADS_ACCOUNT}0"]
+        exclude: ["${ADS_EXCLUDE}"]
+    output:
+      format: ${ADS_FORMAT}
+      file: assets.${ADS_FORMAT}
+      delimiter: ${ADS_DELIMITER}
+      columns: [AccountID, "${ADS_COLUMN}"]
+`), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := cfg.Profile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for field, tt := range map[string]struct{ got, want interface{} }{
+		"access_token": {p.AccessToken, "token"},
+		"version":      {p.Version, "v3"},
+		"settings":     {p.Settings, map[string]string{"seed": "42"}},
+		"filter":       {p.Filter, `adcreative_id = "123"`},
+		"asset_filter": {p.AssetFilter, "width >= 720"},
+		"account":      {*p.Accounts[0], Account{ID: "123", AccessToken: "secret:shop"}},
+		"agency":       {p.Agencies[0].Accounts, []string{"1230"}},
+		"exclude":      {p.Agencies[0].Exclude, []string{"test-*"}},
+		"output": {p.Output, Output{
+			Format: "csv", File: "assets.csv", Delimiter: ";", Columns: []string{"AccountID", "AssetID"},
+		}},
+	} {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		format string
+		data   string
+		want   []string
+	}{
+		{
+			name: "unset variable",
+			data: `
+profiles:
+  prod:
+    provider: MOCK
+    access_token: ${ADS_UNSET_TOKEN}
+    accounts: [{id: "1"}]
+`,
+			want: []string{"ads.yaml:5: profiles.prod.access_token: environment variable ADS_UNSET_TOKEN is not set"},
+		},
+		{
+			name: "fields",
+			data: `
+default_profile: test
+profiles:
+  prod:
+    provider: NONE
+    concurrency: -1
+    accounts:
+      - id: "1"
+      - id: "1"
+        access_token: "secret:"
+    agencies:
+      - accounts: [""]
+        include: ["[a"]
+    filter: created_time >=
+    output:
+      format: pdf
+      delimiter: ";;"
+      columns: [Nope]
+`,
+			want: []string{
+				`ads.yaml:2: default_profile: unknown profile "test"`,
+				`ads.yaml:5: profiles.prod.provider: unknown provider "NONE"`,
+				"ads.yaml:6: profiles.prod.concurrency: must not be negative",
+				"ads.yaml:9: profiles.prod.accounts[1].id: account 1 listed twice",
+				"ads.yaml:10: profiles.prod.accounts[1].access_token: secret name is required",
+				"ads.yaml:12: profiles.prod.agencies[0].id: agency id is required",
+				`ads.yaml:13: profiles.prod.agencies[0].include[0]: invalid pattern "[a"`,
+				"ads.yaml:12: profiles.prod.agencies[0].accounts[0]: account id is required",
+				"ads.yaml:14: profiles.prod.filter: ",
+				`ads.yaml:16: profiles.prod.output.format: unknown format "pdf"`,
+				"ads.yaml:17: profiles.prod.output.delimiter: delimiter must be a single character or tab",
+				`ads.yaml:18: profiles.prod.output.columns: unknown column "Nope"`,
+			},
+		},
+		{
+			name: "unknown field",
+			data: `
+profiles:
+  prod:
+    provider: MOCK
+    acounts: [{id: "1"}]
+`,
+			want: []string{"ads.yaml:5: field acounts not found"},
+		},
+		{
+			name: "syntax",
+			data: "profiles:\n  prod: [\n",
+			want: []string{"ads.yaml:2: did not find expected node content"},
+		},
+		{
+			name:   "toml unknown field",
+			format: "toml",
+			data: `
+[profiles.prod]
+provider = "MOCK"
+acounts = ["1"]
+`,
+			want: []string{"ads.yaml: profiles.prod.acounts: unknown field"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			format := tt.format
+			if format == "" {
+				format = "yaml"
+			}
+			_, err := Parse("ads.yaml", []byte(tt.data), format)
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("error %v, want config errors", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("errors:\n%v\nwant %d", err, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got := errs[i].Error(); !strings.HasPrefix(got, want) {
+					t.Errorf("error %d = %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	cfg, err := Parse("ads.yaml", []byte(`
+profiles:
+  prod:
+    provider: MOCK
+    access_token: profile
+    accounts:
+      - id: "1"
+      - id: "2"
+        access_token: account
+    agencies:
+      - id: "900"
+        access_token: agency
+        accounts: ["3"]
+      - id: "901"
+        accounts: ["4"]
+`), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, _ := cfg.Profile("prod")
+	var got []string
+	for _, c := range p.Credentials() {
+		got = append(got, c.AccountID+"="+c.AccessToken+"@"+c.AgencyID)
+	}
+	if want := []string{"1=profile@", "2=account@", "3=agency@900", "4=profile@901"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("credentials %v, want %v", got, want)
+	}
+}
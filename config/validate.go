@@ -0,0 +1,309 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/filter"
+	"gopkg.in/yaml.v3"
+)
+
+// Error is a config error at a field, Line is 0 when unknown, as for the
+// fields of TOML files
+type Error struct {
+	File string
+	Line int
+	Path string
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		b.WriteString(":" + strconv.Itoa(e.Line))
+	}
+	if e.Path != "" {
+		b.WriteString(": " + e.Path)
+	}
+	b.WriteString(": " + e.Msg)
+	return b.String()
+}
+
+// Errors are all the errors of a config, one per line
+type Errors []*Error
+
+func (errs Errors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlError converts the errors of the yaml decoder, which carry their line in
+// the message
+func yamlError(filename string, err error) error {
+	var msgs []string
+	if terr, ok := err.(*yaml.TypeError); ok {
+		msgs = terr.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+
+	var errs Errors
+	for _, msg := range msgs {
+		e := &Error{File: filename, Msg: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Msg = m[2]
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// validator collects the errors of a config with the line of their field
+type validator struct {
+	cfg  *Config
+	errs Errors
+}
+
+func (v *validator) errorf(path []interface{}, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{
+		File: v.cfg.filename,
+		Line: v.cfg.line(path),
+		Path: pathString(path),
+		Msg:  fmt.Sprintf(format, args...),
+	})
+}
+
+var envVar = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// expand replaces the ${VAR} and ${VAR:-default} references of a value
+func (v *validator) expand(s *string, path ...interface{}) {
+	*s = envVar.ReplaceAllStringFunc(*s, func(ref string) string {
+		m := envVar.FindStringSubmatch(ref)
+		if value, ok := os.LookupEnv(m[1]); ok && value != "" {
+			return value
+		}
+		if m[2] != "" {
+			return strings.TrimPrefix(m[2], ":-")
+		}
+		v.errorf(path, "environment variable %s is not set", m[1])
+		return ""
+	})
+}
+
+func (c *Config) validate() error {
+	v := &validator{cfg: c}
+
+	if len(c.Profiles) == 0 {
+		v.errorf(nil, "no profiles defined")
+	}
+	if c.DefaultProfile != "" {
+		if _, ok := c.Profiles[c.DefaultProfile]; !ok {
+			v.errorf([]interface{}{"default_profile"}, "unknown profile %q", c.DefaultProfile)
+		}
+	}
+
+	for _, name := range c.ProfileNames() {
+		v.profile(c.Profiles[name], []interface{}{"profiles", name})
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+func (v *validator) profile(p *Profile, path []interface{}) {
+	at := func(elems ...interface{}) []interface{} {
+		return append(slices.Clip(path), elems...)
+	}
+
+	v.expand(&p.Provider, at("provider")...)
+	v.expand(&p.AccessToken, at("access_token")...)
+	v.expand(&p.Version, at("version")...)
+	for key, value := range p.Settings {
+		v.expand(&value, at("settings", key)...)
+		p.Settings[key] = value
+	}
+	for key, value := range p.Filters {
+		v.expand(&value, at("filters", key)...)
+		p.Filters[key] = value
+	}
+	v.expand(&p.Filter, at("filter")...)
+	v.expand(&p.AssetFilter, at("asset_filter")...)
+	v.expand(&p.Output.Format, at("output", "format")...)
+	v.expand(&p.Output.File, at("output", "file")...)
+	v.expand(&p.Output.Delimiter, at("output", "delimiter")...)
+	for i := range p.Output.Columns {
+		v.expand(&p.Output.Columns[i], at("output", "columns", i)...)
+	}
+	v.expand(&p.Secrets, at("secrets")...)
+	v.secret(p.AccessToken, at("access_token"))
+
+	switch {
+	case p.Provider == "":
+		v.errorf(path, "provider is required")
+	case !slices.Contains(ads.ProviderNames(), p.Provider):
+		v.errorf(at("provider"), "unknown provider %q, registered: %s", p.Provider, strings.Join(ads.ProviderNames(), ", "))
+	}
+
+	if p.Concurrency < 0 {
+		v.errorf(at("concurrency"), "must not be negative")
+	}
+
+	if len(p.Accounts) == 0 && len(p.Agencies) == 0 {
+		v.errorf(path, "no accounts or agencies defined")
+	}
+
+	seen := make(map[string]bool)
+	account := func(id string, path []interface{}) {
+		switch {
+		case id == "":
+			v.errorf(path, "account id is required")
+		case seen[id]:
+			v.errorf(path, "account %s listed twice", id)
+		}
+		seen[id] = true
+	}
+
+	for i, acc := range p.Accounts {
+		if acc == nil {
+			v.errorf(at("accounts", i), "empty account")
+			continue
+		}
+		v.expand(&acc.ID, at("accounts", i, "id")...)
+		v.expand(&acc.Name, at("accounts", i, "name")...)
+		v.expand(&acc.AccessToken, at("accounts", i, "access_token")...)
+
+		account(acc.ID, at("accounts", i, "id"))
+		v.secret(acc.AccessToken, at("accounts", i, "access_token"))
+	}
+
+	for i, agency := range p.Agencies {
+		if agency == nil {
+			v.errorf(at("agencies", i), "empty agency")
+			continue
+		}
+		v.expand(&agency.ID, at("agencies", i, "id")...)
+		v.expand(&agency.Name, at("agencies", i, "name")...)
+		v.expand(&agency.AccessToken, at("agencies", i, "access_token")...)
+
+		if agency.ID == "" {
+			v.errorf(at("agencies", i, "id"), "agency id is required")
+		}
+		v.secret(agency.AccessToken, at("agencies", i, "access_token"))
+		for field, patterns := range map[string][]string{"include": agency.Include, "exclude": agency.Exclude} {
+			for j := range patterns {
+				v.expand(&patterns[j], at("agencies", i, field, j)...)
+				if pattern := patterns[j]; !validPattern(pattern) {
+					v.errorf(at("agencies", i, field, j), "invalid pattern %q", pattern)
+				}
+			}
+		}
+		for j := range agency.Accounts {
+			v.expand(&agency.Accounts[j], at("agencies", i, "accounts", j)...)
+			account(agency.Accounts[j], at("agencies", i, "accounts", j))
+		}
+	}
+
+	if p.Filter != "" {
+		if _, err := filter.Adcreatives(p.Filter); err != nil {
+			v.errorf(at("filter"), "%v", err)
+		}
+	}
+	if p.AssetFilter != "" {
+		if _, err := filter.Assets(p.AssetFilter); err != nil {
+			v.errorf(at("asset_filter"), "%v", err)
+		}
+	}
+
+	if f := p.Output.Format; f != "" && !slices.Contains(ads.ExportFormats(), f) {
+		v.errorf(at("output", "format"), "unknown format %q, one of %s", f, strings.Join(ads.ExportFormats(), ", "))
+	}
+	if d := p.Output.Delimiter; d != "" && d != "tab" && utf8.RuneCountInString(d) != 1 {
+		v.errorf(at("output", "delimiter"), "delimiter must be a single character or tab")
+	}
+	if len(p.Output.Columns) > 0 {
+		if _, err := ads.SelectColumns(p.Output.Columns); err != nil {
+			v.errorf(at("output", "columns"), "%v", err)
+		}
+	}
+}
+
+// secret checks the name of a secret:NAME token
+func (v *validator) secret(token string, path []interface{}) {
+	if token == "secret:" {
+		v.errorf(path, "secret name is required")
+	}
+}
+
+func validPattern(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
+
+// line returns the line of the field at path, or of its closest parent, 0
+// without a YAML document
+func (c *Config) line(path []interface{}) int {
+	if c.root == nil || len(c.root.Content) == 0 {
+		return 0
+	}
+
+	node := c.root.Content[0]
+	line := node.Line
+	for _, elem := range path {
+		var next *yaml.Node
+		switch elem := elem.(type) {
+		case string:
+			if node.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					if node.Content[i].Value == elem {
+						next = node.Content[i+1]
+						// report scalars on the line of their key
+						line = node.Content[i].Line
+						break
+					}
+				}
+			}
+		case int:
+			if node.Kind == yaml.SequenceNode && elem < len(node.Content) {
+				next = node.Content[elem]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return line
+}
+
+func pathString(path []interface{}) string {
+	var b strings.Builder
+	for _, elem := range path {
+		switch elem := elem.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(elem) + "]")
+		default:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			fmt.Fprint(&b, elem)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+// Package credentials looks up access tokens and app secrets by name so they
+// need not be passed as command line flags, from the environment, a directory
+// of secret files or a passphrase encrypted file.
+//
+// Names are slash separated, the ads command looks up the token of an account
+// as <provider>/<account id>, e.g. GDT/123456.
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned for names without a secret
+var ErrNotFound = errors.New("credentials: secret not found")
+
+// Store looks up secrets by name
+type Store interface {
+	Get(name string) (string, error)
+}
+
+// WritableStore is a store secrets can be saved to
+type WritableStore interface {
+	Store
+	Set(name, value string) error
+	Delete(name string) error
+	Names() ([]string, error)
+}
+
+// Chain looks a secret up in every store in turn
+type Chain []Store
+
+func (c Chain) Get(name string) (string, error) {
+	for _, store := range c {
+		value, err := store.Get(name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return value, err
+	}
+	return "", ErrNotFound
+}
+
+// Open opens the store of a location:
+//
+//	env:[PREFIX]       environment variables, ADS_SECRET_ by default
+//	file:DIR           a file per secret under DIR
+//	encrypted:FILE     a passphrase encrypted file, the default without scheme
+//
+// passphrase is only called for encrypted stores.
+func Open(location string, passphrase func() ([]byte, error)) (Store, error) {
+	scheme, rest, ok := strings.Cut(location, ":")
+	if !ok {
+		scheme, rest = "encrypted", location
+	}
+
+	switch scheme {
+	case "env":
+		return EnvStore{Prefix: rest}, nil
+	case "file":
+		return FileStore{Dir: rest}, nil
+	case "encrypted":
+		pass, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+		return OpenEncrypted(rest, pass)
+	default:
+		return nil, fmt.Errorf("credentials: unknown store %q", scheme)
+	}
+}
@@ -0,0 +1,195 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters of new files, stored in the file so they can be raised.
+// Files with larger parameters are rejected, they would make the key
+// derivation take unbounded memory and time.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrPassphrase is returned when a file cannot be decrypted
+var ErrPassphrase = errors.New("credentials: wrong passphrase or corrupted file")
+
+// EncryptedStore keeps secrets in a file encrypted with AES-256-GCM under a
+// key derived from a passphrase with scrypt
+type EncryptedStore struct {
+	Path string
+
+	mu         sync.Mutex
+	passphrase []byte
+	secrets    map[string]string
+}
+
+// encryptedFile is the layout of the file, only the ciphertext is secret
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// OpenEncrypted decrypts the file at path, a missing file is an empty store
+// created on the first Set
+func OpenEncrypted(path string, passphrase []byte) (*EncryptedStore, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("credentials: empty passphrase")
+	}
+
+	s := &EncryptedStore{Path: path, passphrase: passphrase, secrets: make(map[string]string)}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("credentials: read %s: %w", path, err)
+	}
+	if file.Version != 1 {
+		return nil, fmt.Errorf("credentials: unsupported file version %d", file.Version)
+	}
+
+	if file.N < 2 || file.N > scryptN || file.R < 1 || file.R > scryptR || file.P < 1 || file.P > scryptP {
+		return nil, fmt.Errorf("credentials: scrypt parameters n=%d r=%d p=%d out of bounds", file.N, file.R, file.P)
+	}
+	aead, err := newAEAD(passphrase, file.Salt, file.N, file.R, file.P)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+
+	if err := json.Unmarshal(plain, &s.secrets); err != nil {
+		return nil, ErrPassphrase
+	}
+	return s, nil
+}
+
+func (s *EncryptedStore) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (s *EncryptedStore) Set(name, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.secrets[name] = value
+	return s.save()
+}
+
+func (s *EncryptedStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.secrets, name)
+	return s.save()
+}
+
+// Names returns the sorted names of the secrets
+func (s *EncryptedStore) Names() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// save encrypts the secrets with a fresh salt and nonce
+func (s *EncryptedStore) save() error {
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+
+	file := encryptedFile{Version: 1, N: scryptN, R: scryptR, P: scryptP}
+	file.Salt = make([]byte, 16)
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(s.passphrase, file.Salt, file.N, file.R, file.P)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plain, nil)
+
+	b, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
+
+func newAEAD(passphrase, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// DefaultFile returns the encrypted store under the user config directory
+func DefaultFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "ads-secrets.json"
+	}
+	return filepath.Join(dir, "ads", "secrets.json")
+}
@@ -0,0 +1,78 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+
+	s, err := OpenEncrypted(path, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("gdt", "secret-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenEncrypted(path, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := s.Get("gdt"); err != nil || value != "secret-token" {
+		t.Errorf("Get = %q, %v", value, err)
+	}
+
+	if _, err := OpenEncrypted(path, []byte("wrong")); !errors.Is(err, ErrPassphrase) {
+		t.Errorf("wrong passphrase error = %v", err)
+	}
+}
+
+func TestEncryptedParameterBounds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+
+	s, err := OpenEncrypted(path, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("gdt", "secret-token"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		n, r, p int
+	}{
+		{"n", 1 << 30, scryptR, scryptP},
+		{"r", scryptN, 1 << 20, scryptP},
+		{"p", scryptN, scryptR, 1 << 20},
+		{"zero", 0, 0, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var file encryptedFile
+			if err := json.Unmarshal(b, &file); err != nil {
+				t.Fatal(err)
+			}
+			file.N, file.R, file.P = tt.n, tt.r, tt.p
+			tampered, err := json.Marshal(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tampered, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := OpenEncrypted(path, []byte("passphrase")); err == nil {
+				t.Error("parameters out of bounds accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvStore reads secrets from environment variables named after the prefix
+// and the upper cased name with other characters than letters and digits
+// replaced by _, GDT/123 is ADS_SECRET_GDT_123
+type EnvStore struct {
+	Prefix string
+}
+
+// Var returns the environment variable of a name
+func (s EnvStore) Var(name string) string {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "ADS_SECRET_"
+	}
+
+	return prefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func (s EnvStore) Get(name string) (string, error) {
+	value := os.Getenv(s.Var(name))
+	if value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// FileStore reads secrets from the files of a directory, as mounted by
+// container secrets, the file of GDT/123 is DIR/GDT/123
+type FileStore struct {
+	Dir string
+}
+
+func (s FileStore) Get(name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", ErrNotFound
+	}
+
+	b, err := os.ReadFile(filepath.Join(s.Dir, filepath.FromSlash(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
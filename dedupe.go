@@ -0,0 +1,147 @@
+package ads
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ImageClient downloads the images when FetchImage and HashAssets are given
+// no client, an image taking longer fails its asset only
+var ImageClient = &http.Client{Timeout: 30 * time.Second}
+
+// FetchImage downloads and decodes the image at url, with ImageClient when
+// client is nil
+func FetchImage(client *http.Client, url string) (image.Image, error) {
+	if client == nil {
+		client = ImageClient
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch image %s: %s", url, resp.Status)
+	}
+
+	img, _, err := image.Decode(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decode image %s: %w", url, err)
+	}
+	return img, nil
+}
+
+// HashAssets downloads the images and video cover images of assets and stores
+// their perceptual hash in Asset.PHash, assets already hashed are skipped.
+// Failed downloads are logged and leave the asset unhashed.
+func HashAssets(client *http.Client, assets []*Asset) (hashed int) {
+	log := zap.S()
+	for _, asset := range assets {
+		if asset.PHash != "" {
+			hashed++
+			continue
+		}
+
+		url := asset.ImageUrl()
+		if url == "" {
+			continue
+		}
+
+		img, err := FetchImage(client, url)
+		if err != nil {
+			log.Warnw("hash asset error", "asset_id", asset.AssetID, "error", err)
+			continue
+		}
+
+		asset.PHash = PHash(img).String()
+		hashed++
+	}
+	return
+}
+
+// DuplicateCluster is a group of assets with near identical images
+type DuplicateCluster struct {
+	Assets    []*Asset
+	Distances []int // distance of each asset to the first one
+}
+
+// ClusterDuplicates groups hashed assets whose PHash differ by at most
+// threshold bits. Only clusters with more than one asset are returned, in
+// order of their first asset.
+func ClusterDuplicates(assets []*Asset, threshold int) []*DuplicateCluster {
+	var (
+		hashed []*Asset
+		hashes []ImageHash
+	)
+
+	for _, asset := range assets {
+		if asset.PHash == "" {
+			continue
+		}
+		h, err := ParseImageHash(asset.PHash)
+		if err != nil {
+			continue
+		}
+		hashed = append(hashed, asset)
+		hashes = append(hashes, h)
+	}
+
+	parent := make([]int, len(hashed))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range hashes {
+		for j := i + 1; j < len(hashes); j++ {
+			if hashes[i].Distance(hashes[j]) <= threshold {
+				ri, rj := find(i), find(j)
+				if ri != rj {
+					parent[max(ri, rj)] = min(ri, rj)
+				}
+			}
+		}
+	}
+
+	var (
+		clusters []*DuplicateCluster
+		roots    = make(map[int]*DuplicateCluster)
+		firsts   = make(map[*DuplicateCluster]ImageHash)
+	)
+	for i, asset := range hashed {
+		root := find(i)
+		c, ok := roots[root]
+		if !ok {
+			c = &DuplicateCluster{}
+			roots[root] = c
+			firsts[c] = hashes[i]
+			clusters = append(clusters, c)
+		}
+		c.Assets = append(c.Assets, asset)
+		c.Distances = append(c.Distances, firsts[c].Distance(hashes[i]))
+	}
+
+	dups := clusters[:0]
+	for _, c := range clusters {
+		if len(c.Assets) > 1 {
+			dups = append(dups, c)
+		}
+	}
+	return dups
+}
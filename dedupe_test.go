@@ -0,0 +1,108 @@
+package ads
+
+import (
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClusterDuplicates(t *testing.T) {
+	hashed := func(id string, h ImageHash) *Asset {
+		return &Asset{AssetID: id, PHash: h.String()}
+	}
+	assets := []*Asset{
+		hashed("a", 0),
+		{AssetID: "unhashed"},
+		hashed("b", 0b111),
+		{AssetID: "invalid", PHash: "xyz"},
+		hashed("c", 0b1111111),
+		hashed("d", ^ImageHash(0)),
+		hashed("e", ^ImageHash(0b11)),
+	}
+
+	for _, tt := range []struct {
+		threshold int
+		want      string
+	}{
+		{threshold: 0, want: ""},
+		{threshold: 1, want: ""},
+		// d and e differ by 2 bits
+		{threshold: 2, want: "d=0,e=2"},
+		// b is 3 bits from a and 4 bits from c, c joins a through b
+		{threshold: 3, want: "a=0,b=3|d=0,e=2"},
+		{threshold: 4, want: "a=0,b=3,c=7|d=0,e=2"},
+		{threshold: 64, want: "a=0,b=3,c=7,d=64,e=62"},
+	} {
+		var clusters []string
+		for _, c := range ClusterDuplicates(assets, tt.threshold) {
+			if len(c.Assets) != len(c.Distances) {
+				t.Fatalf("threshold %d: %d assets of %d distances", tt.threshold, len(c.Assets), len(c.Distances))
+			}
+			var members []string
+			for i, a := range c.Assets {
+				members = append(members, a.AssetID+"="+strconv.Itoa(c.Distances[i]))
+			}
+			clusters = append(clusters, strings.Join(members, ","))
+		}
+		if got := strings.Join(clusters, "|"); got != tt.want {
+			t.Errorf("threshold %d: clusters %q, want %q", tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestHashAssets(t *testing.T) {
+	img := synthetic(64, 48, waves)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			png.Encode(w, img)
+		case "/text":
+			w.Write([]byte("not an image"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	assets := []*Asset{
+		{AssetID: "image", PageType: PTImage, SubAssets: []*SubAsset{{Type: SATImage, Url: srv.URL + "/image.png"}}},
+		{AssetID: "hashed", PageType: PTImage, PHash: "00000000000000ff", SubAssets: []*SubAsset{{Type: SATImage, Url: srv.URL + "/missing"}}},
+		{AssetID: "missing", PageType: PTImage, SubAssets: []*SubAsset{{Type: SATImage, Url: srv.URL + "/missing"}}},
+		{AssetID: "text", PageType: PTVideo, SubAssets: []*SubAsset{{Type: SATImage, Url: srv.URL + "/text"}}},
+		{AssetID: "no image", PageType: PTText, Texts: []string{"title"}},
+	}
+	if hashed := HashAssets(nil, assets); hashed != 2 {
+		t.Errorf("hashed %d assets, want 2", hashed)
+	}
+	if want := PHash(img).String(); assets[0].PHash != want {
+		t.Errorf("hash %s, want %s", assets[0].PHash, want)
+	}
+	if assets[1].PHash != "00000000000000ff" {
+		t.Errorf("hash of a hashed asset replaced by %s", assets[1].PHash)
+	}
+	for _, a := range assets[2:] {
+		if a.PHash != "" {
+			t.Errorf("asset %s hashed", a.AssetID)
+		}
+	}
+}
+
+func TestFetchImageTimeout(t *testing.T) {
+	stalled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	defer srv.Close()
+	defer close(stalled)
+
+	defer func(c *http.Client) { ImageClient = c }(ImageClient)
+	ImageClient = &http.Client{Timeout: 50 * time.Millisecond}
+
+	if _, err := FetchImage(nil, srv.URL); err == nil {
+		t.Error("stalled download without a timeout error")
+	}
+}
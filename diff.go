@@ -0,0 +1,166 @@
+package ads
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota + 1
+	ChangeRemoved
+	ChangeModified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// MarshalText
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// AssetKey identifies an asset across snapshots
+type AssetKey struct {
+	AccountID string
+	AssetID   string
+	PageType  PageType
+}
+
+// KeyOf returns the key of an asset
+func KeyOf(a *Asset) AssetKey {
+	return AssetKey{AccountID: a.AccountID, AssetID: a.AssetID, PageType: a.PageType}
+}
+
+func (k AssetKey) String() string {
+	return k.AccountID + "/" + k.PageType.String() + "/" + k.AssetID
+}
+
+// FieldChange is the old and new value of a modified asset field
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Change describes an added, removed or modified asset
+type Change struct {
+	Kind   ChangeKind
+	Key    AssetKey
+	Old    *Asset `json:",omitempty"`
+	New    *Asset `json:",omitempty"`
+	Fields []FieldChange
+}
+
+// diffFields are the compared asset fields, the adcreative link is compared
+// separately because an asset is listed once per adcreative
+var diffFields = []struct {
+	Name  string
+	Value func(a *Asset) string
+}{
+	{"AccountName", func(a *Asset) string { return a.AccountName }},
+	{"Name", func(a *Asset) string { return a.Name }},
+	{"SubType", func(a *Asset) string { return a.SubType }},
+	{"Texts", func(a *Asset) string { return strings.Join(a.Texts, "\n") }},
+	{"SubAssets", func(a *Asset) string {
+		urls := make([]string, 0, len(a.SubAssets))
+		for _, sub := range a.SubAssets {
+			urls = append(urls, sub.Type.String()+" "+sub.Url)
+		}
+		return strings.Join(urls, "\n")
+	}},
+	{"Size", func(a *Asset) string { return fmt.Sprintf("%dx%d", a.Width, a.Height) }},
+	{"Signature", func(a *Asset) string { return a.Signature }},
+	{"PHash", func(a *Asset) string { return a.PHash }},
+}
+
+type diffEntry struct {
+	asset       *Asset
+	adcreatives Set[string]
+}
+
+func indexAssets(assets []*Asset) (keys []AssetKey, index map[AssetKey]*diffEntry) {
+	index = make(map[AssetKey]*diffEntry)
+	for _, a := range assets {
+		key := KeyOf(a)
+		entry, ok := index[key]
+		if !ok {
+			entry = &diffEntry{asset: a, adcreatives: make(Set[string])}
+			index[key] = entry
+			keys = append(keys, key)
+		}
+		if a.AdcreativeID != "" && a.AdcreativeID != "0" {
+			entry.adcreatives.Add(a.AdcreativeID)
+		}
+	}
+	return
+}
+
+// Diff compares two snapshots of assets keyed by account, asset id and page
+// type. Changes are returned added first, then removed and modified, each in
+// key order.
+func Diff(old, new []*Asset) []*Change {
+	var (
+		changes           []*Change
+		oldKeys, oldIndex = indexAssets(old)
+		newKeys, newIndex = indexAssets(new)
+	)
+
+	for _, key := range newKeys {
+		if _, ok := oldIndex[key]; !ok {
+			changes = append(changes, &Change{Kind: ChangeAdded, Key: key, New: newIndex[key].asset})
+		}
+	}
+
+	for _, key := range oldKeys {
+		o := oldIndex[key]
+		n, ok := newIndex[key]
+		if !ok {
+			changes = append(changes, &Change{Kind: ChangeRemoved, Key: key, Old: o.asset})
+			continue
+		}
+
+		var fields []FieldChange
+		for _, f := range diffFields {
+			ov, nv := f.Value(o.asset), f.Value(n.asset)
+			if ov != nv {
+				fields = append(fields, FieldChange{Field: f.Name, Old: ov, New: nv})
+			}
+		}
+
+		ocr, ncr := sortedKeys(o.adcreatives), sortedKeys(n.adcreatives)
+		if ocr != ncr {
+			fields = append(fields, FieldChange{Field: "Adcreatives", Old: ocr, New: ncr})
+		}
+
+		if len(fields) > 0 {
+			changes = append(changes, &Change{Kind: ChangeModified, Key: key, Old: o.asset, New: n.asset, Fields: fields})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Key.String() < changes[j].Key.String()
+	})
+	return changes
+}
+
+func sortedKeys(s Set[string]) string {
+	keys := s.Slice()
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
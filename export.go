@@ -0,0 +1,127 @@
+package ads
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hysios/x/providers"
+)
+
+// Exporter writes assets in an export format. Write may be called several
+// times, Close flushes the remaining output but does not close the
+// underlying writer.
+type Exporter interface {
+	Write(assets []*Asset) error
+	Close() error
+}
+
+// ExportOptions configures tabular exporters, json formats ignore them
+type ExportOptions struct {
+	Columns []string // column names, DefaultColumns when empty
+	Explode bool     // one row per sub asset, csv only
+	Comma   rune     // field delimiter, csv only, defaults to ','
+	BOM     bool     // write a UTF-8 byte order mark for Excel, csv only
+}
+
+type ExporterFunc func(w io.Writer, opts ExportOptions) (Exporter, error)
+
+var exporters providers.Provider[string, ExporterFunc]
+
+// RegisterExporter registers the exporter constructor of a format
+func RegisterExporter(format string, f ExporterFunc) {
+	exporters.Register(format, f)
+}
+
+// NewExporter creates an exporter of format writing to w
+func NewExporter(format string, w io.Writer, opts ExportOptions) (Exporter, error) {
+	ctor, ok := exporters.Lookup(strings.ToLower(format))
+	if !ok {
+		return nil, fmt.Errorf("export format %s not found", format)
+	}
+
+	return ctor(w, opts)
+}
+
+// ExportFormats returns the registered export formats in sorted order
+func ExportFormats() []string {
+	var formats []string
+	exporters.Range(func(format string, _ ExporterFunc) bool {
+		formats = append(formats, format)
+		return true
+	})
+	sort.Strings(formats)
+	return formats
+}
+
+// jsonExporter writes a single json array of assets
+type jsonExporter struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonExporter) Write(assets []*Asset) error {
+	for _, asset := range assets {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("  ", "  ")
+		if err := enc.Encode(asset); err != nil {
+			return err
+		}
+
+		sep := ",\n  "
+		if e.count == 0 {
+			sep = "[\n  "
+		}
+		if _, err := io.WriteString(e.w, sep); err != nil {
+			return err
+		}
+		if _, err := e.w.Write(bytes.TrimRight(buf.Bytes(), "\n")); err != nil {
+			return err
+		}
+		e.count++
+	}
+	return nil
+}
+
+func (e *jsonExporter) Close() error {
+	if e.count == 0 {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+// jsonlExporter writes one json asset per line
+type jsonlExporter struct {
+	enc *json.Encoder
+}
+
+func (e *jsonlExporter) Write(assets []*Asset) error {
+	for _, asset := range assets {
+		if err := e.enc.Encode(asset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *jsonlExporter) Close() error {
+	return nil
+}
+
+func init() {
+	RegisterExporter("json", func(w io.Writer, _ ExportOptions) (Exporter, error) {
+		return &jsonExporter{w: w}, nil
+	})
+	RegisterExporter("jsonl", func(w io.Writer, _ ExportOptions) (Exporter, error) {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return &jsonlExporter{enc: enc}, nil
+	})
+}
@@ -0,0 +1,99 @@
+package ads
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvExporter writes a row per asset, or per sub asset in explode mode, with
+// the selected columns of the schema
+type csvExporter struct {
+	w       io.Writer
+	csv     *csv.Writer
+	columns []*Column
+	explode bool
+	bom     bool
+	header  bool
+}
+
+// NewCSVExporter creates a schema driven csv exporter
+func NewCSVExporter(w io.Writer, opts ExportOptions) (Exporter, error) {
+	columns, err := SelectColumns(opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+
+	return &csvExporter{
+		w:       w,
+		csv:     cw,
+		columns: columns,
+		explode: opts.Explode,
+		bom:     opts.BOM,
+	}, nil
+}
+
+func (e *csvExporter) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+
+	if e.bom {
+		if _, err := io.WriteString(e.w, "\ufeff"); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		names[i] = c.Name
+	}
+	return e.csv.Write(names)
+}
+
+func (e *csvExporter) Write(assets []*Asset) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	for _, asset := range assets {
+		if !e.explode || len(asset.SubAssets) == 0 {
+			if err := e.writeRow(asset, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, sub := range asset.SubAssets {
+			if err := e.writeRow(asset, sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *csvExporter) writeRow(asset *Asset, sub *SubAsset) error {
+	row := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		row[i] = c.Get(asset, sub)
+	}
+	return e.csv.Write(row)
+}
+
+func (e *csvExporter) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func init() {
+	RegisterExporter("csv", NewCSVExporter)
+}
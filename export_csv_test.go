@@ -0,0 +1,125 @@
+package ads
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exportAssets are assets with every exported field set
+func exportAssets() []*Asset {
+	return []*Asset{
+		{
+			Provider: "GDT", AccountID: "1001", AccountName: "shop, inc", AdcreativeID: "21", AdcreativeName: "spring \"sale\"",
+			AssetID: "img1", Name: "banner", PageType: PTImage, SubType: "LARGE",
+			SubAssets: []*SubAsset{
+				{Type: SATImage, Url: "https://example.com/a.jpg"},
+				{Type: SATPageUrl, Url: "https://example.com/a"},
+			},
+			Width: 1280, Height: 720, Signature: "sig1", Version: "v3", PHash: "p:00ff",
+			CreatedTime:      time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+			LastModifiedTime: time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Provider: "GDT", AccountID: "1001", AccountName: "shop, inc", AdcreativeID: "22",
+			AssetID: "22", PageType: PTText, Texts: []string{"line one", "line two;\nwrapped"},
+		},
+	}
+}
+
+func exportCSV(t *testing.T, assets []*Asset, opts ExportOptions) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	e, err := NewExporter("csv", &buf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Write(assets); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	for _, opts := range []ExportOptions{
+		{},
+		{Comma: ';'},
+		{Comma: '\t', BOM: true},
+		{Comma: '|', BOM: true},
+	} {
+		b := exportCSV(t, exportAssets(), opts)
+		if bom := bytes.HasPrefix(b, []byte("\ufeff")); bom != opts.BOM {
+			t.Errorf("%+v: byte order mark %v", opts, bom)
+		}
+
+		comma := opts.Comma
+		if comma == 0 {
+			comma = ','
+		}
+		header, _, _ := strings.Cut(strings.TrimPrefix(string(b), "\ufeff"), "\n")
+		if want := strings.Join(DefaultColumns(), string(comma)); header != want {
+			t.Errorf("%+v: header %q, want %q", opts, header, want)
+		}
+
+		got, err := ReadAssets(bytes.NewReader(b), "csv")
+		if err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		if want := exportAssets(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%+v: read back\n%s\nwant\n%s", opts, dump(got), dump(want))
+		}
+	}
+}
+
+func TestCSVExplode(t *testing.T) {
+	b := exportCSV(t, exportAssets(), ExportOptions{
+		Columns: []string{"AssetID", "SubAssetIndex", "SubAssetType", "SubAssetUrl", "PrimaryUrl"},
+		Explode: true,
+	})
+
+	rows, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"AssetID", "SubAssetIndex", "SubAssetType", "SubAssetUrl", "PrimaryUrl"},
+		{"img1", "0", "SATImage", "https://example.com/a.jpg", "https://example.com/a.jpg"},
+		{"img1", "1", "SATPageUrl", "https://example.com/a", "https://example.com/a.jpg"},
+		{"22", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows %q, want %q", rows, want)
+	}
+}
+
+func TestCSVEmpty(t *testing.T) {
+	b := exportCSV(t, nil, ExportOptions{Columns: []string{"AccountID", "AssetID"}, BOM: true})
+	if string(b) != "\ufeffAccountID,AssetID\n" {
+		t.Errorf("empty export %q", b)
+	}
+}
+
+func dump(assets []*Asset) string {
+	var b strings.Builder
+	for _, a := range assets {
+		b.WriteString(strings.Join(rowOf(a), " | ") + "\n")
+	}
+	return b.String()
+}
+
+// rowOf returns the default columns of an asset
+func rowOf(a *Asset) []string {
+	columns, _ := SelectColumns(nil)
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		row[i] = c.Get(a, nil)
+	}
+	return row
+}
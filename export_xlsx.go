@@ -0,0 +1,226 @@
+package ads
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxStyleDefault = 0
+	xlsxStyleHeader  = 1
+	xlsxStyleWrap    = 2
+)
+
+var xlsxSubAssetsHeader = []string{"AccountID", "AssetID", "PageType", "Index", "Type", "Url"}
+
+// xlsxExporter writes an Assets sheet with a row per asset, Texts as
+// multiline cells, and a SubAssets sheet with a row per sub asset
+type xlsxExporter struct {
+	zip       *zip.Writer
+	columns   []*Column
+	sheet     *bufio.Writer
+	rows      int
+	subAssets [][]string
+}
+
+type xlsxCell struct {
+	Value string
+	Style int
+}
+
+func (e *xlsxExporter) open() error {
+	if e.sheet != nil {
+		return nil
+	}
+
+	w, err := e.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	e.sheet = bufio.NewWriter(w)
+
+	header := make([]xlsxCell, len(e.columns))
+	for i, c := range e.columns {
+		header[i] = xlsxCell{c.Name, xlsxStyleHeader}
+	}
+
+	e.sheet.WriteString(xlsxSheetStart)
+	return e.writeRow(e.sheet, &e.rows, header)
+}
+
+func (e *xlsxExporter) Write(assets []*Asset) error {
+	if err := e.open(); err != nil {
+		return err
+	}
+
+	for _, asset := range assets {
+		row := make([]xlsxCell, len(e.columns))
+		for i, c := range e.columns {
+			if c.Name == "Texts" {
+				row[i] = xlsxCell{strings.Join(asset.Texts, "\n"), xlsxStyleWrap}
+			} else {
+				row[i] = xlsxCell{c.Get(asset, nil), xlsxStyleDefault}
+			}
+		}
+
+		if err := e.writeRow(e.sheet, &e.rows, row); err != nil {
+			return err
+		}
+
+		for i, sub := range asset.SubAssets {
+			e.subAssets = append(e.subAssets, []string{
+				asset.AccountID, asset.AssetID, asset.PageType.String(), strconv.Itoa(i), sub.Type.String(), sub.Url,
+			})
+		}
+	}
+	return nil
+}
+
+func (e *xlsxExporter) Close() error {
+	if err := e.open(); err != nil {
+		return err
+	}
+
+	e.sheet.WriteString(xlsxSheetEnd)
+	if err := e.sheet.Flush(); err != nil {
+		return err
+	}
+
+	w, err := e.zip.Create("xl/worksheets/sheet2.xml")
+	if err != nil {
+		return err
+	}
+
+	var (
+		sheet = bufio.NewWriter(w)
+		rows  int
+		cells = make([]xlsxCell, len(xlsxSubAssetsHeader))
+	)
+	sheet.WriteString(xlsxSheetStart)
+	for i, name := range xlsxSubAssetsHeader {
+		cells[i] = xlsxCell{name, xlsxStyleHeader}
+	}
+	if err := e.writeRow(sheet, &rows, cells); err != nil {
+		return err
+	}
+	for _, row := range e.subAssets {
+		for i, value := range row {
+			cells[i] = xlsxCell{value, xlsxStyleDefault}
+		}
+		if err := e.writeRow(sheet, &rows, cells); err != nil {
+			return err
+		}
+	}
+	sheet.WriteString(xlsxSheetEnd)
+	if err := sheet.Flush(); err != nil {
+		return err
+	}
+
+	for _, part := range xlsxParts {
+		w, err := e.zip.Create(part.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, xml.Header+part.Body); err != nil {
+			return err
+		}
+	}
+	return e.zip.Close()
+}
+
+func (e *xlsxExporter) writeRow(w *bufio.Writer, rows *int, cells []xlsxCell) error {
+	*rows++
+	r := strconv.Itoa(*rows)
+
+	w.WriteString(`<row r="` + r + `">`)
+	for i, cell := range cells {
+		w.WriteString(`<c r="` + xlsxColumn(i) + r + `" t="inlineStr"`)
+		if cell.Style != xlsxStyleDefault {
+			w.WriteString(` s="` + strconv.Itoa(cell.Style) + `"`)
+		}
+		w.WriteString(`><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(w, []byte(cell.Value)); err != nil {
+			return err
+		}
+		w.WriteString(`</t></is></c>`)
+	}
+	_, err := w.WriteString("</row>")
+	return err
+}
+
+// xlsxColumn returns the column letters of a zero based index
+func xlsxColumn(i int) string {
+	var col []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		col = append([]byte{byte('A' + (i-1)%26)}, col...)
+	}
+	return string(col)
+}
+
+const (
+	xlsxSheetStart = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+var xlsxParts = []struct {
+	Name string
+	Body string
+}{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Assets" sheetId="1" r:id="rId1"/><sheet name="SubAssets" sheetId="2" r:id="rId2"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>` +
+		`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyAlignment="1"><alignment vertical="top" wrapText="1"/></xf>` +
+		`</cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`},
+}
+
+func init() {
+	RegisterExporter("xlsx", func(w io.Writer, opts ExportOptions) (Exporter, error) {
+		// sub assets have their own sheet
+		names := opts.Columns
+		if len(names) == 0 {
+			for _, name := range DefaultColumns() {
+				if name != "SubAssets" {
+					names = append(names, name)
+				}
+			}
+		}
+
+		columns, err := SelectColumns(names)
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxExporter{zip: zip.NewWriter(w), columns: columns}, nil
+	})
+}
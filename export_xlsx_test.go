@@ -0,0 +1,141 @@
+package ads
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// xlsxSheet is the part of a worksheet read back by the tests
+type xlsxSheet struct {
+	Rows []struct {
+		R     string `xml:"r,attr"`
+		Cells []struct {
+			R     string `xml:"r,attr"`
+			T     string `xml:"t,attr"`
+			S     string `xml:"s,attr"`
+			Value string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// values returns the cell values of the rows
+func (s *xlsxSheet) values() [][]string {
+	var values [][]string
+	for _, row := range s.Rows {
+		var cells []string
+		for _, c := range row.Cells {
+			cells = append(cells, c.Value)
+		}
+		values = append(values, cells)
+	}
+	return values
+}
+
+func TestXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	e, err := NewExporter("xlsx", &buf, ExportOptions{Columns: []string{"AccountName", "AssetID", "Texts", "PrimaryUrl"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Write(exportAssets()); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string][]byte)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = b
+
+		// every part is well formed
+		dec := xml.NewDecoder(bytes.NewReader(b))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", f.Name, err)
+			}
+		}
+	}
+
+	var names []string
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := []string{
+		"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/styles.xml",
+		"xl/workbook.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml",
+	}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("parts %q, want %q", names, want)
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(parts["xl/workbook.xml"], &workbook); err != nil {
+		t.Fatal(err)
+	}
+	if len(workbook.Sheets) != 2 || workbook.Sheets[0].Name != "Assets" || workbook.Sheets[1].Name != "SubAssets" {
+		t.Errorf("sheets %+v", workbook.Sheets)
+	}
+
+	var assets xlsxSheet
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &assets); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{
+		{"AccountName", "AssetID", "Texts", "PrimaryUrl"},
+		{"shop, inc", "img1", "", "https://example.com/a.jpg"},
+		{"shop, inc", "22", "line one\nline two;\nwrapped", ""},
+	}; !reflect.DeepEqual(assets.values(), want) {
+		t.Errorf("assets sheet %q, want %q", assets.values(), want)
+	}
+	if c := assets.Rows[0].Cells[0]; c.R != "A1" || c.T != "inlineStr" || c.S != "1" {
+		t.Errorf("header cell %+v", c)
+	}
+	if c := assets.Rows[2].Cells[2]; c.R != "C3" || c.S != "2" {
+		t.Errorf("texts cell %+v", c)
+	}
+
+	var subAssets xlsxSheet
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet2.xml"], &subAssets); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{
+		xlsxSubAssetsHeader,
+		{"1001", "img1", "PTImage", "0", "SATImage", "https://example.com/a.jpg"},
+		{"1001", "img1", "PTImage", "1", "SATPageUrl", "https://example.com/a"},
+	}; !reflect.DeepEqual(subAssets.values(), want) {
+		t.Errorf("sub assets sheet %q, want %q", subAssets.values(), want)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(i); got != want {
+			t.Errorf("column %d = %s, want %s", i, got, want)
+		}
+	}
+}
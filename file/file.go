@@ -0,0 +1,237 @@
+// Package file lists the assets of a snapshot exported by ads export as json,
+// jsonl, csv or any format with a registered reader, so the downstream
+// commands run offline without credentials. The account id of the provider
+// is the path of the snapshot, the access token is ignored. Snapshots cannot
+// be synced into a catalog: it keys removals and watermarks by the account
+// synced, the path, while the assets keep the accounts of the snapshot.
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/stretchr/objx"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	OnlyAdcreatives bool
+	// Format is the snapshot format, defaults to the file extension
+	Format string
+	// Accounts keeps the assets of these accounts, all when empty
+	Accounts []string
+}
+
+type FileAdcreatives struct {
+	Path           string
+	Config         Config
+	adcreativesFns []ads.AdcreativeMatchFunc
+	log            *zap.SugaredLogger
+}
+
+func NewAdcreatives(path string) *FileAdcreatives {
+	f := &FileAdcreatives{
+		Path: path,
+		log:  zap.S().With("provider", "FILE"),
+	}
+
+	f.SetAdcreativesFunc(ads.NotDeleted)
+
+	return f
+}
+
+// Assets reads the assets of the snapshot. The assets of an adcreative are
+// kept when the adcreative matches the funcs, it is built from the exported
+// columns with the adcreative_id, adcreative_name, account_id, account_name,
+// created_time and last_modified_time fields. Assets of no adcreative are
+// kept unless OnlyAdcreatives is on.
+func (f *FileAdcreatives) Assets() ([]*ads.Asset, error) {
+	assets, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		adcreatives = make(map[string]ads.Map)
+		matches     = make(map[string]bool)
+		match       = ads.And(f.adcreativesFns...)
+	)
+	for _, asset := range assets {
+		if asset.AdcreativeID == "" {
+			continue
+		}
+		key := asset.AccountID + "/" + asset.AdcreativeID
+		if adcr, ok := adcreatives[key]; ok {
+			merge(adcr, asset)
+		} else {
+			adcreatives[key] = adcreative(asset)
+		}
+	}
+	for key, adcr := range adcreatives {
+		matches[key] = match(adcr)
+	}
+
+	var kept []*ads.Asset
+	for _, asset := range assets {
+		if asset.AdcreativeID == "" {
+			if !f.Config.OnlyAdcreatives {
+				kept = append(kept, asset)
+			}
+			continue
+		}
+		if matches[asset.AccountID+"/"+asset.AdcreativeID] {
+			kept = append(kept, asset)
+		}
+	}
+	f.log.Debugw("read snapshot", "path", f.Path, "assets", len(assets), "kept", len(kept))
+	return kept, nil
+}
+
+// AssetsSince reads the assets modified after since, the watermark is the
+// latest modification time of the snapshot
+func (f *FileAdcreatives) AssetsSince(since time.Time) (assets []*ads.Asset, watermark time.Time, err error) {
+	all, err := f.Assets()
+	if err != nil {
+		return nil, since, err
+	}
+
+	watermark = since
+	for _, asset := range all {
+		modified := asset.LastModifiedTime
+		if modified.IsZero() {
+			modified = asset.CreatedTime
+		}
+		if modified.After(since) {
+			assets = append(assets, asset)
+		}
+		if modified.After(watermark) {
+			watermark = modified
+		}
+	}
+	return assets, watermark, nil
+}
+
+// read reads the snapshot and keeps the assets of the configured accounts
+func (f *FileAdcreatives) read() ([]*ads.Asset, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	format := f.Config.Format
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(f.Path), ".")
+	}
+	assets, err := ads.ReadAssets(file, format)
+	if err != nil {
+		return nil, fmt.Errorf("file: %s: %w", f.Path, err)
+	}
+	if len(f.Config.Accounts) == 0 {
+		return assets, nil
+	}
+
+	accounts := make(map[string]bool)
+	for _, id := range f.Config.Accounts {
+		accounts[id] = true
+	}
+	var kept []*ads.Asset
+	for _, asset := range assets {
+		if accounts[asset.AccountID] {
+			kept = append(kept, asset)
+		}
+	}
+	return kept, nil
+}
+
+// adcreative builds the adcreative matched by the funcs from its first asset
+func adcreative(asset *ads.Asset) ads.Map {
+	adcr := objx.New(map[string]interface{}{
+		"adcreative_id":   asset.AdcreativeID,
+		"adcreative_name": asset.AdcreativeName,
+		"account_id":      asset.AccountID,
+		"account_name":    asset.AccountName,
+	})
+	merge(adcr, asset)
+	return adcr
+}
+
+// merge sets the created_time of the adcreative to the earliest creation
+// time of its assets and last_modified_time to the latest modification time
+func merge(adcr ads.Map, asset *ads.Asset) {
+	if t := asset.CreatedTime; !t.IsZero() {
+		if created := adcr.Get("created_time"); created.IsNil() || t.Unix() < created.Int64() {
+			adcr.Set("created_time", t.Unix())
+		}
+	}
+	if t := asset.LastModifiedTime; !t.IsZero() {
+		if modified := adcr.Get("last_modified_time"); modified.IsNil() || t.Unix() > modified.Int64() {
+			adcr.Set("last_modified_time", t.Unix())
+		}
+	}
+}
+
+// SetAdcreativesFunc ...
+func (f *FileAdcreatives) SetAdcreativesFunc(match ads.AdcreativeMatchFunc) {
+	f.adcreativesFns = append(f.adcreativesFns, match)
+}
+
+// ClearAdcreativesFuncs ...
+func (f *FileAdcreatives) ClearAdcreativesFuncs() {
+	f.adcreativesFns = nil
+}
+
+// OnlyAdcreatives drops the assets of no adcreative
+func (f *FileAdcreatives) OnlyAdcreatives(on bool) {
+	f.Config.OnlyAdcreatives = on
+}
+
+// Configure applies provider settings: format overrides the file extension,
+// account is a comma separated list of the accounts kept
+func (f *FileAdcreatives) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		switch key {
+		case "format":
+			f.Config.Format = value
+		case "account":
+			f.Config.Accounts = nil
+			for _, id := range strings.Split(value, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					f.Config.Accounts = append(f.Config.Accounts, id)
+				}
+			}
+		default:
+			return fmt.Errorf("file: unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+var (
+	_ ads.GetAdcreatives         = (*FileAdcreatives)(nil)
+	_ ads.IncrementalAdcreatives = (*FileAdcreatives)(nil)
+	_ ads.Configurable           = (*FileAdcreatives)(nil)
+)
+
+func init() {
+	ads.RegisterProvider("FILE", func(accountId string, _ string, _ bool) (ads.GetAdcreatives, error) {
+		if accountId == "" {
+			return nil, fmt.Errorf("file: no snapshot path")
+		}
+		return NewAdcreatives(accountId), nil
+	})
+	ads.DescribeProvider(ads.ProviderInfo{
+		Name:         "FILE",
+		Description:  "snapshot exported by ads export, the account is its path, cannot be synced",
+		Capabilities: []ads.Capability{ads.CapIncremental, ads.CapOnlyAdcreatives, ads.CapDownload},
+		PageTypes:    []ads.PageType{ads.PTPageUrl, ads.PTVideo, ads.PTImage, ads.PTText},
+		Settings: []ads.Setting{
+			{Name: "format", Description: "snapshot format, defaults to the file extension"},
+			{Name: "account", Description: "comma separated list of the accounts kept"},
+		},
+	})
+}
@@ -0,0 +1,197 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 10, 0, 0, 0, time.UTC)
+}
+
+// snapshot are the assets of the exported snapshot
+func snapshot() []*ads.Asset {
+	return []*ads.Asset{
+		{
+			AccountID: "1", AdcreativeID: "21", AdcreativeName: "spring", AssetID: "img1", PageType: ads.PTImage,
+			SubAssets:   []*ads.SubAsset{{Type: ads.SATImage, Url: "https://example.com/1.jpg"}},
+			CreatedTime: day(1), LastModifiedTime: day(5),
+		},
+		{AccountID: "1", AdcreativeID: "21", AssetID: "21", PageType: ads.PTText, Texts: []string{"title"}, LastModifiedTime: day(3)},
+		{AccountID: "1", AdcreativeID: "22", AssetID: "22", PageType: ads.PTText, Texts: []string{"old"}, LastModifiedTime: day(2)},
+		{AccountID: "2", AdcreativeID: "23", AssetID: "v1", PageType: ads.PTVideo, CreatedTime: day(4)},
+		{AccountID: "1", AssetID: "img2", PageType: ads.PTImage, CreatedTime: day(1)},
+	}
+}
+
+// export writes the snapshot in format to a file of the name
+func export(t *testing.T, format, name string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	w, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	e, err := ads.NewExporter(format, w, ads.ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Write(snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func assetIDs(assets []*ads.Asset) string {
+	var ids []string
+	for _, a := range assets {
+		ids = append(ids, a.AssetID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestAssets(t *testing.T) {
+	for _, format := range []string{"json", "jsonl", "csv"} {
+		f := NewAdcreatives(export(t, format, "snapshot."+format))
+		assets, err := f.Assets()
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(assets, snapshot()) {
+			t.Errorf("%s: read assets %s", format, assetIDs(assets))
+		}
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	f := NewAdcreatives(export(t, "jsonl", "snapshot.data"))
+	if _, err := f.Assets(); err == nil {
+		t.Error("snapshot of an unknown extension read")
+	}
+
+	if err := f.Configure(map[string]string{"format": "jsonl", "account": " 2, 3 "}); err != nil {
+		t.Fatal(err)
+	}
+	assets, err := f.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := assetIDs(assets); got != "v1" {
+		t.Errorf("assets of account 2: %s", got)
+	}
+
+	if err := f.Configure(map[string]string{"version": "v1"}); err == nil {
+		t.Error("unknown setting accepted")
+	}
+	if _, err := NewAdcreatives(filepath.Join(t.TempDir(), "missing.json")).Assets(); err == nil {
+		t.Error("missing snapshot read")
+	}
+}
+
+func TestAdcreativesFuncs(t *testing.T) {
+	path := export(t, "json", "snapshot.json")
+
+	for _, tt := range []struct {
+		name  string
+		match ads.AdcreativeMatchFunc
+		only  bool
+		want  string
+	}{
+		{name: "all", match: func(ads.Map) bool { return true }, want: "img1,21,22,v1,img2"},
+		{name: "only adcreatives", match: func(ads.Map) bool { return true }, only: true, want: "img1,21,22,v1"},
+		{
+			name:  "adcreative id",
+			match: func(adcr ads.Map) bool { return adcr.Get("adcreative_id").Str() == "22" },
+			want:  "22,img2",
+		},
+		{
+			// the adcreative is modified when its latest asset is
+			name: "modified",
+			match: func(adcr ads.Map) bool {
+				return adcr.Get("last_modified_time").Int64() >= day(4).Unix()
+			},
+			want: "img1,21,img2",
+		},
+		{
+			// the adcreative is created with its earliest asset
+			name: "created",
+			match: func(adcr ads.Map) bool {
+				created := adcr.Get("created_time")
+				return !created.IsNil() && created.Int64() < day(2).Unix()
+			},
+			only: true,
+			want: "img1,21",
+		},
+		{
+			name: "account",
+			match: func(adcr ads.Map) bool {
+				return adcr.Get("account_id").Str() == "2"
+			},
+			only: true,
+			want: "v1",
+		},
+	} {
+		f := NewAdcreatives(path)
+		f.SetAdcreativesFunc(tt.match)
+		f.OnlyAdcreatives(tt.only)
+		assets, err := f.Assets()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := assetIDs(assets); got != tt.want {
+			t.Errorf("%s: assets %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	f := NewAdcreatives(path)
+	f.SetAdcreativesFunc(func(ads.Map) bool { return false })
+	f.ClearAdcreativesFuncs()
+	if assets, err := f.Assets(); err != nil || len(assets) != 5 {
+		t.Errorf("assets without funcs %d, %v", len(assets), err)
+	}
+}
+
+func TestAssetsSince(t *testing.T) {
+	f := NewAdcreatives(export(t, "csv", "snapshot.csv"))
+
+	for _, tt := range []struct {
+		since     time.Time
+		want      string
+		watermark time.Time
+	}{
+		{since: time.Time{}, want: "img1,21,22,v1,img2", watermark: day(5)},
+		// modified after the watermark, or created for assets never modified
+		{since: day(2), want: "img1,21,v1", watermark: day(5)},
+		{since: day(5), want: "", watermark: day(5)},
+		{since: day(9), want: "", watermark: day(9)},
+	} {
+		assets, watermark, err := f.AssetsSince(tt.since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := assetIDs(assets); got != tt.want {
+			t.Errorf("since %v: assets %s, want %s", tt.since, got, tt.want)
+		}
+		if !watermark.Equal(tt.watermark) {
+			t.Errorf("since %v: watermark %v, want %v", tt.since, watermark, tt.watermark)
+		}
+	}
+}
+
+func TestProvider(t *testing.T) {
+	if _, err := ads.Open("FILE", "", "", false); err == nil {
+		t.Error("provider without a snapshot path")
+	}
+}
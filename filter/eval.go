@@ -0,0 +1,227 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+)
+
+func (n *binary) eval(get Getter) interface{} {
+	if n.op == "&&" {
+		return truthy(n.left.eval(get)) && truthy(n.right.eval(get))
+	}
+	return truthy(n.left.eval(get)) || truthy(n.right.eval(get))
+}
+
+func (n *not) eval(get Getter) interface{} {
+	return !truthy(n.x.eval(get))
+}
+
+func (n *field) eval(get Getter) interface{} {
+	v, ok := get(n.path)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func (n *literal) eval(Getter) interface{} {
+	return n.value
+}
+
+func (n *list) eval(get Getter) interface{} {
+	values := make([]interface{}, len(n.elems))
+	for i, elem := range n.elems {
+		values[i] = elem.eval(get)
+	}
+	return values
+}
+
+func (n *compare) eval(get Getter) interface{} {
+	left, right := n.left.eval(get), n.right.eval(get)
+
+	switch n.op {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	case "~":
+		return left != nil && n.re.MatchString(toString(left))
+	case "!~":
+		return left == nil || !n.re.MatchString(toString(left))
+	case "in":
+		return contains(right, left)
+	case "not in":
+		return !contains(right, left)
+	}
+
+	c, ok := order(left, right)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// truthy reports if a value is set and not zero
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != "" && v != "false" && v != "0"
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	if n, ok := toNumber(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+func contains(set, v interface{}) bool {
+	elems, ok := set.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, elem := range elems {
+		if equal(elem, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if ab, ok := a.(bool); ok {
+		return ab == truthy(b)
+	}
+	if bb, ok := b.(bool); ok {
+		return bb == truthy(a)
+	}
+
+	if c, ok := order(a, b); ok {
+		return c == 0
+	}
+	return toString(a) == toString(b)
+}
+
+// order compares two values as times when either is a date, as numbers when
+// both are numbers and as strings when both are strings
+func order(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	ta, aTime := toTime(a)
+	tb, bTime := toTime(b)
+	if aTime || bTime {
+		if !aTime {
+			ta, aTime = unixTime(a)
+		}
+		if !bTime {
+			tb, bTime = unixTime(b)
+		}
+		if !aTime || !bTime {
+			return 0, false
+		}
+		return ta.Compare(tb), true
+	}
+
+	na, aNum := toNumber(a)
+	nb, bNum := toNumber(b)
+	switch {
+	case aNum && bNum:
+		switch {
+		case na < nb:
+			return -1, true
+		case na > nb:
+			return 1, true
+		}
+		return 0, true
+	case aNum || bNum:
+		// a number never orders against text
+		return 0, false
+	}
+
+	sa, aStr := a.(string)
+	sb, bStr := b.(string)
+	if aStr && bStr {
+		return strings.Compare(sa, sb), true
+	}
+	return 0, false
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// toTime parses dates, numbers are not dates on their own
+func toTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		return ads.ParseDate(v)
+	}
+	return time.Time{}, false
+}
+
+// unixTime reads a number compared with a date as unix seconds
+func unixTime(v interface{}) (time.Time, bool) {
+	n, ok := toNumber(v)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(n), 0), true
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,176 @@
+// Package filter compiles filter expressions selecting adcreatives and assets:
+//
+//	configured_status == "AD_STATUS_NORMAL" && created_time > "2024-01-01"
+//	adgroup.campaign_type in ["CAMPAIGN_TYPE_NORMAL", "CAMPAIGN_TYPE_WECHAT_MOMENTS"]
+//	type == "image" && width >= 1080 && !(sub_type ~ "^IMAGE_")
+//
+// Fields are dotted paths into the adcreative, or asset columns. Comparisons
+// are numeric when both sides are numbers, by time when a side is a date, and
+// by string otherwise. ~ and !~ match a regular expression, a bare field is
+// true when set to a non-zero value. && || ! can be written and or not, and
+// null stands for unset fields.
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+)
+
+// Error is a syntax error at a byte offset of the filter
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("filter: %s at column %d", e.Msg, e.Pos+1)
+}
+
+// Getter returns the value of a field, false when unset
+type Getter func(field string) (interface{}, bool)
+
+// Expr is a parsed filter
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses a filter expression
+func Parse(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Fields returns the fields the filter reads
+func (e *Expr) Fields() []string {
+	var fields []string
+	walk(e.root, func(n node) {
+		if f, ok := n.(*field); ok {
+			fields = append(fields, f.path)
+		}
+	})
+	return fields
+}
+
+// Conditions returns the comparisons of fields with literals the whole filter
+// requires, the operands of its top level &&, for providers to push down
+func (e *Expr) Conditions() []ads.Condition {
+	var (
+		conds   []ads.Condition
+		collect func(n node)
+	)
+	collect = func(n node) {
+		switch n := n.(type) {
+		case *binary:
+			if n.op == "&&" {
+				collect(n.left)
+				collect(n.right)
+			}
+		case *compare:
+			if cond, ok := n.condition(); ok {
+				conds = append(conds, cond)
+			}
+		}
+	}
+	collect(e.root)
+	return conds
+}
+
+// Match evaluates the filter with the field values of get
+func (e *Expr) Match(get Getter) bool {
+	return truthy(e.root.eval(get))
+}
+
+// Adcreatives compiles a filter over the fields of adcreatives
+func Adcreatives(src string) (ads.AdcreativeMatchFunc, error) {
+	e, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return e.Adcreatives(), nil
+}
+
+// Adcreatives returns the filter as a match func of adcreatives
+func (e *Expr) Adcreatives() ads.AdcreativeMatchFunc {
+	return func(adcreative ads.Map) bool {
+		return e.Match(func(field string) (interface{}, bool) {
+			if !adcreative.Has(field) {
+				return nil, false
+			}
+			return adcreative.Get(field).Data(), true
+		})
+	}
+}
+
+// AssetMatchFunc selects assets
+type AssetMatchFunc func(asset *ads.Asset) bool
+
+// pageTypes are the values of the type field of assets
+var pageTypes = map[ads.PageType]string{
+	ads.PTUnknown: "unknown",
+	ads.PTPageUrl: "page",
+	ads.PTVideo:   "video",
+	ads.PTImage:   "image",
+	ads.PTText:    "text",
+}
+
+// Assets compiles a filter over assets. Fields are the export columns, case
+// and underscores ignored so sub_type is SubType, and type, the page type as
+// page, video, image or text. Unknown fields are an error.
+func Assets(src string) (AssetMatchFunc, error) {
+	e, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]*ads.Column)
+	for _, name := range e.Fields() {
+		if name == "type" {
+			continue
+		}
+		c, ok := ads.LookupColumn(strings.ReplaceAll(name, "_", ""))
+		if !ok {
+			return nil, fmt.Errorf("filter: unknown asset field %q", name)
+		}
+		columns[name] = c
+	}
+
+	return func(asset *ads.Asset) bool {
+		return e.Match(func(field string) (interface{}, bool) {
+			if field == "type" {
+				return pageTypes[asset.PageType], true
+			}
+			value := columns[field].Get(asset, nil)
+			return value, value != ""
+		})
+	}, nil
+}
+
+// Filter returns the assets matching match
+func (match AssetMatchFunc) Filter(assets []*ads.Asset) []*ads.Asset {
+	var kept []*ads.Asset
+	for _, asset := range assets {
+		if match(asset) {
+			kept = append(kept, asset)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,259 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/stretchr/objx"
+)
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		src    string
+		fields []string
+	}{
+		{`a == 1`, []string{"a"}},
+		{`a = 'x' and not b or c`, []string{"a", "b", "c"}},
+		{`!(a.b[0] ~ "^x") && c != null`, []string{"a.b[0]", "c"}},
+		{`a not in ["x", 'y', 1, true, null]`, []string{"a"}},
+		{`-1.5 < a && a <= 2`, []string{"a", "a"}},
+		{`a in b`, []string{"a", "b"}},
+		{`"it's" == 'it\'s'`, nil},
+	} {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.src, err)
+			continue
+		}
+		if got := e.Fields(); !reflect.DeepEqual(got, tt.fields) {
+			t.Errorf("Parse(%q).Fields() = %q, want %q", tt.src, got, tt.fields)
+		}
+		if e.String() != tt.src {
+			t.Errorf("Parse(%q).String() = %q", tt.src, e.String())
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{`a == "x`, 5, "unterminated string"},
+		{`a == 1.2.3`, 5, "invalid number"},
+		{`a # 1`, 2, "unexpected character"},
+		{`a == `, 5, "unexpected end of filter"},
+		{`(a == 1`, 7, "expected )"},
+		{`a == 1 b`, 7, `unexpected "b"`},
+		{`a ~ 1`, 4, "needs a regular expression"},
+		{`a ~ "("`, 4, "invalid regular expression"},
+		{`a in "x"`, 5, "needs a list or a field"},
+		{`a in [b]`, 5, "lists hold"},
+		{`a in [1, 2`, 10, "expected ]"},
+		{`a == and`, 5, `unexpected "and"`},
+	} {
+		_, err := Parse(tt.src)
+		var ferr *Error
+		if !errors.As(err, &ferr) {
+			t.Errorf("Parse(%q) error = %v", tt.src, err)
+			continue
+		}
+		if ferr.Pos != tt.pos || !strings.Contains(ferr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) error at %d %q, want at %d %q", tt.src, ferr.Pos, ferr.Msg, tt.pos, tt.msg)
+		}
+		if !strings.Contains(err.Error(), "column") {
+			t.Errorf("Parse(%q) error %q has no column", tt.src, err)
+		}
+	}
+}
+
+// values returns a Getter of the values
+func values(m map[string]interface{}) Getter {
+	return func(field string) (interface{}, bool) {
+		v, ok := m[field]
+		return v, ok
+	}
+}
+
+func TestMatch(t *testing.T) {
+	get := values(map[string]interface{}{
+		"n":       float64(10),
+		"i":       int64(3),
+		"s":       "abc",
+		"ns":      "42",
+		"created": float64(time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local).Unix()),
+		"date":    "2024-03-01",
+		"on":      true,
+		"zero":    float64(0),
+		"empty":   "",
+		"tags":    []interface{}{"x", "y"},
+		"none":    nil,
+	})
+
+	for _, tt := range []struct {
+		src  string
+		want bool
+	}{
+		// numbers
+		{`n == 10`, true},
+		{`n > 9.5 && n < 11`, true},
+		{`i >= 3 and i <= 3`, true},
+		{`ns == 42`, true},
+		{`ns > 100`, false},
+		{`10 < n`, false},
+		// dates against unix times and dates
+		{`created > "2024-02-29"`, true},
+		{`created < "2024-02-29"`, false},
+		{`created == "2024-03-01"`, true},
+		{`date >= "2024-03-01" && date < "2024-03-02T00:00:00Z"`, true},
+		{`date > 1700000000`, true},
+		// strings, a number never orders against text
+		{`s == "abc"`, true},
+		{`s < "abd"`, true},
+		{`s > 1`, false},
+		{`s != 1`, true},
+		// booleans and bare fields
+		{`on`, true},
+		{`on == true`, true},
+		{`zero`, false},
+		{`empty`, false},
+		{`!missing`, true},
+		{`tags`, true},
+		// in and not in
+		{`s in ["x", "abc"]`, true},
+		{`n in [1, 10]`, true},
+		{`n not in [1, 10]`, false},
+		{`"y" in tags`, true},
+		{`"z" not in tags`, true},
+		{`s in tags`, false},
+		{`missing in [null]`, true},
+		// regular expressions
+		{`s ~ "^a.c$"`, true},
+		{`s !~ "^b"`, true},
+		{`missing ~ ".*"`, false},
+		{`missing !~ "x"`, true},
+		// null
+		{`missing == null`, true},
+		{`none == null`, true},
+		{`s == null`, false},
+		{`s != nil`, true},
+		{`missing > 1`, false},
+		// precedence
+		{`on || missing && s == "x"`, true},
+		{`(on || missing) && s == "x"`, false},
+		{`not (n == 10 or s == "x")`, false},
+	} {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.src, err)
+			continue
+		}
+		if got := e.Match(get); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestConditions(t *testing.T) {
+	for _, tt := range []struct {
+		src  string
+		want []ads.Condition
+	}{
+		{`a == "x" && 5 < b && c in ["p", 1]`, []ads.Condition{
+			{Field: "a", Operator: "==", Values: []string{"x"}},
+			{Field: "b", Operator: ">", Values: []string{"5"}},
+			{Field: "c", Operator: "in", Values: []string{"p", "1"}},
+		}},
+		{`a >= "2024-01-01" and b != 1.5`, []ads.Condition{
+			{Field: "a", Operator: ">=", Values: []string{"2024-01-01"}},
+			{Field: "b", Operator: "!=", Values: []string{"1.5"}},
+		}},
+		// only the operands of the top level && are required
+		{`a == 1 || b == 2`, nil},
+		{`!(a == 1) && (b == 2 || c == 3)`, nil},
+		{`a == 1 && (b == 2 && c == 3)`, []ads.Condition{
+			{Field: "a", Operator: "==", Values: []string{"1"}},
+			{Field: "b", Operator: "==", Values: []string{"2"}},
+			{Field: "c", Operator: "==", Values: []string{"3"}},
+		}},
+		// operators, operands and values that cannot be pushed down
+		{`a ~ "x" && a not in [1] && a == b && a == null && a in [null] && 1 == 1 && a`, nil},
+	} {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.src, err)
+		}
+		if got := e.Conditions(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Conditions(%q) = %+v, want %+v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestAdcreatives(t *testing.T) {
+	match, err := Adcreatives(`configured_status == "AD_STATUS_NORMAL" && adgroup.campaign_type in ["A", "B"] && !is_deleted`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		adcreative map[string]interface{}
+		want       bool
+	}{
+		{map[string]interface{}{"configured_status": "AD_STATUS_NORMAL", "adgroup": map[string]interface{}{"campaign_type": "B"}}, true},
+		{map[string]interface{}{"configured_status": "AD_STATUS_NORMAL", "adgroup": map[string]interface{}{"campaign_type": "C"}}, false},
+		{map[string]interface{}{"configured_status": "AD_STATUS_NORMAL", "adgroup": map[string]interface{}{"campaign_type": "A"}, "is_deleted": true}, false},
+		{map[string]interface{}{"configured_status": "AD_STATUS_SUSPEND"}, false},
+	} {
+		if got := match(objx.New(tt.adcreative)); got != tt.want {
+			t.Errorf("match(%v) = %v, want %v", tt.adcreative, got, tt.want)
+		}
+	}
+}
+
+func TestAssets(t *testing.T) {
+	assets := []*ads.Asset{
+		{AssetID: "1", PageType: ads.PTImage, SubType: "IMAGE_LARGE", Width: 1080, CreatedTime: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{AssetID: "2", PageType: ads.PTImage, SubType: "IMAGE_SMALL", Width: 640},
+		{AssetID: "3", PageType: ads.PTVideo, Width: 1920, SubAssets: []*ads.SubAsset{{Type: ads.SATVideo, Url: "https://example.com/v.mp4"}}},
+		{AssetID: "4", PageType: ads.PTText, Texts: []string{"hello"}},
+	}
+
+	for _, tt := range []struct {
+		src  string
+		want string
+	}{
+		{`type == "image" && width >= 1080`, "1"},
+		{`type in ["image", "video"] && !(sub_type ~ "_SMALL$")`, "1,3"},
+		{`SubType == null`, "3,4"},
+		{`width`, "1,2,3"},
+		{`created_time > "2024-02-01"`, "1"},
+		{`primary_url ~ "\\.mp4$"`, "3"},
+		{`asset_id in ["2", "4"]`, "2,4"},
+		{`assetid == 4`, "4"},
+	} {
+		match, err := Assets(tt.src)
+		if err != nil {
+			t.Errorf("Assets(%q): %v", tt.src, err)
+			continue
+		}
+		var ids []string
+		for _, asset := range match.Filter(assets) {
+			ids = append(ids, asset.AssetID)
+		}
+		if got := strings.Join(ids, ","); got != tt.want {
+			t.Errorf("%s kept %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	if _, err := Assets(`colour == "red"`); err == nil || !strings.Contains(err.Error(), "unknown asset field") {
+		t.Errorf("unknown field error = %v", err)
+	}
+	if _, err := Assets(`width >`); err == nil {
+		t.Error("no syntax error")
+	}
+}
@@ -0,0 +1,282 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hnhuaxi/ads"
+)
+
+// node is a node of the expression tree
+type node interface {
+	eval(get Getter) interface{}
+}
+
+type (
+	binary struct {
+		op          string
+		left, right node
+	}
+	not struct {
+		x node
+	}
+	compare struct {
+		op          string
+		left, right node
+		re          *regexp.Regexp
+	}
+	field struct {
+		path string
+	}
+	literal struct {
+		value interface{}
+	}
+	list struct {
+		elems []node
+	}
+)
+
+// parser is a recursive descent parser:
+//
+//	or      = and { ("||" | "or") and }
+//	and     = unary { ("&&" | "and") unary }
+//	unary   = ("!" | "not") unary | cmp
+//	cmp     = operand [ op operand | ["not"] "in" operand ]
+//	operand = field | string | number | true | false | null | list | "(" or ")"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is reports if the next token is the operator or keyword op
+func (p *parser) is(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("||", "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("&&", "and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.is("!", "not") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &not{x: x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	op := ""
+	switch {
+	case p.is("==", "!=", "<", "<=", ">", ">=", "~", "!~", "in"):
+		op = p.next().text
+	case p.is("not") && p.tokens[p.pos+1].text == "in":
+		p.next()
+		p.next()
+		op = "not in"
+	default:
+		return left, nil
+	}
+
+	t := p.peek()
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	c := &compare{op: op, left: left, right: right}
+
+	switch op {
+	case "~", "!~":
+		var s string
+		if lit, ok := right.(*literal); ok {
+			s, _ = lit.value.(string)
+		}
+		if s == "" {
+			return nil, &Error{Pos: t.pos, Msg: op + " needs a regular expression string"}
+		}
+		if c.re, err = regexp.Compile(s); err != nil {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("invalid regular expression: %v", err)}
+		}
+	case "in", "not in":
+		if _, ok := right.(*literal); ok {
+			return nil, &Error{Pos: t.pos, Msg: op + " needs a list or a field"}
+		}
+	}
+	return c, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return &literal{value: t.text}, nil
+	case tokNumber:
+		n, _ := strconv.ParseFloat(t.text, 64)
+		return &literal{value: n}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null", "nil":
+			return &literal{value: nil}, nil
+		case "and", "or", "not", "in":
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+		}
+		return &field{path: t.text}, nil
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if end := p.next(); end.kind != tokRParen {
+			return nil, &Error{Pos: end.pos, Msg: fmt.Sprintf("expected ) instead of %s", end)}
+		}
+		return x, nil
+	case tokLBrack:
+		l := &list{}
+		for p.peek().kind != tokRBrack {
+			elem, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := elem.(*literal); !ok {
+				return nil, &Error{Pos: t.pos, Msg: "lists hold strings, numbers, booleans or null"}
+			}
+			l.elems = append(l.elems, elem)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+		if end := p.next(); end.kind != tokRBrack {
+			return nil, &Error{Pos: end.pos, Msg: fmt.Sprintf("expected ] instead of %s", end)}
+		}
+		return l, nil
+	default:
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+	}
+}
+
+// condition returns a field compared with literals, operands are swapped so
+// the field comes first
+func (c *compare) condition() (ads.Condition, bool) {
+	op := c.op
+	f, isField := c.left.(*field)
+	right := c.right
+	if !isField {
+		swapped := map[string]string{"==": "==", "!=": "!=", "<": ">", "<=": ">=", ">": "<", ">=": "<="}
+		f, isField = c.right.(*field)
+		op, right = swapped[op], c.left
+	}
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "in":
+	default:
+		return ads.Condition{}, false
+	}
+	if !isField {
+		return ads.Condition{}, false
+	}
+
+	cond := ads.Condition{Field: f.path, Operator: op}
+	switch right := right.(type) {
+	case *literal:
+		if right.value == nil || op == "in" {
+			return ads.Condition{}, false
+		}
+		cond.Values = []string{toString(right.value)}
+	case *list:
+		if op != "in" {
+			return ads.Condition{}, false
+		}
+		for _, elem := range right.elems {
+			value := elem.(*literal).value
+			if value == nil {
+				return ads.Condition{}, false
+			}
+			cond.Values = append(cond.Values, toString(value))
+		}
+	default:
+		return ads.Condition{}, false
+	}
+	return cond, true
+}
+
+// walk calls fn for n and all nodes below it
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch n := n.(type) {
+	case *binary:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	case *not:
+		walk(n.x, fn)
+	case *compare:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	case *list:
+		for _, elem := range n.elems {
+			walk(elem, fn)
+		}
+	}
+}
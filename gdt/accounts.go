@@ -0,0 +1,105 @@
+package gdt
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+)
+
+// Accounts lists the advertiser accounts managed by the agency account, or
+// by the business manager account with the business account_type, with the
+// balance of their funds
+func (g *GdtAdcreatives) Accounts() ([]*ads.Account, error) {
+	var (
+		objs []ads.Map
+		err  error
+	)
+	switch g.Config.AccountType {
+	case "business":
+		objs, err = g.v3.AllOrganizationAccounts()
+	default:
+		objs, err = g.v3.AllAdvertisers(g.AccountID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		accounts = make([]*ads.Account, 0, len(objs))
+		ids      = make([]int64, 0, len(objs))
+	)
+	for _, obj := range objs {
+		id := int64(obj.Get("account_id").Float64())
+		accounts = append(accounts, &ads.Account{
+			ID:     strconv.FormatInt(id, 10),
+			Name:   obj.Get("corporation_name").Str(),
+			Status: obj.Get("system_status").Str(),
+		})
+		ids = append(ids, id)
+	}
+
+	balances := g.balances(ids)
+	for i, acc := range accounts {
+		acc.Balance = balances[i]
+	}
+	return accounts, nil
+}
+
+// fundsBatchSize is the number of advertisers whose funds are listed at
+// once, the API lists the funds of a single advertiser a call
+const fundsBatchSize = 10
+
+// balances returns the balance of the funds of every advertiser, listed in
+// concurrent batches
+func (g *GdtAdcreatives) balances(ids []int64) []int64 {
+	balances := make([]int64, len(ids))
+	for start, batch := range httpapi.Batches(ids, fundsBatchSize) {
+		var wg sync.WaitGroup
+		for i, id := range batch {
+			wg.Add(1)
+			go func(i int, id int64) {
+				defer wg.Done()
+
+				funds, err := g.v3.Funds(id)
+				if err != nil {
+					g.log.Warnw("list funds error", "account", id, "error", err)
+				}
+				for _, fund := range funds {
+					balances[i] += int64(fund.Get("balance").Float64())
+				}
+			}(start*fundsBatchSize+i, id)
+		}
+		wg.Wait()
+	}
+	return balances
+}
+
+// AccountName returns the corporation name of the advertiser, empty when it
+// cannot be listed. The name is looked up once, unless set by SetAccountName.
+func (g *GdtAdcreatives) AccountName() string {
+	if g.accountName != nil {
+		return *g.accountName
+	}
+
+	var name string
+	objs, _, _, err := g.v3.Advertisers(0, 0)
+	if err != nil {
+		g.log.Warnw("get advertiser error", "account", g.AccountID, "error", err)
+	}
+	for _, obj := range objs {
+		if int64(obj.Get("account_id").Float64()) == g.AccountID {
+			name = obj.Get("corporation_name").Str()
+		}
+	}
+
+	g.accountName = &name
+	return name
+}
+
+// SetAccountName names the assets of the account without looking up the
+// advertiser
+func (g *GdtAdcreatives) SetAccountName(name string) {
+	g.accountName = &name
+}
@@ -0,0 +1,32 @@
+// Package dump logs the requests and responses of the GDT SDK clients at
+// debug level. The SDK debug mode writes them to stdout with the access token
+// in the url, this goes through the zap logger and its redaction instead.
+package dump
+
+import (
+	"net/http"
+	"net/http/httputil"
+
+	"go.uber.org/zap"
+)
+
+// Middleware dumps every request and response, append it to the middlewares
+// of a v1.1 or v3 SDK client
+type Middleware struct{}
+
+func (Middleware) Handle(req *http.Request, next func(req *http.Request) (rsp *http.Response, err error)) (rsp *http.Response, err error) {
+	log := zap.S()
+	if b, err := httputil.DumpRequestOut(req, true); err == nil {
+		log.Debugw("gdt request", "host", req.URL.Host, "request", string(b))
+	}
+
+	rsp, err = next(req)
+	if err != nil {
+		log.Debugw("gdt request failed", "host", req.URL.Host, "error", err)
+		return rsp, err
+	}
+	if b, err := httputil.DumpResponse(rsp, true); err == nil {
+		log.Debugw("gdt response", "host", req.URL.Host, "response", string(b))
+	}
+	return rsp, err
+}
@@ -0,0 +1,263 @@
+// Package filtering models the filtering parameter of the GDT list calls.
+// A Filter is split per call into the predicates the call evaluates, sent as
+// its filtering parameter or is_deleted flag, and the rest, evaluated on the
+// listed adcreatives.
+package filtering
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antihax/optional"
+	"github.com/hnhuaxi/ads"
+)
+
+// Operator is a filtering operator
+type Operator string
+
+const (
+	Equals        Operator = "EQUALS"
+	NotEquals     Operator = "NOT_EQUALS"
+	In            Operator = "IN"
+	Contains      Operator = "CONTAINS"
+	Greater       Operator = "GREATER"
+	GreaterEquals Operator = "GREATER_EQUALS"
+	Less          Operator = "LESS"
+	LessEquals    Operator = "LESS_EQUALS"
+)
+
+// Predicate is a condition on a field, times are unix seconds
+type Predicate struct {
+	Field    string
+	Operator Operator
+	Values   []string
+}
+
+// Filter is a set of predicates objects must all match
+type Filter []Predicate
+
+// Status keeps adcreatives with a configured_status of the values
+func Status(values ...string) Predicate {
+	return Predicate{Field: "configured_status", Operator: In, Values: values}
+}
+
+// CampaignIDs keeps the adcreatives of campaigns, v2 only
+func CampaignIDs(ids ...string) Predicate {
+	return Predicate{Field: "campaign_id", Operator: In, Values: ids}
+}
+
+// AdgroupIDs keeps the dynamic creatives of adgroups, v3 only
+func AdgroupIDs(ids ...string) Predicate {
+	return Predicate{Field: "adgroup_id", Operator: In, Values: ids}
+}
+
+// PageTypes keeps adcreatives with a page_type of the values
+func PageTypes(types ...string) Predicate {
+	return Predicate{Field: "page_type", Operator: In, Values: types}
+}
+
+// Deleted keeps deleted or not deleted adcreatives
+func Deleted(deleted bool) Predicate {
+	return Predicate{Field: "is_deleted", Operator: Equals, Values: []string{strconv.FormatBool(deleted)}}
+}
+
+// CreatedBetween keeps objects created in [from, to), a zero bound is open
+func CreatedBetween(from, to time.Time) Filter {
+	return between("created_time", from, to)
+}
+
+// ModifiedBetween keeps objects modified in [from, to), a zero bound is open
+func ModifiedBetween(from, to time.Time) Filter {
+	return between("last_modified_time", from, to)
+}
+
+func between(field string, from, to time.Time) Filter {
+	var f Filter
+	if !from.IsZero() {
+		f = append(f, Predicate{Field: field, Operator: GreaterEquals, Values: []string{unix(from)}})
+	}
+	if !to.IsZero() {
+		f = append(f, Predicate{Field: field, Operator: Less, Values: []string{unix(to)}})
+	}
+	return f
+}
+
+func unix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// Call describes the filtering of a list call
+type Call struct {
+	Name string
+	// Fields are the fields and operators of the filtering parameter
+	Fields map[string][]Operator
+	// IsDeleted is set for calls taking the is_deleted flag
+	IsDeleted bool
+	// Adcreatives is set for adcreative listings, predicates the call does
+	// not support are evaluated on the listed adcreatives. The predicates
+	// are on adcreatives, other listings take none of them.
+	Adcreatives bool
+}
+
+var (
+	timeOperators = []Operator{Equals, Greater, GreaterEquals, Less, LessEquals}
+	idOperators   = []Operator{Equals, In}
+
+	V2Adcreatives = &Call{
+		Name: "adcreatives/get",
+		Fields: map[string][]Operator{
+			"adcreative_id":      idOperators,
+			"campaign_id":        idOperators,
+			"page_type":          idOperators,
+			"created_time":       timeOperators,
+			"last_modified_time": timeOperators,
+		},
+		IsDeleted:   true,
+		Adcreatives: true,
+	}
+	V3DynamicCreatives = &Call{
+		Name: "dynamic_creatives/get",
+		Fields: map[string][]Operator{
+			"dynamic_creative_id": idOperators,
+			"adgroup_id":          idOperators,
+			"configured_status":   idOperators,
+			"created_time":        timeOperators,
+			"last_modified_time":  timeOperators,
+		},
+		IsDeleted:   true,
+		Adcreatives: true,
+	}
+	Images = &Call{
+		Name: "images/get",
+		Fields: map[string][]Operator{
+			"image_id":           idOperators,
+			"created_time":       timeOperators,
+			"last_modified_time": timeOperators,
+		},
+	}
+	Videos = &Call{
+		Name: "videos/get",
+		Fields: map[string][]Operator{
+			"video_id":           idOperators,
+			"media_id":           idOperators,
+			"created_time":       timeOperators,
+			"last_modified_time": timeOperators,
+		},
+	}
+)
+
+// supports reports if the call evaluates the predicate
+func (c *Call) supports(p Predicate) bool {
+	if p.Field == "is_deleted" {
+		return c.IsDeleted && p.Operator == Equals && len(p.Values) == 1
+	}
+	ops, ok := c.Fields[p.Field]
+	if !ok || !slices.Contains(ops, p.Operator) {
+		return false
+	}
+	return p.Operator == In || len(p.Values) == 1
+}
+
+// Split returns the predicates pushed down to the call and the rest, to
+// evaluate on adcreative listings. Both are empty for the library listings.
+func (f Filter) Split(call *Call) (pushed, rest Filter) {
+	if !call.Adcreatives {
+		return nil, nil
+	}
+	for _, p := range f {
+		switch {
+		case call.supports(p):
+			pushed = append(pushed, p)
+		default:
+			rest = append(rest, p)
+		}
+	}
+	return pushed, rest
+}
+
+// Option returns the filtering parameter of the predicates, followed by the
+// extra ones, without is_deleted
+func (f Filter) Option(extra ...Predicate) optional.Interface {
+	var conds []interface{}
+	for _, p := range append(slices.Clip(f), extra...) {
+		if p.Field == "is_deleted" {
+			continue
+		}
+		conds = append(conds, map[string]interface{}{
+			"field":    p.Field,
+			"operator": string(p.Operator),
+			"values":   p.Values,
+		})
+	}
+	if len(conds) == 0 {
+		return optional.EmptyInterface()
+	}
+	return optional.NewInterface(conds)
+}
+
+// IsDeleted returns the is_deleted flag of the predicates
+func (f Filter) IsDeleted() optional.Bool {
+	for _, p := range f {
+		if p.Field == "is_deleted" {
+			return optional.NewBool(p.Values[0] == "true")
+		}
+	}
+	return optional.EmptyBool()
+}
+
+// Match reports if an object matches all predicates, predicates on fields
+// the object lacks are ignored
+func (f Filter) Match(obj ads.Map) bool {
+	for _, p := range f {
+		if !p.Match(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports if an object matches the predicate, or lacks its field
+func (p Predicate) Match(obj ads.Map) bool {
+	if !obj.Has(p.Field) {
+		return true
+	}
+	v := obj.Get(p.Field)
+
+	var value string
+	switch {
+	case v.IsBool():
+		value = strconv.FormatBool(v.Bool())
+	case v.IsFloat64():
+		value = strconv.FormatFloat(v.Float64(), 'f', -1, 64)
+	default:
+		value = v.String()
+	}
+
+	switch p.Operator {
+	case Equals, In:
+		return slices.Contains(p.Values, value)
+	case NotEquals:
+		return !slices.Contains(p.Values, value)
+	case Contains:
+		return len(p.Values) == 1 && strings.Contains(value, p.Values[0])
+	}
+
+	a, errA := strconv.ParseFloat(value, 64)
+	b, errB := strconv.ParseFloat(p.Values[0], 64)
+	if errA != nil || errB != nil {
+		return false
+	}
+	switch p.Operator {
+	case Greater:
+		return a > b
+	case GreaterEquals:
+		return a >= b
+	case Less:
+		return a < b
+	case LessEquals:
+		return a <= b
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package filtering
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	f := append(CreatedBetween(time.Unix(100, 0), time.Unix(200, 0)), Status("AD_STATUS_NORMAL"), Deleted(false))
+
+	pushed, rest := f.Split(V2Adcreatives)
+	if len(pushed) != 3 || len(rest) != 1 || rest[0].Field != "configured_status" {
+		t.Errorf("v2 adcreatives pushed %v, rest %v", pushed, rest)
+	}
+	if !pushed.IsDeleted().IsSet() {
+		t.Error("is_deleted not pushed to adcreatives")
+	}
+
+	pushed, rest = f.Split(V3DynamicCreatives)
+	if len(pushed) != 4 || len(rest) != 0 {
+		t.Errorf("v3 dynamic creatives pushed %v, rest %v", pushed, rest)
+	}
+
+	for _, call := range []*Call{Images, Videos} {
+		if pushed, rest := f.Split(call); len(pushed) != 0 || len(rest) != 0 {
+			t.Errorf("%s pushed %v, rest %v", call.Name, pushed, rest)
+		}
+	}
+}
@@ -1,18 +1,30 @@
 package gdt
 
 import (
+	"errors"
+	"fmt"
 	"slices"
 	"strconv"
+	"time"
 
 	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/gdt/filtering"
+	"github.com/hnhuaxi/ads/gdt/oauth"
 	v2 "github.com/hnhuaxi/ads/gdt/v2"
 	v3 "github.com/hnhuaxi/ads/gdt/v3"
+	"github.com/hysios/x/utils"
 	"github.com/stretchr/objx"
 	"go.uber.org/zap"
 )
 
 type Config struct {
 	OnlyAdcreatives bool
+	// Version pins the marketing API version, v2 or v3, empty tries v2 and
+	// falls back to v3 for accounts without v2 adcreatives
+	Version string
+	// AccountType is the type of the account listing managed accounts, agency
+	// or business for a business manager
+	AccountType string
 }
 
 type GdtAdcreatives struct {
@@ -21,6 +33,9 @@ type GdtAdcreatives struct {
 	v3              *v3.GdtV3API
 	Config          Config
 	adcreateivesFns []ads.AdcreativeMatchFunc
+	filter          filtering.Filter
+	watermark       int64
+	accountName     *string
 	log             *zap.SugaredLogger
 }
 
@@ -36,31 +51,82 @@ func NewAdcreatives(accountId string, accessToken string, debug bool) (*GdtAdcre
 		log:       zap.S(),
 	}
 
-	advs.SetAdcreativesFunc(func(adcreative ads.Map) (process bool) {
-		return !adcreative.Get("is_deleted").Bool()
-	})
+	advs.SetAdcreativesFunc(ads.NotDeleted)
 
 	return advs, nil
 }
 
-// Assets ...
+// AssetsSince lists the assets of adcreatives, images and videos modified
+// since the watermark, a zero since lists everything
+func (g *GdtAdcreatives) AssetsSince(since time.Time) (assets []*ads.Asset, watermark time.Time, err error) {
+	var unix int64
+	if !since.IsZero() {
+		unix = since.Unix()
+	}
+
+	g.v2.ModifiedSince, g.v3.ModifiedSince = unix, unix
+	defer func() {
+		g.v2.ModifiedSince, g.v3.ModifiedSince = 0, 0
+	}()
+
+	g.watermark = unix
+	assets, err = g.Assets()
+	if err != nil {
+		return nil, since, err
+	}
+
+	if g.watermark == 0 {
+		return assets, since, nil
+	}
+	return assets, time.Unix(g.watermark, 0), nil
+}
+
+// Assets lists the assets of the account named after the advertiser
 func (g *GdtAdcreatives) Assets() (assets []*ads.Asset, err error) {
-	r, total, err := g.v2.Adcreatives(1, 100)
+	assets, err = g.assets()
 	if err != nil {
 		return nil, err
 	}
 
+	name := g.AccountName()
+	for _, asset := range assets {
+		asset.AccountName = name
+	}
+	return assets, nil
+}
+
+func (g *GdtAdcreatives) assets() (assets []*ads.Asset, err error) {
+	var r []ads.Map
+	if g.Config.Version != "v3" {
+		// the watermark moves once every page is read
+		r, err = g.v2.AllAdcreatives()
+		if err != nil {
+			return nil, err
+		}
+		g.observe(r)
+	}
+
 	type adType struct {
 		AdcreativeID   int64
 		AdcreativeName string
 	}
 
-	if total == 0 {
+	if len(r) == 0 {
+		if g.Config.Version == "v2" {
+			return nil, nil
+		}
+		var isV2 bool
+		if isV2, err = g.isV2(); err != nil {
+			return nil, err
+		}
+		if isV2 {
+			return nil, nil
+		}
 		goto V3
 	}
 	{
 		log := g.log.With("version", "v2")
-		adcreatives := g.processAdcreatives(r)
+		adcreatives := g.processAdcreatives(r, filtering.V2Adcreatives)
 		g.printJson(log, "adcreatives", adcreatives)
 
 		var (
@@ -113,7 +179,9 @@ func (g *GdtAdcreatives) Assets() (assets []*ads.Asset, err error) {
 							Url:  pageSpec.Get("page_url").String(),
 						},
 					},
-					Version: "v2",
+					Version:          "v2",
+					CreatedTime:      unixTime(adcr, "created_time"),
+					LastModifiedTime: unixTime(adcr, "last_modified_time"),
 					// Url:       pageSpec.Get("page_url").String(),
 				})
 			} else if pageSpec.Get("page_id").Int() > 0 {
@@ -261,16 +329,9 @@ func (g *GdtAdcreatives) Assets() (assets []*ads.Asset, err error) {
 
 		if needImages {
 			var images []ads.Map
-			if g.Config.OnlyAdcreatives {
-				images, err = g.v2.AllImages(imageIds.Slice()...)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				images, err = g.v2.AllImages()
-				if err != nil {
-					return nil, err
-				}
+			images, err = g.materials(g.v2.AllImages, "image_id", imageIds.Slice())
+			if err != nil {
+				return nil, err
 			}
 
 			g.printJson(log, "images", images)
@@ -296,23 +357,20 @@ func (g *GdtAdcreatives) Assets() (assets []*ads.Asset, err error) {
 							Url:  image.Get("preview_url").String(),
 						},
 					},
-					Version: "v2",
+					Width:            intValue(image, "width"),
+					Height:           intValue(image, "height"),
+					Version:          "v2",
+					CreatedTime:      unixTime(image, "created_time"),
+					LastModifiedTime: unixTime(image, "last_modified_time"),
 				})
 			}
 		}
 
 		if needVideos {
 			var videos []ads.Map
-			if g.Config.OnlyAdcreatives {
-				videos, err = g.v2.AllVideos(videoIds.Slice()...)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				videos, err = g.v2.AllVideos()
-				if err != nil {
-					return nil, err
-				}
+			videos, err = g.materials(g.v2.AllVideos, "video_id", videoIds.Slice())
+			if err != nil {
+				return nil, err
 			}
 			g.printJson(log, "videos", videos)
 
@@ -332,8 +390,12 @@ func (g *GdtAdcreatives) Assets() (assets []*ads.Asset, err error) {
 					PageType:       ads.PTVideo,
 					SubType:        videoType,
 					// Url:       video.Get("preview_url").String(),
-					Signature: video.Get("signature").String(),
-					Version:   "v2",
+					Signature:        video.Get("signature").String(),
+					Width:            intValue(video, "width"),
+					Height:           intValue(video, "height"),
+					Version:          "v2",
+					CreatedTime:      unixTime(video, "created_time"),
+					LastModifiedTime: unixTime(video, "last_modified_time"),
 				}
 
 				asset.SubAssets = append(asset.SubAssets, &ads.SubAsset{
@@ -357,8 +419,9 @@ V3:
 		if err != nil {
 			return nil, err
 		}
+		g.observe(r)
 
-		adcreatives := g.processAdcreatives(r)
+		adcreatives := g.processAdcreatives(r, filtering.V3DynamicCreatives)
 		_ = adcreatives
 
 		var (
@@ -427,7 +490,9 @@ V3:
 										Url:  pageSpec.ObjxMap().Get("h5_spec.page_url").String(),
 									},
 								},
-								Version: "v3",
+								Version:          "v3",
+								CreatedTime:      unixTime(adcr, "created_time"),
+								LastModifiedTime: unixTime(adcr, "last_modified_time"),
 								// Url:       pageSpec.ObjxMap().Get("h5_spec.page_url").String(),
 							})
 						}
@@ -597,16 +662,9 @@ V3:
 
 		if needVideos {
 			var videos []ads.Map
-			if g.Config.OnlyAdcreatives {
-				videos, err = g.v3.AllVideos(videosIds.Slice()...)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				videos, err = g.v3.AllVideos()
-				if err != nil {
-					return nil, err
-				}
+			videos, err = g.materials(g.v3.AllVideos, "video_id", videosIds.Slice())
+			if err != nil {
+				return nil, err
 			}
 
 			g.printJson(log, "videos", videos)
@@ -625,8 +683,12 @@ V3:
 					PageType:       ads.PTVideo,
 					SubType:        videoType,
 					// Url:       video.Get("preview_url").String(),
-					Signature: video.Get("signature").String(),
-					Version:   "v3",
+					Signature:        video.Get("signature").String(),
+					Width:            intValue(video, "media_width"),
+					Height:           intValue(video, "media_height"),
+					Version:          "v3",
+					CreatedTime:      unixTime(video, "created_time"),
+					LastModifiedTime: unixTime(video, "last_modified_time"),
 				}
 
 				asset.SubAssets = append(asset.SubAssets, &ads.SubAsset{
@@ -645,16 +707,9 @@ V3:
 
 		if needImages {
 			var images []ads.Map
-			if g.Config.OnlyAdcreatives {
-				images, err = g.v3.AllImages(imagesIds.Slice()...)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				images, err = g.v3.AllImages()
-				if err != nil {
-					return nil, err
-				}
+			images, err = g.materials(g.v3.AllImages, "image_id", imagesIds.Slice())
+			if err != nil {
+				return nil, err
 			}
 
 			g.printJson(log, "images", images)
@@ -679,7 +734,11 @@ V3:
 							Url:  image.Get("preview_url").String(),
 						},
 					},
-					Version: "v3",
+					Width:            intValue(image, "image_width"),
+					Height:           intValue(image, "image_height"),
+					Version:          "v3",
+					CreatedTime:      unixTime(image, "created_time"),
+					LastModifiedTime: unixTime(image, "last_modified_time"),
 				})
 			}
 		}
@@ -714,37 +773,256 @@ func (g *GdtAdcreatives) SetAdcreativesFunc(match ads.AdcreativeMatchFunc) {
 	g.adcreateivesFns = append(g.adcreateivesFns, match)
 }
 
+// ClearAdcreativesFuncs ...
+func (g *GdtAdcreatives) ClearAdcreativesFuncs() {
+	g.adcreateivesFns = nil
+}
+
+// SetFilter sets the filter of adcreatives, pushed down to the list calls
+// supporting its predicates and evaluated on the listed adcreatives otherwise
+func (g *GdtAdcreatives) SetFilter(f filtering.Filter) {
+	g.filter = f
+	g.v2.Filter, g.v3.Filter = f, f
+}
+
+// PushDown adds the conditions on fields the adcreative list calls may filter
+// to the filter, the images and videos listings are not filtered
+func (g *GdtAdcreatives) PushDown(conds []ads.Condition) {
+	f := slices.Clip(g.filter)
+	for _, cond := range conds {
+		if p, ok := predicate(cond); ok {
+			f = append(f, p)
+		}
+	}
+	g.SetFilter(f)
+}
+
+var operators = map[string]filtering.Operator{
+	"==": filtering.Equals,
+	"!=": filtering.NotEquals,
+	"<":  filtering.Less,
+	"<=": filtering.LessEquals,
+	">":  filtering.Greater,
+	">=": filtering.GreaterEquals,
+	"in": filtering.In,
+}
+
+// predicate converts a condition on an id, status, page type, time or the
+// is_deleted flag, times are read as unix seconds or dates
+func predicate(cond ads.Condition) (filtering.Predicate, bool) {
+	p := filtering.Predicate{Field: cond.Field, Operator: operators[cond.Operator], Values: cond.Values}
+	if p.Operator == "" || len(p.Values) == 0 {
+		return p, false
+	}
+
+	switch cond.Field {
+	case "configured_status", "page_type", "campaign_id", "adgroup_id", "adcreative_id", "dynamic_creative_id":
+		switch p.Operator {
+		case filtering.Equals, filtering.NotEquals, filtering.In:
+			return p, true
+		}
+	case "created_time", "last_modified_time":
+		if p.Operator == filtering.In || p.Operator == filtering.NotEquals {
+			return p, false
+		}
+		if _, err := strconv.ParseFloat(p.Values[0], 64); err == nil {
+			return p, true
+		}
+		if t, ok := ads.ParseDate(p.Values[0]); ok {
+			p.Values = []string{strconv.FormatInt(t.Unix(), 10)}
+			return p, true
+		}
+	case "is_deleted":
+		if p.Operator == filtering.Equals && (p.Values[0] == "true" || p.Values[0] == "false") {
+			return p, true
+		}
+	}
+	return p, false
+}
+
 // OnlyAdcreatives ...
 func (g *GdtAdcreatives) OnlyAdcreatives(on bool) {
 	g.Config.OnlyAdcreatives = on
 }
 
-// processAdcreatives ...
-func (g *GdtAdcreatives) processAdcreatives(adcreatives []ads.Map) (processes []ads.Map) {
-	for _, adcreative := range adcreatives {
-		for _, fn := range g.adcreateivesFns {
-			if fn(adcreative) {
-				processes = append(processes, adcreative)
+// Configure applies provider settings: version pins the API version,
+// account_type selects how managed accounts are listed, and client_id,
+// client_secret and token_file refresh the OAuth token stored for the account
+// or token_key
+func (g *GdtAdcreatives) Configure(settings map[string]string) error {
+	var conf oauth.Config
+	for key, value := range settings {
+		switch key {
+		case "version":
+			if value != "" && value != "v2" && value != "v3" {
+				return fmt.Errorf("gdt: unsupported API version %q", value)
+			}
+			g.Config.Version = value
+		case "client_id":
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("gdt: invalid client_id %q", value)
 			}
+			conf.ClientID = id
+		case "client_secret":
+			conf.ClientSecret = value
+		case "account_type":
+			if value != "" && value != "agency" && value != "business" {
+				return fmt.Errorf("gdt: unsupported account_type %q", value)
+			}
+			g.Config.AccountType = value
+		case "token_file", "token_key":
+		default:
+			return fmt.Errorf("gdt: unknown setting %q", key)
+		}
+	}
+
+	tokenFile := settings["token_file"]
+	if tokenFile == "" {
+		return nil
+	}
+	if conf.ClientID == 0 || conf.ClientSecret == "" {
+		return errors.New("gdt: token_file needs client_id and client_secret")
+	}
+
+	// accounts of an agency use the token stored for the agency
+	key := utils.Default(settings["token_key"], strconv.FormatInt(g.AccountID, 10))
+	store := oauth.NewFileStore(tokenFile)
+	if _, err := store.Load(key); err != nil {
+		if errors.Is(err, oauth.ErrNoToken) {
+			g.log.Debugw("no oauth token stored, using the access token", "account", key)
+			return nil
+		}
+		return err
+	}
+
+	g.SetTokenSource(conf.TokenSource(store, key))
+	return nil
+}
+
+// SetTokenSource takes the access token of every request from ts instead of
+// the access token the provider was opened with
+func (g *GdtAdcreatives) SetTokenSource(ts oauth.TokenSource) {
+	g.v2.SetTokenSource(ts)
+	g.v3.SetTokenSource(ts)
+}
+
+// processAdcreatives keeps the adcreatives matching all funcs and the
+// predicates of the filter the list call did not evaluate
+func (g *GdtAdcreatives) processAdcreatives(adcreatives []ads.Map, call *filtering.Call) (processes []ads.Map) {
+	_, rest := g.filter.Split(call)
+	match := ads.And(append(slices.Clip(g.adcreateivesFns), rest.Match)...)
+	for _, adcreative := range adcreatives {
+		if match(adcreative) {
+			processes = append(processes, adcreative)
 		}
 	}
 
 	return processes
 }
 
+// observe advances the watermark to the latest last_modified_time of the
+// listed adcreatives. Images and videos are listed whatever the watermark, a
+// new library material must not move it past adcreatives not listed yet.
+// isV2 tells whether the account has v2 adcreatives, the listing of a v2
+// account is empty too when no adcreative changed since the watermark or
+// matches the pushed down filter
+func (g *GdtAdcreatives) isV2() (bool, error) {
+	since, filter := g.v2.ModifiedSince, g.v2.Filter
+	g.v2.ModifiedSince, g.v2.Filter = 0, nil
+	defer func() {
+		g.v2.ModifiedSince, g.v2.Filter = since, filter
+	}()
+
+	_, total, err := g.v2.Adcreatives(1, 1)
+	return total > 0, err
+}
+
+// materials lists the images or videos of the ids with OnlyAdcreatives, all
+// of the account otherwise. Incremental listings only hold the materials
+// modified since the watermark, the older materials of the listed
+// adcreatives are looked up by id.
+func (g *GdtAdcreatives) materials(list func(ids ...string) ([]ads.Map, error), key string, ids []string) ([]ads.Map, error) {
+	if g.Config.OnlyAdcreatives {
+		return list(ids...)
+	}
+
+	all, err := list()
+	if err != nil || g.v2.ModifiedSince == 0 && g.v3.ModifiedSince == 0 {
+		return all, err
+	}
+
+	listed := make(ads.Set[string])
+	for _, obj := range all {
+		listed.Add(obj.Get(key).String())
+	}
+	var missing []string
+	for _, id := range ids {
+		if _, ok := listed[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return all, nil
+	}
+
+	objs, err := list(missing...)
+	return append(all, objs...), err
+}
+
+func (g *GdtAdcreatives) observe(objs []ads.Map) {
+	for _, obj := range objs {
+		g.watermark = max(g.watermark, int64(obj.Get("last_modified_time").Float64()))
+	}
+}
+
 // printJson
 func (g *GdtAdcreatives) printJson(log *zap.SugaredLogger, key string, v interface{}) {
 	log.With(key, v).Debug("json")
 }
 
-var _ ads.GetAdcreatives = (*GdtAdcreatives)(nil)
+var (
+	_ ads.GetAdcreatives         = (*GdtAdcreatives)(nil)
+	_ ads.IncrementalAdcreatives = (*GdtAdcreatives)(nil)
+	_ ads.Configurable           = (*GdtAdcreatives)(nil)
+	_ ads.PushdownAdcreatives    = (*GdtAdcreatives)(nil)
+	_ ads.AccountLister          = (*GdtAdcreatives)(nil)
+	_ ads.NamedAccount           = (*GdtAdcreatives)(nil)
+)
 
 func init() {
 	ads.RegisterProvider("GDT", func(accountId string, accessToken string, debug bool) (ads.GetAdcreatives, error) {
 		return NewAdcreatives(accountId, accessToken, debug)
 	})
+	ads.DescribeProvider(ads.ProviderInfo{
+		Name:         "GDT",
+		Description:  "Tencent Guangdiantong (广点通) marketing API",
+		Capabilities: []ads.Capability{ads.CapIncremental, ads.CapPushdown, ads.CapAccounts, ads.CapOnlyAdcreatives, ads.CapDownload},
+		PageTypes:    []ads.PageType{ads.PTPageUrl, ads.PTVideo, ads.PTImage, ads.PTText},
+		Credentials:  []string{"access_token"},
+		Settings: []ads.Setting{
+			{Name: "version", Description: "API version, v2 or v3, empty tries v2 and falls back to v3"},
+			{Name: "account_type", Description: "agency or business, how managed accounts are listed"},
+			{Name: "client_id", Description: "OAuth app id refreshing the token_file tokens"},
+			{Name: "client_secret", Description: "OAuth app secret refreshing the token_file tokens"},
+			{Name: "token_file", Description: "file of the OAuth tokens saved by ads auth"},
+			{Name: "token_key", Description: "key of the token in token_file, defaults to the account id, the agency id for accounts of an agency"},
+		},
+	})
 }
 
 func itoa(i int) string {
 	return strconv.Itoa(i)
 }
+
+func intValue(obj ads.Map, key string) int {
+	return int(obj.Get(key).Float64())
+}
+
+func unixTime(obj ads.Map, key string) time.Time {
+	sec := int64(obj.Get(key).Float64())
+	if sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
@@ -0,0 +1,102 @@
+package gdt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/gdt/filtering"
+	"github.com/stretchr/objx"
+)
+
+func TestProcessAdcreativesOnce(t *testing.T) {
+	g, err := NewAdcreatives("1001", "token", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetAdcreativesFunc(ads.ActiveOnly)
+	g.SetAdcreativesFunc(func(ads.Map) bool { return true })
+
+	adcreatives := []ads.Map{
+		objx.New(map[string]interface{}{"adcreative_id": 1, "configured_status": "AD_STATUS_NORMAL"}),
+		objx.New(map[string]interface{}{"adcreative_id": 2, "configured_status": "AD_STATUS_SUSPEND"}),
+		objx.New(map[string]interface{}{"adcreative_id": 3, "is_deleted": true}),
+	}
+
+	got := g.processAdcreatives(adcreatives, &filtering.Call{Adcreatives: true})
+	if len(got) != 1 || got[0].Get("adcreative_id").Int() != 1 {
+		t.Fatalf("processed %v, want adcreative 1 once", got)
+	}
+
+	g.ClearAdcreativesFuncs()
+	if got := g.processAdcreatives(adcreatives, &filtering.Call{Adcreatives: true}); len(got) != len(adcreatives) {
+		t.Fatalf("processed %d adcreatives without funcs, want %d", len(got), len(adcreatives))
+	}
+}
+
+func TestMaterials(t *testing.T) {
+	g, err := NewAdcreatives("1001", "token", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the library lists image 1 modified since the watermark, image 2 of a
+	// changed adcreative is older
+	var calls []string
+	list := func(ids ...string) ([]ads.Map, error) {
+		calls = append(calls, strings.Join(ids, ","))
+		if len(ids) == 0 {
+			return []ads.Map{objx.New(map[string]interface{}{"image_id": "1"})}, nil
+		}
+		var objs []ads.Map
+		for _, id := range ids {
+			objs = append(objs, objx.New(map[string]interface{}{"image_id": id}))
+		}
+		return objs, nil
+	}
+	ids := func(objs []ads.Map) string {
+		var s []string
+		for _, obj := range objs {
+			s = append(s, obj.Get("image_id").String())
+		}
+		return strings.Join(s, ",")
+	}
+
+	for _, tt := range []struct {
+		since int64
+		only  bool
+		want  string
+		calls string
+	}{
+		{since: 0, want: "1", calls: ""},
+		{since: 100, want: "1,2", calls: "|2"},
+		{since: 100, only: true, want: "1,2", calls: "1,2"},
+	} {
+		calls = nil
+		g.v2.ModifiedSince, g.v3.ModifiedSince = tt.since, tt.since
+		g.OnlyAdcreatives(tt.only)
+
+		objs, err := g.materials(list, "image_id", []string{"1", "2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(objs); got != tt.want {
+			t.Errorf("since %d only %v: materials %s, want %s", tt.since, tt.only, got, tt.want)
+		}
+		if got := strings.Join(calls, "|"); got != tt.calls {
+			t.Errorf("since %d only %v: calls %q, want %q", tt.since, tt.only, got, tt.calls)
+		}
+	}
+}
+
+func TestSetAccountName(t *testing.T) {
+	g, err := NewAdcreatives("1001", "token", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a known name is not looked up, the advertiser API is not called
+	g.SetAccountName("shop")
+	if name := g.AccountName(); name != "shop" {
+		t.Errorf("account name %q", name)
+	}
+}
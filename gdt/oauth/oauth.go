@@ -0,0 +1,247 @@
+// Package oauth implements the authorization code flow of the Tencent
+// marketing API, refreshes access tokens before they expire and persists the
+// rotated tokens in a TokenStore.
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/antihax/optional"
+	"github.com/hnhuaxi/ads/gdt/dump"
+	"github.com/hnhuaxi/ads/redact"
+	"github.com/hysios/x/utils/ptr"
+	gdtads "github.com/tencentad/marketing-api-go-sdk/pkg/ads"
+	"github.com/tencentad/marketing-api-go-sdk/pkg/api"
+	"github.com/tencentad/marketing-api-go-sdk/pkg/config"
+	"go.uber.org/zap"
+)
+
+// AuthURL is the authorize page of the marketing API
+const AuthURL = "https://developers.e.qq.com/oauth/authorize"
+
+// expiryDelta is how long before its expiry an access token is refreshed
+const expiryDelta = 10 * time.Minute
+
+var (
+	// ErrNoToken is returned by stores without a token for the key
+	ErrNoToken = errors.New("oauth: no token stored")
+	// ErrTokenExpired is returned when the refresh token expired too and the
+	// account must be authorized again with ads auth
+	ErrTokenExpired = errors.New("oauth: refresh token expired, authorize again")
+)
+
+// Token is an access token with the refresh token rotating it
+type Token struct {
+	AccessToken   string    `json:"access_token"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
+	Expiry        time.Time `json:"expiry,omitempty"`
+	RefreshExpiry time.Time `json:"refresh_expiry,omitempty"`
+	// AccountID is the account that granted the token
+	AccountID string `json:"account_id,omitempty"`
+}
+
+// Valid reports whether the access token is set and not about to expire
+func (t *Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(expiryDelta).Before(t.Expiry)
+}
+
+// CanRefresh reports whether the refresh token is set and not expired
+func (t *Token) CanRefresh() bool {
+	if t == nil || t.RefreshToken == "" {
+		return false
+	}
+	return t.RefreshExpiry.IsZero() || time.Now().Before(t.RefreshExpiry)
+}
+
+// TokenSource returns a valid token for every request
+type TokenSource interface {
+	Token() (*Token, error)
+}
+
+// StaticToken is a raw access token that is never refreshed
+type StaticToken string
+
+func (s StaticToken) Token() (*Token, error) {
+	return &Token{AccessToken: string(s)}, nil
+}
+
+// Config is an OAuth app of the marketing API
+type Config struct {
+	ClientID     int64
+	ClientSecret string
+	// RedirectURL must match the callback registered for the app, the API
+	// does not accept ports
+	RedirectURL string
+	Debug       bool
+}
+
+// AuthCodeURL returns the URL of the page granting the app access, it
+// redirects to RedirectURL with the code and state
+func (c *Config) AuthCodeURL(state string) string {
+	q := url.Values{}
+	q.Set("client_id", strconv.FormatInt(c.ClientID, 10))
+	q.Set("redirect_uri", c.RedirectURL)
+	q.Set("state", state)
+	return AuthURL + "?" + q.Encode()
+}
+
+// Exchange trades an authorization code for a token
+func (c *Config) Exchange(ctx context.Context, code string) (*Token, error) {
+	return c.token(ctx, "authorization_code", &api.OauthTokenOpts{
+		AuthorizationCode: optional.NewString(code),
+		RedirectUri:       optional.NewString(c.RedirectURL),
+	})
+}
+
+// Refresh trades a refresh token for a new access token, the refresh token
+// is kept when the response does not rotate it
+func (c *Config) Refresh(ctx context.Context, t *Token) (*Token, error) {
+	refreshed, err := c.token(ctx, "refresh_token", &api.OauthTokenOpts{
+		RefreshToken: optional.NewString(t.RefreshToken),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken, refreshed.RefreshExpiry = t.RefreshToken, t.RefreshExpiry
+	}
+	if refreshed.AccountID == "" {
+		refreshed.AccountID = t.AccountID
+	}
+	return refreshed, nil
+}
+
+func (c *Config) token(ctx context.Context, grantType string, opts *api.OauthTokenOpts) (*Token, error) {
+	tads := gdtads.Init(&config.SDKConfig{})
+	tads.UseProduction()
+	if c.Debug {
+		tads.AppendMiddleware(dump.Middleware{})
+	}
+
+	now := time.Now()
+	resp, _, err := tads.Oauth().Token(ctx, c.ClientID, c.ClientSecret, grantType, opts)
+	if err != nil {
+		return nil, fmt.Errorf("oauth: %s grant: %w", grantType, err)
+	}
+	if ptr.Type(resp.AccessToken) == "" {
+		return nil, fmt.Errorf("oauth: %s grant returned no access token", grantType)
+	}
+
+	t := &Token{
+		AccessToken:  ptr.Type(resp.AccessToken),
+		RefreshToken: ptr.Type(resp.RefreshToken),
+	}
+	if sec := ptr.Type(resp.AccessTokenExpiresIn); sec > 0 {
+		t.Expiry = now.Add(time.Duration(sec) * time.Second)
+	}
+	if sec := ptr.Type(resp.RefreshTokenExpiresIn); sec > 0 {
+		t.RefreshExpiry = now.Add(time.Duration(sec) * time.Second)
+	}
+	if info := resp.AuthorizerInfo; info != nil && info.AccountId != nil {
+		t.AccountID = strconv.FormatInt(*info.AccountId, 10)
+	}
+	redact.Register(t.AccessToken, t.RefreshToken)
+	return t, nil
+}
+
+type sourceKey struct {
+	store    TokenStore
+	key      string
+	clientID int64
+}
+
+var (
+	sourcesMu sync.Mutex
+	sources   = make(map[sourceKey]*refreshingSource)
+)
+
+// TokenSource returns a source refreshing the token stored under key and
+// saving every rotated token back to the store. The source of a store, key
+// and app is shared by the process, the accounts of an agency refresh the
+// agency token once instead of losing each other's rotated refresh token.
+func (c *Config) TokenSource(store TokenStore, key string) TokenSource {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+
+	k := sourceKey{store: store, key: key, clientID: c.ClientID}
+	if s, ok := sources[k]; ok {
+		return s
+	}
+	s := &refreshingSource{conf: c, store: store, key: key, log: zap.S()}
+	sources[k] = s
+	return s
+}
+
+type refreshingSource struct {
+	mu    sync.Mutex
+	conf  *Config
+	store TokenStore
+	key   string
+	token *Token
+	log   *zap.SugaredLogger
+}
+
+func (s *refreshingSource) Token() (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+
+	// another process may have rotated the token already
+	stored, err := s.store.Load(s.key)
+	if err != nil {
+		return nil, err
+	}
+	redact.Register(stored.AccessToken, stored.RefreshToken)
+	if stored.Valid() {
+		s.token = stored
+		return stored, nil
+	}
+
+	if !stored.CanRefresh() {
+		return nil, fmt.Errorf("%w: %s", ErrTokenExpired, s.key)
+	}
+
+	refreshed, err := s.conf.Refresh(context.Background(), stored)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Save(s.key, refreshed); err != nil {
+		return nil, err
+	}
+	s.log.Infow("refresh access token", "key", s.key, "expiry", refreshed.Expiry)
+
+	s.token = refreshed
+	return refreshed, nil
+}
+
+// Middleware sets the access token of every SDK request from a token source,
+// append it to the middlewares of a v1.1 or v3 SDK client
+type Middleware struct {
+	Source TokenSource
+}
+
+func (m *Middleware) Handle(req *http.Request, next func(req *http.Request) (rsp *http.Response, err error)) (rsp *http.Response, err error) {
+	t, err := m.Source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.URL.Query()
+	query.Set("access_token", t.AccessToken)
+	req.URL.RawQuery = query.Encode()
+	return next(req)
+}
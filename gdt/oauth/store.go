@@ -0,0 +1,126 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TokenStore persists tokens by key, usually the account id
+type TokenStore interface {
+	// Load returns ErrNoToken when no token is stored under key
+	Load(key string) (*Token, error)
+	Save(key string, t *Token) error
+}
+
+// FileStore keeps the tokens of all keys in a JSON file readable by the
+// owner only
+type FileStore struct {
+	Path string
+	mu   sync.Mutex
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[string]*FileStore)
+)
+
+// NewFileStore returns the store of the tokens in path, the stores of a file
+// are shared by the process so saves of different keys never race
+func NewFileStore(path string) *FileStore {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	if s, ok := stores[abs]; ok {
+		return s
+	}
+	s := &FileStore{Path: path}
+	stores[abs] = s
+	return s
+}
+
+// DefaultTokenFile returns the token file under the user config directory
+func DefaultTokenFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "ads-tokens.json"
+	}
+	return filepath.Join(dir, "ads", "tokens.json")
+}
+
+func (s *FileStore) Load(key string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := tokens[key]
+	if !ok {
+		return nil, ErrNoToken
+	}
+	return t, nil
+}
+
+func (s *FileStore) Save(key string, t *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	tokens[key] = t
+
+	b, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+
+	// replace the file at once so readers never see a partial write, the
+	// temp file is created with mode 0600
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.Path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (s *FileStore) read() (map[string]*Token, error) {
+	tokens := make(map[string]*Token)
+
+	b, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
@@ -0,0 +1,99 @@
+package oauth
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hnhuaxi/ads/redact"
+)
+
+func TestFileStoreShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if NewFileStore(path) != NewFileStore(path) {
+		t.Fatal("stores of a file are not shared")
+	}
+}
+
+func TestFileStoreConcurrentSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tokens.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if err := NewFileStore(path).Save(key, &Token{AccessToken: "token" + key}); err != nil {
+				t.Error(err)
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	store := NewFileStore(path)
+	for i := 0; i < 20; i++ {
+		key := strconv.Itoa(i)
+		tok, err := store.Load(key)
+		if err != nil {
+			t.Fatalf("load %s: %v", key, err)
+		}
+		if tok.AccessToken != "token"+key {
+			t.Errorf("token of %s = %q", key, tok.AccessToken)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temp files left: %v", entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("token file mode = %v, %v", info.Mode().Perm(), err)
+	}
+}
+
+func TestTokenSourceShared(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "tokens.json"))
+	if err := store.Save("agency", &Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := (&Config{ClientID: 1}).TokenSource(store, "agency")
+	b := (&Config{ClientID: 1}).TokenSource(store, "agency")
+	if a != b {
+		t.Error("sources of a key are not shared")
+	}
+	if (&Config{ClientID: 2}).TokenSource(store, "agency") == a {
+		t.Error("sources of another app are shared")
+	}
+
+	tok, err := a.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "token" {
+		t.Errorf("access token = %q", tok.AccessToken)
+	}
+}
+
+func TestTokenSourceRedacts(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "tokens.json"))
+	if err := store.Save("1001", &Token{AccessToken: "stored-access-token", RefreshToken: "stored-refresh-token", Expiry: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&Config{ClientID: 3}).TokenSource(store, "1001").Token(); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"stored-access-token", "stored-refresh-token"} {
+		if got := redact.String("token " + secret); got != "token "+redact.Mask {
+			t.Errorf("redact.String = %q", got)
+		}
+	}
+}
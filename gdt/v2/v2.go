@@ -6,6 +6,9 @@ import (
 
 	"github.com/antihax/optional"
 	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/gdt/dump"
+	"github.com/hnhuaxi/ads/gdt/filtering"
+	"github.com/hnhuaxi/ads/gdt/oauth"
 	"github.com/hysios/x/utils/ptr"
 	gdtads "github.com/tencentad/marketing-api-go-sdk/pkg/ads"
 	"github.com/tencentad/marketing-api-go-sdk/pkg/api"
@@ -16,16 +19,23 @@ import (
 type GdtAPI struct {
 	AccountID int64
 	*gdtads.SDKClient
-	log *zap.SugaredLogger
+	// ModifiedSince limits adcreatives, images and videos listings to objects
+	// modified at or after this unix time, zero lists everything
+	ModifiedSince int64
+	// Filter is pushed down to the list calls supporting its predicates
+	Filter filtering.Filter
+	log    *zap.SugaredLogger
 }
 
 func NewGdtAPI(accountId string, accessToken string, debug bool) *GdtAPI {
 	id, _ := strconv.ParseInt(accountId, 10, 64)
 	tads := gdtads.Init(&config.SDKConfig{
 		AccessToken: accessToken,
-		IsDebug:     debug,
 	})
 	tads.UseProduction()
+	if debug {
+		tads.AppendMiddleware(dump.Middleware{})
+	}
 
 	return &GdtAPI{
 		AccountID: id,
@@ -34,6 +44,11 @@ func NewGdtAPI(accountId string, accessToken string, debug bool) *GdtAPI {
 	}
 }
 
+// SetTokenSource takes the access token of every request from ts
+func (g *GdtAPI) SetTokenSource(ts oauth.TokenSource) {
+	g.SDKClient.AppendMiddleware(&oauth.Middleware{Source: ts})
+}
+
 var AdcreativesFields = []string{
 	"adcreative_id",
 	"adcreative_name",
@@ -52,9 +67,11 @@ func (g *GdtAPI) Adcreatives(page, pageSize int) (objs []ads.Map, total int64, e
 	var ctx = context.TODO()
 
 	resp, _, err := g.SDKClient.Adcreatives().Get(ctx, g.AccountID, &api.AdcreativesGetOpts{
-		Page:     optional.NewInt64(int64(page)),
-		PageSize: optional.NewInt64(int64(pageSize)),
-		Fields:   optional.NewInterface(AdcreativesFields),
+		Page:      optional.NewInt64(int64(page)),
+		PageSize:  optional.NewInt64(int64(pageSize)),
+		Fields:    optional.NewInterface(AdcreativesFields),
+		Filtering: g.filtering(filtering.V2Adcreatives, "adcreative_id", nil),
+		IsDeleted: g.isDeleted(filtering.V2Adcreatives),
 	})
 
 	if err != nil {
@@ -77,6 +94,23 @@ func (g *GdtAPI) Adcreatives(page, pageSize int) (objs []ads.Map, total int64, e
 	return objs, ptr.Type(resp.PageInfo.TotalNumber), nil
 }
 
+// AllAdcreatives
+func (g *GdtAPI) AllAdcreatives() (objs []ads.Map, err error) {
+	for page := 1; ; page++ {
+		resp, total, err := g.Adcreatives(page, 100)
+		if err != nil {
+			return objs, err
+		}
+
+		objs = append(objs, resp...)
+		if len(resp) == 0 || int64(len(objs)) >= total {
+			break
+		}
+	}
+
+	return
+}
+
 // Pages
 func (g *GdtAPI) Pages(page, pageSize int, ids ...string) (objs []ads.Map, total int64, err error) {
 	var ctx = context.TODO()
@@ -112,8 +146,7 @@ func (g *GdtAPI) PagesLoop(ids ...string) (objs []ads.Map, err error) {
 	for page := 1; ; page++ {
 		resp, total, err := g.Pages(page, 100, ids...)
 		if err != nil {
-			objs = append(objs, resp...)
-			return objs, nil
+			return objs, err
 		}
 
 		objs = append(objs, resp...)
@@ -133,8 +166,11 @@ func (g *GdtAPI) AllPages(ids ...string) (all []ads.Map, err error) {
 
 	for len(ids) > 0 {
 		l := min(100, len(ids))
-		objs, _ := g.PagesLoop(ids[:l]...)
+		objs, err := g.PagesLoop(ids[:l]...)
 		all = append(all, objs...)
+		if err != nil {
+			return all, err
+		}
 		ids = ids[l:]
 	}
 
@@ -165,7 +201,7 @@ func (g *GdtAPI) Images(page, pageSize int, ids ...string) (objs []ads.Map, tota
 	resp, _, err := g.SDKClient.Images().Get(ctx, g.AccountID, &api.ImagesGetOpts{
 		Page:      optional.NewInt64(int64(page)),
 		PageSize:  optional.NewInt64(int64(pageSize)),
-		Filtering: filterIds("image_id", ids),
+		Filtering: g.filtering(filtering.Images, "image_id", ids),
 		Fields:    optional.NewInterface(ImageFields),
 	})
 
@@ -194,9 +230,7 @@ func (g *GdtAPI) ImagesLoop(ids ...string) (objs []ads.Map, err error) {
 	for page := 1; ; page++ {
 		resp, total, err := g.Images(page, 100, ids...)
 		if err != nil {
-			objs = append(objs, resp...)
-			g.log.Errorw("images loop error", "error", err)
-			return objs, nil
+			return objs, err
 		}
 
 		objs = append(objs, resp...)
@@ -216,8 +250,11 @@ func (g *GdtAPI) AllImages(ids ...string) (all []ads.Map, err error) {
 
 	for len(ids) > 0 {
 		l := min(100, len(ids))
-		objs, _ := g.ImagesLoop(ids[:l]...)
+		objs, err := g.ImagesLoop(ids[:l]...)
 		all = append(all, objs...)
+		if err != nil {
+			return all, err
+		}
 		ids = ids[l:]
 	}
 
@@ -246,7 +283,7 @@ func (g *GdtAPI) Videos(page, pageSize int, ids ...string) (objs []ads.Map, tota
 		Page:      optional.NewInt64(int64(page)),
 		PageSize:  optional.NewInt64(int64(pageSize)),
 		Fields:    optional.NewInterface(VideoFields),
-		Filtering: filterIds("video_id", ids),
+		Filtering: g.filtering(filtering.Videos, "video_id", ids),
 	})
 
 	if err != nil {
@@ -274,9 +311,7 @@ func (g *GdtAPI) VideosLoop(ids ...string) (objs []ads.Map, err error) {
 	for page := 1; ; page++ {
 		resp, total, err := g.Videos(page, 100, ids...)
 		if err != nil {
-			objs = append(objs, resp...)
-			g.log.Errorw("videos loop error", "error", err)
-			return objs, nil
+			return objs, err
 		}
 
 		objs = append(objs, resp...)
@@ -296,14 +331,42 @@ func (g *GdtAPI) AllVideos(ids ...string) (all []ads.Map, err error) {
 
 	for len(ids) > 0 {
 		l := min(100, len(ids))
-		objs, _ := g.VideosLoop(ids[:l]...)
+		objs, err := g.VideosLoop(ids[:l]...)
 		all = append(all, objs...)
+		if err != nil {
+			return all, err
+		}
 		ids = ids[l:]
 	}
 
 	return
 }
 
+// filtering returns the IN filter of ids, or when listing everything the
+// predicates of Filter the call supports and the last_modified_time filter of
+// ModifiedSince
+func (g *GdtAPI) filtering(call *filtering.Call, key string, ids []string) optional.Interface {
+	if len(ids) > 0 {
+		return filterIds(key, ids)
+	}
+
+	pushed, _ := g.Filter.Split(call)
+	if g.ModifiedSince > 0 {
+		pushed = append(pushed, filtering.Predicate{
+			Field:    "last_modified_time",
+			Operator: filtering.GreaterEquals,
+			Values:   []string{strconv.FormatInt(g.ModifiedSince, 10)},
+		})
+	}
+	return pushed.Option()
+}
+
+// isDeleted returns the is_deleted flag of Filter
+func (g *GdtAPI) isDeleted(call *filtering.Call) optional.Bool {
+	pushed, _ := g.Filter.Split(call)
+	return pushed.IsDeleted()
+}
+
 func filterIds(key string, ids []string) optional.Interface {
 	if len(ids) == 0 {
 		return optional.EmptyInterface()
@@ -2,14 +2,19 @@ package v3
 
 import (
 	"context"
+	"errors"
 	"strconv"
 
 	"github.com/antihax/optional"
 	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/gdt/dump"
+	"github.com/hnhuaxi/ads/gdt/filtering"
+	"github.com/hnhuaxi/ads/gdt/oauth"
 	"github.com/hysios/x/utils/ptr"
 	adsv3 "github.com/tencentad/marketing-api-go-sdk/pkg/ads/v3"
 	apiv3 "github.com/tencentad/marketing-api-go-sdk/pkg/api/v3"
 	config "github.com/tencentad/marketing-api-go-sdk/pkg/config/v3"
+	"go.uber.org/zap"
 )
 
 // adsv3 "github.com/tencentad/marketing-api-go-sdk/pkg/ads/v3"
@@ -17,19 +22,33 @@ import (
 type GdtV3API struct {
 	AccountID int64
 	*adsv3.SDKClient
+	// ModifiedSince limits adcreatives, images and videos listings to objects
+	// modified at or after this unix time, zero lists everything
+	ModifiedSince int64
+	// Filter is pushed down to the list calls supporting its predicates
+	Filter filtering.Filter
 }
 
 func NewGdtAPI(accountId string, accessToken string, debug bool) *GdtV3API {
 	id, _ := strconv.ParseInt(accountId, 10, 64)
+	tads := adsv3.Init(&config.SDKConfig{
+		AccessToken: accessToken,
+	})
+	if debug {
+		tads.AppendMiddleware(dump.Middleware{})
+	}
+
 	return &GdtV3API{
 		AccountID: id,
-		SDKClient: adsv3.Init(&config.SDKConfig{
-			AccessToken: accessToken,
-			IsDebug:     debug,
-		}),
+		SDKClient: tads,
 	}
 }
 
+// SetTokenSource takes the access token of every request from ts
+func (g *GdtV3API) SetTokenSource(ts oauth.TokenSource) {
+	g.SDKClient.AppendMiddleware(&oauth.Middleware{Source: ts})
+}
+
 var AdvertisersFields = []string{
 	"dynamic_creative_id",
 	"dynamic_creative_name",
@@ -49,9 +68,11 @@ func (g *GdtV3API) Adcreatives(page, pageSize int) (objs []ads.Map, total int64,
 	var ctx = context.TODO()
 
 	resp, _, err := g.SDKClient.DynamicCreatives().Get(ctx, g.AccountID, &apiv3.DynamicCreativesGetOpts{
-		Page:     optional.NewInt64(int64(page)),
-		PageSize: optional.NewInt64(int64(pageSize)),
-		Fields:   optional.NewInterface(AdvertisersFields),
+		Page:      optional.NewInt64(int64(page)),
+		PageSize:  optional.NewInt64(int64(pageSize)),
+		Fields:    optional.NewInterface(AdvertisersFields),
+		Filtering: g.filtering(filtering.V3DynamicCreatives, "dynamic_creative_id", nil),
+		IsDeleted: g.isDeleted(filtering.V3DynamicCreatives),
 	})
 
 	if err != nil {
@@ -79,8 +100,7 @@ func (g *GdtV3API) AllAdcreatives() (objs []ads.Map, err error) {
 	for page := 1; ; page++ {
 		resp, total, err := g.Adcreatives(page, 100)
 		if err != nil {
-			objs = append(objs, resp...)
-			return objs, nil
+			return objs, err
 		}
 
 		objs = append(objs, resp...)
@@ -221,8 +241,7 @@ func (g *GdtV3API) AllXJPages(pageType string) (objs []ads.Map, err error) {
 	for page := 1; ; page++ {
 		resp, total, err := g.XJPages(pageType, page, 100)
 		if err != nil {
-			objs = append(objs, resp...)
-			return objs, nil
+			return objs, err
 		}
 		objs = append(objs, resp...)
 		if int64(len(objs)) >= total {
@@ -256,9 +275,14 @@ func (g *GdtV3API) AllPages(pageType string, subTypes ...string) (objs []ads.Map
 		for _, subType := range subTypes {
 			pages, err := g.AllXJPages(subType)
 			if err != nil {
+				// page types the account cannot use fail, an expired token
+				// fails them all
+				if errors.Is(err, oauth.ErrTokenExpired) {
+					return nil, err
+				}
+				zap.S().Warnw("xijing pages error", "page_type", subType, "error", err)
 				allPages = append(allPages, pages...)
 				continue
-				// return nil, err
 			}
 			allPages = append(allPages, pages...)
 		}
@@ -269,8 +293,7 @@ func (g *GdtV3API) AllPages(pageType string, subTypes ...string) (objs []ads.Map
 		for page := 1; ; page++ {
 			resp, total, err := g.Pages(pageType, page, 100)
 			if err != nil {
-				objs = append(objs, resp...)
-				return objs, nil
+				return objs, err
 			}
 			objs = append(objs, resp...)
 			if int64(len(objs)) >= total {
@@ -287,8 +310,7 @@ func (g *GdtV3API) AllWechatPages() (objs []ads.Map, err error) {
 	for page := 1; ; page++ {
 		resp, total, err := g.WechatPages(page, 100)
 		if err != nil {
-			objs = append(objs, resp...)
-			return objs, nil
+			return objs, err
 		}
 		objs = append(objs, resp...)
 		if int64(len(objs)) >= total {
@@ -324,7 +346,7 @@ func (g *GdtV3API) Videos(page, pageSize int, ids ...string) (objs []ads.Map, to
 			Page:      optional.NewInt64(int64(page)),
 			PageSize:  optional.NewInt64(int64(pageSize)),
 			Fields:    optional.NewInterface(VideoFields),
-			Filtering: filterIds("media_id", ids),
+			Filtering: g.filtering(filtering.Videos, "media_id", ids),
 		}
 	)
 
@@ -355,8 +377,7 @@ func (g *GdtV3API) AllVideosLoop(ids ...string) (objs []ads.Map, err error) {
 	for page := 1; ; page++ {
 		resp, total, err := g.Videos(page, 100, ids...)
 		if err != nil {
-			objs = append(objs, resp...)
-			return objs, nil
+			return objs, err
 		}
 
 		objs = append(objs, resp...)
@@ -376,8 +397,11 @@ func (g *GdtV3API) AllVideos(ids ...string) (all []ads.Map, err error) {
 
 	for len(ids) > 0 {
 		l := min(100, len(ids))
-		objs, _ := g.AllVideosLoop(ids[:l]...)
+		objs, err := g.AllVideosLoop(ids[:l]...)
 		all = append(all, objs...)
+		if err != nil {
+			return all, err
+		}
 		ids = ids[l:]
 	}
 	return
@@ -408,6 +432,7 @@ func (g *GdtV3API) Images(page, pageSize int, ids ...string) (objs []ads.Map, to
 			Page:      optional.NewInt64(int64(page)),
 			PageSize:  optional.NewInt64(int64(pageSize)),
 			Fields:    optional.NewInterface(ImageFields),
+			Filtering: g.filtering(filtering.Images, "image_id", ids),
 		}
 	)
 
@@ -438,8 +463,7 @@ func (g *GdtV3API) ImagesLoop(ids ...string) (objs []ads.Map, err error) {
 	for page := 1; ; page++ {
 		resp, total, err := g.Images(page, 100, ids...)
 		if err != nil {
-			objs = append(objs, resp...)
-			return objs, nil
+			return objs, err
 		}
 
 		objs = append(objs, resp...)
@@ -459,13 +483,41 @@ func (g *GdtV3API) AllImages(ids ...string) (all []ads.Map, err error) {
 
 	for len(ids) > 0 {
 		l := min(100, len(ids))
-		objs, _ := g.ImagesLoop(ids[:l]...)
+		objs, err := g.ImagesLoop(ids[:l]...)
 		all = append(all, objs...)
+		if err != nil {
+			return all, err
+		}
 		ids = ids[l:]
 	}
 	return
 }
 
+// filtering returns the IN filter of ids, or when listing everything the
+// predicates of Filter the call supports and the last_modified_time filter of
+// ModifiedSince
+func (g *GdtV3API) filtering(call *filtering.Call, key string, ids []string) optional.Interface {
+	if len(ids) > 0 {
+		return filterIds(key, ids)
+	}
+
+	pushed, _ := g.Filter.Split(call)
+	if g.ModifiedSince > 0 {
+		pushed = append(pushed, filtering.Predicate{
+			Field:    "last_modified_time",
+			Operator: filtering.GreaterEquals,
+			Values:   []string{strconv.FormatInt(g.ModifiedSince, 10)},
+		})
+	}
+	return pushed.Option()
+}
+
+// isDeleted returns the is_deleted flag of Filter
+func (g *GdtV3API) isDeleted(call *filtering.Call) optional.Bool {
+	pushed, _ := g.Filter.Split(call)
+	return pushed.IsDeleted()
+}
+
 func filterIds(key string, ids []string) optional.Interface {
 	if len(ids) == 0 {
 		return optional.EmptyInterface()
@@ -478,3 +530,133 @@ func filterIds(key string, ids []string) optional.Interface {
 		},
 	})
 }
+
+var AdvertiserFields = []string{
+	"account_id",
+	"corporation_name",
+	"corporate_brand_name",
+	"system_status",
+	"agency_account_id",
+}
+
+// Advertisers lists a page of advertiser accounts from cursor, of the agency
+// when agencyID is set, else the account itself
+func (g *GdtV3API) Advertisers(agencyID int64, cursor int64) (objs []ads.Map, next int64, hasMore bool, err error) {
+	var (
+		ctx  = context.TODO()
+		opts = &apiv3.AdvertiserGetOpts{}
+	)
+	if agencyID > 0 {
+		opts.AgencyId = optional.NewInt64(agencyID)
+	} else {
+		opts.AccountId = optional.NewInt64(g.AccountID)
+	}
+	if cursor > 0 {
+		opts.Cursor = optional.NewInt64(cursor)
+	}
+
+	resp, _, err := g.SDKClient.Advertiser().Get(ctx, AdvertiserFields, "PAGINATION_MODE_CURSOR", 100, opts)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if resp.List == nil {
+		return nil, 0, false, nil
+	}
+
+	objs, err = ads.ToMapSlice(*resp.List)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if info := resp.CursorPageInfo; info != nil {
+		return objs, ptr.Type(info.Cursor), ptr.Type(info.HasMore), nil
+	}
+	return objs, 0, false, nil
+}
+
+// AllAdvertisers lists the advertiser accounts of an agency
+func (g *GdtV3API) AllAdvertisers(agencyID int64) (objs []ads.Map, err error) {
+	var cursor int64
+	for {
+		resp, next, hasMore, err := g.Advertisers(agencyID, cursor)
+		objs = append(objs, resp...)
+		if err != nil {
+			return objs, err
+		}
+		if !hasMore || next == cursor {
+			break
+		}
+		cursor = next
+	}
+
+	return
+}
+
+// OrganizationAccounts lists a page of the accounts of the business manager
+// account from cursor
+func (g *GdtV3API) OrganizationAccounts(cursor int64) (objs []ads.Map, next int64, hasMore bool, err error) {
+	var (
+		ctx  = context.TODO()
+		opts = &apiv3.OrganizationAccountRelationGetOpts{
+			AccountId: optional.NewInt64(g.AccountID),
+			PageSize:  optional.NewInt64(100),
+		}
+	)
+	if cursor > 0 {
+		opts.Cursor = optional.NewInt64(cursor)
+	}
+
+	resp, _, err := g.SDKClient.OrganizationAccountRelation().Get(ctx, "PAGINATION_MODE_CURSOR", opts)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if resp.List == nil {
+		return nil, 0, false, nil
+	}
+
+	objs, err = ads.ToMapSlice(*resp.List)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if info := resp.CursorPageInfo; info != nil {
+		return objs, ptr.Type(info.Cursor), ptr.Type(info.HasMore), nil
+	}
+	return objs, 0, false, nil
+}
+
+// AllOrganizationAccounts lists the accounts of the business manager account
+func (g *GdtV3API) AllOrganizationAccounts() (objs []ads.Map, err error) {
+	var cursor int64
+	for {
+		resp, next, hasMore, err := g.OrganizationAccounts(cursor)
+		objs = append(objs, resp...)
+		if err != nil {
+			return objs, err
+		}
+		if !hasMore || next == cursor {
+			break
+		}
+		cursor = next
+	}
+
+	return
+}
+
+// Funds lists the fund accounts of an advertiser
+func (g *GdtV3API) Funds(accountID int64) (objs []ads.Map, err error) {
+	var ctx = context.TODO()
+
+	resp, _, err := g.SDKClient.Funds().Get(ctx, accountID, &apiv3.FundsGetOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.List == nil {
+		return nil, nil
+	}
+
+	return ads.ToMapSlice(*resp.List)
+}
@@ -0,0 +1,119 @@
+package googleads
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+)
+
+const (
+	// DefaultEndpoint is the REST interface of the Google Ads API
+	DefaultEndpoint = "https://googleads.googleapis.com"
+	// DefaultVersion is the API version called, Google retires a version
+	// about a year after its release and the default is raised with it
+	DefaultVersion = "v22"
+)
+
+// Error is an error returned by the API
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("googleads: %s (%d %s)", e.Message, e.Code, e.Status)
+}
+
+// Client calls the API with an OAuth access token and a developer token
+type Client struct {
+	Endpoint       string
+	Version        string
+	AccessToken    string
+	DeveloperToken string
+	// LoginCustomerID is the manager account the access token was granted
+	// by, empty for direct access
+	LoginCustomerID string
+	HTTPClient      *http.Client
+	// Debug logs the requests and responses at debug level
+	Debug bool
+}
+
+// Search runs a Google Ads Query Language query for the customer and returns
+// the rows of all pages
+func (c *Client) Search(ctx context.Context, customerID, query string) ([]ads.Map, error) {
+	var (
+		rows      []ads.Map
+		pageToken string
+	)
+	for {
+		body := map[string]interface{}{"query": query}
+		if pageToken != "" {
+			body["pageToken"] = pageToken
+		}
+
+		page, err := c.post(ctx, "customers/"+customerID+"/googleAds:search", body)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, page.Get("results").ObjxMapSlice()...)
+
+		pageToken = page.Get("nextPageToken").String()
+		if pageToken == "" {
+			return rows, nil
+		}
+	}
+}
+
+func (c *Client) post(ctx context.Context, path string, body map[string]interface{}) (ads.Map, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	version := c.Version
+	if version == "" {
+		version = DefaultVersion
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/"+version+"/"+path, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	req.Header.Set("Developer-Token", c.DeveloperToken)
+	if c.LoginCustomerID != "" {
+		req.Header.Set("Login-Customer-Id", c.LoginCustomerID)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rspBody, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Error *Error `json:"error"`
+	}
+	if err := json.Unmarshal(rspBody, &r); err != nil {
+		return nil, fmt.Errorf("googleads: %s: %w", path, err)
+	}
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	return ads.FromJSON(rspBody)
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	// errors come with a 4xx or 5xx status and an error object
+	return httpapi.Doer{Name: "googleads", HTTPClient: c.HTTPClient, Debug: c.Debug, ErrorObjects: true}.Do(req)
+}
@@ -0,0 +1,417 @@
+// Package googleads lists the ads of a Google Ads customer with Google Ads
+// Query Language searches of the REST interface. Responsive search and
+// display ads are mapped to assets by field: headlines, descriptions and
+// business names are text assets, marketing images and logos image assets,
+// YouTube videos video assets and final urls page assets, each with its
+// field as sub type.
+package googleads
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+	"github.com/hysios/x/utils"
+	"go.uber.org/zap"
+)
+
+// batchSize is the number of asset resource names of a query
+const batchSize = 100
+
+type Config struct {
+	OnlyAdcreatives bool
+	// Endpoint is the url of the REST interface, DefaultEndpoint when empty
+	Endpoint string
+	// Version is the API version, DefaultVersion when empty
+	Version string
+}
+
+// GoogleAdsAdcreatives lists the ads of a customer, the account id is the
+// customer id with or without dashes
+type GoogleAdsAdcreatives struct {
+	CustomerID     string
+	Config         Config
+	client         *Client
+	adcreativesFns []ads.AdcreativeMatchFunc
+	accountName    *string
+	log            *zap.SugaredLogger
+}
+
+func NewAdcreatives(accountId string, accessToken string, debug bool) (*GoogleAdsAdcreatives, error) {
+	customerId := strings.ReplaceAll(accountId, "-", "")
+	if customerId == "" {
+		return nil, fmt.Errorf("googleads: no customer id")
+	}
+	g := &GoogleAdsAdcreatives{
+		CustomerID: customerId,
+		client:     &Client{AccessToken: accessToken, Debug: debug},
+		log:        zap.S().With("provider", "GOOGLEADS"),
+	}
+
+	g.SetAdcreativesFunc(ads.NotDeleted)
+
+	return g, nil
+}
+
+const adsQuery = `SELECT
+  ad_group_ad.status,
+  ad_group_ad.ad.id,
+  ad_group_ad.ad.name,
+  ad_group_ad.ad.type,
+  ad_group_ad.ad.final_urls,
+  ad_group_ad.ad.final_mobile_urls,
+  ad_group_ad.ad.responsive_search_ad.headlines,
+  ad_group_ad.ad.responsive_search_ad.descriptions,
+  ad_group_ad.ad.responsive_display_ad.headlines,
+  ad_group_ad.ad.responsive_display_ad.long_headline,
+  ad_group_ad.ad.responsive_display_ad.descriptions,
+  ad_group_ad.ad.responsive_display_ad.business_name,
+  ad_group_ad.ad.responsive_display_ad.marketing_images,
+  ad_group_ad.ad.responsive_display_ad.square_marketing_images,
+  ad_group_ad.ad.responsive_display_ad.logo_images,
+  ad_group_ad.ad.responsive_display_ad.square_logo_images,
+  ad_group_ad.ad.responsive_display_ad.youtube_videos
+FROM ad_group_ad`
+
+const assetFields = `asset.resource_name,
+  asset.id,
+  asset.name,
+  asset.type,
+  asset.image_asset.full_size.url,
+  asset.image_asset.full_size.width_pixels,
+  asset.image_asset.full_size.height_pixels,
+  asset.youtube_video_asset.youtube_video_id,
+  asset.youtube_video_asset.youtube_video_title`
+
+// ref is the ad and field of an asset
+type ref struct {
+	AdID   string
+	AdName string
+	Field  string
+}
+
+// textFields and mediaFields are the fields of responsive ads with their sub
+// types
+var (
+	textFields = []struct{ path, subType string }{
+		{"responsiveSearchAd.headlines", "HEADLINE"},
+		{"responsiveSearchAd.descriptions", "DESCRIPTION"},
+		{"responsiveDisplayAd.headlines", "HEADLINE"},
+		{"responsiveDisplayAd.longHeadline", "LONG_HEADLINE"},
+		{"responsiveDisplayAd.descriptions", "DESCRIPTION"},
+		{"responsiveDisplayAd.businessName", "BUSINESS_NAME"},
+	}
+	mediaFields = []struct{ path, subType string }{
+		{"responsiveDisplayAd.marketingImages", "MARKETING_IMAGE"},
+		{"responsiveDisplayAd.squareMarketingImages", "SQUARE_MARKETING_IMAGE"},
+		{"responsiveDisplayAd.logoImages", "LOGO_IMAGE"},
+		{"responsiveDisplayAd.squareLogoImages", "SQUARE_LOGO_IMAGE"},
+		{"responsiveDisplayAd.youtubeVideos", "YOUTUBE_VIDEO"},
+	}
+)
+
+// Assets lists the assets of the customer's ads: final urls, texts, images
+// and YouTube videos
+func (g *GoogleAdsAdcreatives) Assets() (assets []*ads.Asset, err error) {
+	ctx := context.Background()
+	if g.client.DeveloperToken == "" {
+		return nil, fmt.Errorf("googleads: no developer_token setting")
+	}
+
+	rows, err := g.client.Search(ctx, g.CustomerID, adsQuery)
+	if err != nil {
+		return nil, err
+	}
+	adList := httpapi.Process(rows, normalize, g.adcreativesFns)
+	httpapi.PrintJson(g.log, "ads", adList)
+
+	var (
+		resourceNames = make(ads.Set[string])
+		adsAssets     = make(map[string]*ref)
+		pageIndexs    = make(map[string]bool)
+	)
+
+	for _, row := range adList {
+		ad := row.Get("adGroupAd.ad").ObjxMap()
+		r := &ref{
+			AdID:   ad.Get("id").String(),
+			AdName: ad.Get("name").String(),
+		}
+
+		urls := append(ads.StringSlice(ad.Get("finalUrls")), ads.StringSlice(ad.Get("finalMobileUrls"))...)
+		for i, url := range urls {
+			if pageIndexs[url] {
+				continue
+			}
+			pageIndexs[url] = true
+			assets = append(assets, &ads.Asset{
+				AccountID:      g.CustomerID,
+				Name:           r.AdName,
+				AssetID:        r.AdID + "_" + strconv.Itoa(i),
+				AdcreativeID:   r.AdID,
+				AdcreativeName: r.AdName,
+				PageType:       ads.PTPageUrl,
+				SubType:        "FINAL_URL",
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATPageUrl,
+						Url:  url,
+					},
+				},
+			})
+		}
+
+		texts := make(map[string][]string)
+		var subTypes []string
+		for _, field := range textFields {
+			for _, text := range adTexts(ad, field.path) {
+				if slices.Contains(texts[field.subType], text) {
+					continue
+				}
+				if len(texts[field.subType]) == 0 {
+					subTypes = append(subTypes, field.subType)
+				}
+				texts[field.subType] = append(texts[field.subType], text)
+			}
+		}
+		for _, subType := range subTypes {
+			assets = append(assets, &ads.Asset{
+				AccountID:      g.CustomerID,
+				Name:           r.AdName,
+				AssetID:        r.AdID + "_" + subType,
+				AdcreativeID:   r.AdID,
+				AdcreativeName: r.AdName,
+				PageType:       ads.PTText,
+				SubType:        subType,
+				Texts:          texts[subType],
+			})
+		}
+
+		for _, field := range mediaFields {
+			for _, asset := range ad.Get(field.path).ObjxMapSlice() {
+				name := asset.Get("asset").String()
+				if name == "" {
+					continue
+				}
+				resourceNames.Add(name)
+				if _, ok := adsAssets[name]; !ok {
+					adsAssets[name] = &ref{AdID: r.AdID, AdName: r.AdName, Field: field.subType}
+				}
+			}
+		}
+	}
+
+	if len(resourceNames) > 0 {
+		list, err := g.assets(ctx, resourceNames.Slice())
+		if err != nil {
+			return nil, err
+		}
+		httpapi.PrintJson(g.log, "assets", list)
+
+		for _, row := range list {
+			asset := row.Get("asset").ObjxMap()
+			r, ok := adsAssets[asset.Get("resourceName").String()]
+			if !ok {
+				r = &ref{Field: asset.Get("type").String()}
+			}
+
+			switch asset.Get("type").String() {
+			case "IMAGE":
+				assets = append(assets, &ads.Asset{
+					AccountID:      g.CustomerID,
+					Name:           asset.Get("name").String(),
+					AssetID:        asset.Get("id").String(),
+					AdcreativeID:   r.AdID,
+					AdcreativeName: r.AdName,
+					PageType:       ads.PTImage,
+					SubType:        r.Field,
+					SubAssets: []*ads.SubAsset{
+						{
+							Type: ads.SATImage,
+							Url:  asset.Get("imageAsset.fullSize.url").String(),
+						},
+					},
+					Width:  httpapi.IntValue(asset, "imageAsset.fullSize.widthPixels"),
+					Height: httpapi.IntValue(asset, "imageAsset.fullSize.heightPixels"),
+				})
+			case "YOUTUBE_VIDEO":
+				videoId := asset.Get("youtubeVideoAsset.youtubeVideoId").String()
+				assets = append(assets, &ads.Asset{
+					AccountID:      g.CustomerID,
+					Name:           utils.Default(asset.Get("youtubeVideoAsset.youtubeVideoTitle").String(), asset.Get("name").String()),
+					AssetID:        asset.Get("id").String(),
+					AdcreativeID:   r.AdID,
+					AdcreativeName: r.AdName,
+					PageType:       ads.PTVideo,
+					SubType:        r.Field,
+					SubAssets: []*ads.SubAsset{
+						{
+							Type: ads.SATImage,
+							Url:  "https://i.ytimg.com/vi/" + videoId + "/hqdefault.jpg",
+						},
+						{
+							Type: ads.SATVideo,
+							Url:  "https://www.youtube.com/watch?v=" + videoId,
+						},
+					},
+				})
+			}
+		}
+	}
+
+	name := g.AccountName()
+	for _, asset := range assets {
+		asset.AccountName = name
+	}
+	return assets, nil
+}
+
+// adTexts returns the texts of an ad text asset field or list
+func adTexts(ad ads.Map, path string) []string {
+	v := ad.Get(path)
+	if v.IsObjxMap() || v.IsMSI() {
+		if text := v.ObjxMap().Get("text").String(); text != "" {
+			return []string{text}
+		}
+		return nil
+	}
+	if v.IsStr() {
+		return []string{v.Str()}
+	}
+
+	var texts []string
+	for _, asset := range v.ObjxMapSlice() {
+		if text := asset.Get("text").String(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+// assets reads the image and video assets of the resource names, all image
+// and video assets of the customer unless only the assets of ads are listed
+func (g *GoogleAdsAdcreatives) assets(ctx context.Context, resourceNames []string) ([]ads.Map, error) {
+	if !g.Config.OnlyAdcreatives {
+		return g.client.Search(ctx, g.CustomerID,
+			"SELECT "+assetFields+"\nFROM asset\nWHERE asset.type IN ('IMAGE', 'YOUTUBE_VIDEO')")
+	}
+
+	var rows []ads.Map
+	for _, batch := range httpapi.Batches(resourceNames, batchSize) {
+		quoted := make([]string, len(batch))
+		for i, name := range batch {
+			quoted[i] = "'" + strings.ReplaceAll(name, "'", `\'`) + "'"
+		}
+		objs, err := g.client.Search(ctx, g.CustomerID,
+			"SELECT "+assetFields+"\nFROM asset\nWHERE asset.resource_name IN ("+strings.Join(quoted, ", ")+")")
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, objs...)
+	}
+	return rows, nil
+}
+
+// AccountName returns the descriptive name of the customer, empty when it
+// cannot be read
+func (g *GoogleAdsAdcreatives) AccountName() string {
+	if g.accountName != nil {
+		return *g.accountName
+	}
+
+	var name string
+	rows, err := g.client.Search(context.Background(), g.CustomerID, "SELECT customer.descriptive_name FROM customer")
+	if err != nil {
+		g.log.Warnw("get customer error", "account", g.CustomerID, "error", err)
+	}
+	for _, row := range rows {
+		name = row.Get("customer.descriptiveName").String()
+	}
+
+	g.accountName = &name
+	return name
+}
+
+// SetAdcreativesFunc ...
+func (g *GoogleAdsAdcreatives) SetAdcreativesFunc(match ads.AdcreativeMatchFunc) {
+	g.adcreativesFns = append(g.adcreativesFns, match)
+}
+
+// ClearAdcreativesFuncs ...
+func (g *GoogleAdsAdcreatives) ClearAdcreativesFuncs() {
+	g.adcreativesFns = nil
+}
+
+// OnlyAdcreatives ...
+func (g *GoogleAdsAdcreatives) OnlyAdcreatives(on bool) {
+	g.Config.OnlyAdcreatives = on
+}
+
+// Configure applies provider settings: developer_token is required by the
+// API, login_customer_id is the manager account granting access, endpoint
+// replaces the url of the REST interface and version pins the API version,
+// such as v22
+func (g *GoogleAdsAdcreatives) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		switch key {
+		case "developer_token":
+			g.client.DeveloperToken = value
+		case "login_customer_id":
+			g.client.LoginCustomerID = strings.ReplaceAll(value, "-", "")
+		case "endpoint":
+			g.Config.Endpoint, g.client.Endpoint = value, value
+		case "version":
+			if value != "" && !strings.HasPrefix(value, "v") {
+				return fmt.Errorf("googleads: invalid API version %q", value)
+			}
+			g.Config.Version, g.client.Version = value, value
+		default:
+			return fmt.Errorf("googleads: unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// statuses are the configured_status of ad group ad statuses
+var statuses = map[string]string{
+	"ENABLED": "AD_STATUS_NORMAL",
+	"PAUSED":  "AD_STATUS_SUSPEND",
+}
+
+// normalize sets the is_deleted and configured_status fields of GDT
+// adcreatives from an ad group ad status
+func normalize(row ads.Map) {
+	status := row.Get("adGroupAd.status").String()
+	row.Set("is_deleted", status == "REMOVED")
+	if s, ok := statuses[status]; ok {
+		row.Set("configured_status", s)
+	}
+}
+
+var (
+	_ ads.GetAdcreatives = (*GoogleAdsAdcreatives)(nil)
+	_ ads.Configurable   = (*GoogleAdsAdcreatives)(nil)
+)
+
+func init() {
+	ads.RegisterProvider("GOOGLEADS", func(accountId string, accessToken string, debug bool) (ads.GetAdcreatives, error) {
+		return NewAdcreatives(accountId, accessToken, debug)
+	})
+	ads.DescribeProvider(ads.ProviderInfo{
+		Name:         "GOOGLEADS",
+		Description:  "Google Ads ads and image and YouTube video assets",
+		Capabilities: []ads.Capability{ads.CapOnlyAdcreatives, ads.CapDownload},
+		PageTypes:    []ads.PageType{ads.PTPageUrl, ads.PTVideo, ads.PTImage, ads.PTText},
+		Credentials:  []string{"access_token", "developer_token"},
+		Settings: []ads.Setting{
+			{Name: "developer_token", Description: "developer token of the API access", Required: true},
+			{Name: "login_customer_id", Description: "manager account the access token was granted by"},
+			{Name: "endpoint", Description: "base url of the API", Default: DefaultEndpoint},
+			{Name: "version", Description: "API version, the default follows the versions Google supports and is raised when one is retired", Default: DefaultVersion},
+		},
+	})
+}
@@ -0,0 +1,170 @@
+package googleads
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/apitest"
+)
+
+// search is a search request of the API
+type search struct {
+	Query     string
+	PageToken string
+}
+
+// server serves the search of the customer with the pages of the FROM
+// resource of the query
+func server(t *testing.T, route func(from string, s search) (int, interface{})) *httptest.Server {
+	return apitest.Server(t, apitest.Config{
+		Auth: func(r *http.Request) bool {
+			return r.Header.Get("Authorization") == "Bearer token" && r.Header.Get("Developer-Token") == "developer"
+		},
+	}, map[string]apitest.Route{
+		"/" + DefaultVersion + "/customers/1234567890/googleAds:search": func(r *apitest.Request) interface{} {
+			s := search{}
+			s.Query, _ = r.Body["query"].(string)
+			s.PageToken, _ = r.Body["pageToken"].(string)
+			_, from, _ := strings.Cut(s.Query, "FROM ")
+			from, _, _ = strings.Cut(from, "\n")
+			status, body := route(strings.TrimSpace(from), s)
+			return apitest.Status{Code: status, Body: body}
+		},
+	})
+}
+
+func open(t *testing.T, srv *httptest.Server) *GoogleAdsAdcreatives {
+	return apitest.Open(t, NewAdcreatives, "123-456-7890", srv, map[string]string{"developer_token": "developer"})
+}
+
+func TestAssets(t *testing.T) {
+	var pageTokens []string
+	srv := server(t, func(from string, s search) (int, interface{}) {
+		switch from {
+		case "customer":
+			return http.StatusOK, apitest.Object{"results": []apitest.Object{{"customer": apitest.Object{"descriptiveName": "shop"}}}}
+		case "asset":
+			return http.StatusOK, apitest.Object{"results": []apitest.Object{
+				{"asset": apitest.Object{
+					"resourceName": "customers/1234567890/assets/31", "id": "31", "name": "banner", "type": "IMAGE",
+					"imageAsset": apitest.Object{"fullSize": apitest.Object{"url": "https://example.com/banner.png", "widthPixels": "1200", "heightPixels": 628}},
+				}},
+				{"asset": apitest.Object{
+					"resourceName": "customers/1234567890/assets/32", "id": "32", "name": "clip", "type": "YOUTUBE_VIDEO",
+					"youtubeVideoAsset": apitest.Object{"youtubeVideoId": "yt1", "youtubeVideoTitle": "clip title"},
+				}},
+			}}
+		}
+
+		pageTokens = append(pageTokens, s.PageToken)
+		if s.PageToken == "" {
+			return http.StatusOK, apitest.Object{"nextPageToken": "p2", "results": []apitest.Object{{"adGroupAd": apitest.Object{
+				"status": "ENABLED",
+				"ad": apitest.Object{
+					"id": "11", "name": "search ad",
+					"finalUrls": []string{"https://example.com/a", "https://example.com/b"},
+					"responsiveSearchAd": apitest.Object{
+						"headlines":    []apitest.Object{{"text": "headline a"}, {"text": "headline b"}},
+						"descriptions": []apitest.Object{{"text": "description a"}},
+					},
+				},
+			}}}}
+		}
+		return http.StatusOK, apitest.Object{"results": []apitest.Object{
+			{"adGroupAd": apitest.Object{
+				"status": "PAUSED",
+				"ad": apitest.Object{
+					"id": "12", "name": "display ad",
+					"finalUrls": []string{"https://example.com/a"},
+					"responsiveDisplayAd": apitest.Object{
+						"headlines":       []apitest.Object{{"text": "headline c"}},
+						"longHeadline":    apitest.Object{"text": "long headline"},
+						"marketingImages": []apitest.Object{{"asset": "customers/1234567890/assets/31"}},
+						"youtubeVideos":   []apitest.Object{{"asset": "customers/1234567890/assets/32"}},
+					},
+				},
+			}},
+			{"adGroupAd": apitest.Object{
+				"status": "REMOVED",
+				"ad":     apitest.Object{"id": "13", "name": "removed", "finalUrls": []string{"https://example.com/gone"}},
+			}},
+		}}
+	})
+
+	g := open(t, srv)
+	g.OnlyAdcreatives(true)
+	assets, err := g.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(pageTokens, ",") != ",p2" {
+		t.Errorf("page tokens = %q", pageTokens)
+	}
+
+	got := apitest.Index(t, assets, "1234567890", "shop")
+	if got.Len() != 8 {
+		t.Errorf("got %d assets, want 8", got.Len())
+	}
+	get := got.Get
+
+	if url := get("11_1", ads.PTPageUrl).SubAssets[0].Url; url != "https://example.com/b" {
+		t.Errorf("final url = %q", url)
+	}
+	get("11_0", ads.PTPageUrl)
+	if texts := get("11_HEADLINE", ads.PTText).Texts; strings.Join(texts, "|") != "headline a|headline b" {
+		t.Errorf("headlines = %q", texts)
+	}
+	get("11_DESCRIPTION", ads.PTText)
+	if asset := get("12_LONG_HEADLINE", ads.PTText); asset.AdcreativeID != "12" || asset.Texts[0] != "long headline" {
+		t.Errorf("long headline = %+v", asset)
+	}
+
+	image := get("31", ads.PTImage)
+	if image.AdcreativeID != "12" || image.SubType != "MARKETING_IMAGE" || image.Width != 1200 || image.Height != 628 {
+		t.Errorf("image = %+v", image)
+	}
+	video := get("32", ads.PTVideo)
+	if video.Name != "clip title" || video.SubAssets[1].Url != "https://www.youtube.com/watch?v=yt1" {
+		t.Errorf("video = %+v", video)
+	}
+}
+
+func TestError(t *testing.T) {
+	srv := server(t, func(string, search) (int, interface{}) {
+		return http.StatusForbidden, apitest.Object{"error": apitest.Object{"code": 403, "message": "The caller does not have permission", "status": "PERMISSION_DENIED"}}
+	})
+
+	_, err := open(t, srv).Assets()
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v", err)
+	}
+	if apiErr.Code != 403 || apiErr.Status != "PERMISSION_DENIED" {
+		t.Errorf("error = %+v", apiErr)
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	srv := server(t, func(string, search) (int, interface{}) {
+		return http.StatusBadGateway, apitest.Object{}
+	})
+
+	_, err := open(t, srv).Assets()
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestNoDeveloperToken(t *testing.T) {
+	g, err := NewAdcreatives("123-456-7890", "token", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Assets(); err == nil {
+		t.Error("no error without a developer token")
+	}
+}
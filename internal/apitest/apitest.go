@@ -0,0 +1,150 @@
+// Package apitest fakes the open APIs of the providers in their tests: a
+// server answering the calls of the url paths with JSON, and the checks of
+// the listed assets.
+package apitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/hnhuaxi/ads"
+)
+
+// Object is a JSON object
+type Object = map[string]interface{}
+
+// Request is a call of the server
+type Request struct {
+	*http.Request
+	Query url.Values
+	// Body is the decoded JSON body, nil without a body
+	Body Object
+}
+
+// Route answers the calls of a path, the result is wrapped by the
+// Envelope of the server unless it is a Status
+type Route func(r *Request) interface{}
+
+// Status is a response with an HTTP status, sent as is
+type Status struct {
+	Code int
+	Body interface{}
+}
+
+// Config is the behaviour of a server shared by the calls
+type Config struct {
+	// Auth checks the credentials of a call, a call failing it fails the test
+	Auth func(r *http.Request) bool
+	// Envelope wraps the results of the routes in the response of the API,
+	// the results are sent as is when nil
+	Envelope func(result interface{}) interface{}
+}
+
+// Server serves the routes of the url paths, calls of other paths fail the
+// test. The server is closed with the test.
+func Server(t testing.TB, c Config, routes map[string]Route) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.Auth != nil && !c.Auth(r) {
+			t.Errorf("%s %s: invalid credentials", r.Method, r.URL.Path)
+		}
+		route, ok := routes[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected call %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		req := &Request{Request: r, Query: r.URL.Query()}
+		if r.ContentLength != 0 && r.Body != nil {
+			if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil {
+				t.Errorf("%s: decode body: %v", r.URL.Path, err)
+			}
+		}
+
+		result := route(req)
+		if status, ok := result.(Status); ok {
+			w.WriteHeader(status.Code)
+			json.NewEncoder(w).Encode(status.Body)
+			return
+		}
+		if c.Envelope != nil {
+			result = c.Envelope(result)
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Open opens a provider instance calling srv, the endpoint setting is added
+// to the settings
+func Open[T ads.Configurable](t testing.TB, open func(accountId, accessToken string, debug bool) (T, error), accountId string, srv *httptest.Server, settings map[string]string) T {
+	t.Helper()
+
+	get, err := open(accountId, "token", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := map[string]string{"endpoint": srv.URL}
+	for key, value := range settings {
+		all[key] = value
+	}
+	if err := get.Configure(all); err != nil {
+		t.Fatal(err)
+	}
+	return get
+}
+
+// Assets indexes listed assets by key
+type Assets struct {
+	t         testing.TB
+	accountID string
+	byKey     map[ads.AssetKey]*ads.Asset
+}
+
+// Index indexes the assets, an asset listed twice or not of the account and
+// its name fails the test
+func Index(t testing.TB, assets []*ads.Asset, accountID, accountName string) *Assets {
+	t.Helper()
+
+	a := &Assets{t: t, accountID: accountID, byKey: make(map[ads.AssetKey]*ads.Asset)}
+	for _, asset := range assets {
+		key := ads.KeyOf(asset)
+		if _, ok := a.byKey[key]; ok {
+			t.Errorf("duplicated asset %v", key)
+		}
+		a.byKey[key] = asset
+		if asset.AccountID != accountID || asset.AccountName != accountName {
+			t.Errorf("asset %v of account %q %q", key, asset.AccountID, asset.AccountName)
+		}
+	}
+	return a
+}
+
+// Len returns the number of assets
+func (a *Assets) Len() int {
+	return len(a.byKey)
+}
+
+// Get returns the asset of the id and page type, a missing asset ends the
+// test
+func (a *Assets) Get(assetID string, pageType ads.PageType) *ads.Asset {
+	a.t.Helper()
+
+	asset := a.byKey[ads.AssetKey{AccountID: a.accountID, AssetID: assetID, PageType: pageType}]
+	if asset == nil {
+		a.t.Fatalf("no %s asset %s", pageType, assetID)
+	}
+	return asset
+}
+
+// Has reports whether the asset of the id and page type is listed
+func (a *Assets) Has(assetID string, pageType ads.PageType) bool {
+	_, ok := a.byKey[ads.AssetKey{AccountID: a.accountID, AssetID: assetID, PageType: pageType}]
+	return ok
+}
@@ -0,0 +1,128 @@
+// Package httpapi is the plumbing shared by the providers calling a JSON
+// open API: sending the requests of their client, reading the fields of the
+// listed objects and matching the listed creatives.
+package httpapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"go.uber.org/zap"
+)
+
+// DefaultClient sends the requests of the clients without an HTTP client,
+// a stalled API fails the call after its timeout
+var DefaultClient = &http.Client{Timeout: time.Minute}
+
+// Doer sends the requests of a provider client
+type Doer struct {
+	// Name prefixes the debug logs and errors
+	Name string
+	// HTTPClient sends the requests, DefaultClient when nil
+	HTTPClient *http.Client
+	// Debug logs the requests and responses at debug level
+	Debug bool
+	// ErrorObjects accepts responses of a failed status with an error object,
+	// the client decodes the error of the API
+	ErrorObjects bool
+}
+
+// Do sends the request and returns the body of the response
+func (d Doer) Do(req *http.Request) ([]byte, error) {
+	log := zap.S()
+	if d.Debug {
+		if b, err := httputil.DumpRequestOut(req, true); err == nil {
+			log.Debugw(d.Name+" request", "request", string(b))
+		}
+	}
+
+	client := d.HTTPClient
+	if client == nil {
+		client = DefaultClient
+	}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if d.Debug {
+		log.Debugw(d.Name+" response", "status", rsp.Status, "response", string(body))
+	}
+	if rsp.StatusCode != http.StatusOK && !(d.ErrorObjects && strings.Contains(string(body), `"error"`)) {
+		return nil, fmt.Errorf("%s: %s: %s", d.Name, req.URL.Path, rsp.Status)
+	}
+	return body, nil
+}
+
+// Process keeps the creatives matching all fns. Creatives are normalized
+// first, normalize sets the fields of GDT adcreatives the fns match, such as
+// is_deleted, configured_status and created_time.
+func Process(creatives []ads.Map, normalize func(ads.Map), fns []ads.AdcreativeMatchFunc) (processes []ads.Map) {
+	match := ads.And(fns...)
+	for _, creative := range creatives {
+		normalize(creative)
+		if match(creative) {
+			processes = append(processes, creative)
+		}
+	}
+	return processes
+}
+
+// PrintJson logs v under key at debug level
+func PrintJson(log *zap.SugaredLogger, key string, v interface{}) {
+	log.With(key, v).Debug("json")
+}
+
+// CST is the time zone of the times of the Chinese APIs
+var CST = time.FixedZone("CST", 8*60*60)
+
+// DateTime is the layout of the times of the Chinese APIs
+const DateTime = "2006-01-02 15:04:05"
+
+// ParseTime reads the time of key in the layout, zero when it is missing or
+// malformed
+func ParseTime(obj ads.Map, key, layout string, loc *time.Location) time.Time {
+	t, err := time.ParseInLocation(layout, obj.Get(key).String(), loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// IntValue reads the integer of key, decoded as an int64 or float64 or sent
+// as a string
+func IntValue(obj ads.Map, key string) int {
+	v := obj.Get(key)
+	switch {
+	case v.IsInt64():
+		return int(v.Int64())
+	case v.IsStr():
+		n, _ := strconv.Atoi(v.Str())
+		return n
+	}
+	return int(v.Float64())
+}
+
+// Batches splits values in batches of size, the ids of a list call filter
+func Batches[T any](values []T, size int) [][]T {
+	var b [][]T
+	for len(values) > size {
+		b = append(b, values[:size])
+		values = values[size:]
+	}
+	if len(values) > 0 {
+		b = append(b, values)
+	}
+	return b
+}
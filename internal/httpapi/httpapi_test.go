@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/stretchr/objx"
+)
+
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"data": 1}`))
+		case "/error":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"message": "bad"}}`))
+		default:
+			http.Error(w, "down", http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	get := func(d Doer, path string) ([]byte, error) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.Do(req)
+	}
+
+	d := Doer{Name: "test", Debug: true}
+	if body, err := get(d, "/ok"); err != nil || string(body) != `{"data": 1}` {
+		t.Errorf("ok = %s, %v", body, err)
+	}
+	if _, err := get(d, "/error"); err == nil {
+		t.Error("error object accepted without ErrorObjects")
+	}
+	if _, err := get(d, "/down"); err == nil || err.Error() != "test: /down: 502 Bad Gateway" {
+		t.Errorf("down error = %v", err)
+	}
+
+	d.ErrorObjects = true
+	if body, err := get(d, "/error"); err != nil || len(body) == 0 {
+		t.Errorf("error object = %s, %v", body, err)
+	}
+	if _, err := get(d, "/down"); err == nil {
+		t.Error("failed status without an error object accepted")
+	}
+}
+
+func TestDoTimeout(t *testing.T) {
+	stalled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	defer srv.Close()
+	defer close(stalled)
+
+	defer func(c *http.Client) { DefaultClient = c }(DefaultClient)
+	DefaultClient = &http.Client{Timeout: 50 * time.Millisecond}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (Doer{Name: "test"}).Do(req); err == nil {
+		t.Error("stalled call without a timeout error")
+	}
+}
+
+func TestProcess(t *testing.T) {
+	creatives := []ads.Map{
+		objx.New(map[string]interface{}{"id": 1, "deleted": true}),
+		objx.New(map[string]interface{}{"id": 2}),
+	}
+	normalize := func(creative ads.Map) {
+		creative.Set("is_deleted", creative.Get("deleted").Bool())
+	}
+
+	got := Process(creatives, normalize, []ads.AdcreativeMatchFunc{ads.NotDeleted, ads.NotDeleted})
+	if len(got) != 1 || got[0].Get("id").Int() != 2 {
+		t.Errorf("processed %v", got)
+	}
+	if got := Process(creatives, normalize, nil); len(got) != 2 {
+		t.Errorf("processed %d creatives without funcs", len(got))
+	}
+}
+
+func TestIntValue(t *testing.T) {
+	obj, err := ads.FromJSON([]byte(`{"int": 640, "float": 1.5e3, "str": "42", "bad": "x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]int{"int": 640, "float": 1500, "str": 42, "bad": 0, "missing": 0} {
+		if got := IntValue(obj, key); got != want {
+			t.Errorf("IntValue(%s) = %d, want %d", key, got, want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	obj := objx.New(map[string]interface{}{"time": "2024-03-01 10:00:00", "bad": "yesterday"})
+	if got := ParseTime(obj, "time", DateTime, CST); !got.Equal(time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseTime = %v", got)
+	}
+	if got := ParseTime(obj, "bad", DateTime, CST); !got.IsZero() {
+		t.Errorf("ParseTime of a malformed time = %v", got)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	for _, tt := range []struct {
+		values []string
+		want   [][]string
+	}{
+		{nil, nil},
+		{[]string{"a"}, [][]string{{"a"}}},
+		{[]string{"a", "b"}, [][]string{{"a", "b"}}},
+		{[]string{"a", "b", "c", "d", "e"}, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}},
+	} {
+		if got := Batches(tt.values, 2); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Batches(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package kuaishou
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+	"github.com/stretchr/objx"
+)
+
+// DefaultEndpoint is the Open API of the Magnetic Engine
+const DefaultEndpoint = "https://ad.e.kuaishou.com/rest/openapi"
+
+// pageSize is the page size of list calls
+const pageSize = 100
+
+// Error is an error code returned by the API
+type Error struct {
+	Code      int
+	Message   string
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("kuaishou: code %d: %s (request %s)", e.Code, e.Message, e.RequestID)
+}
+
+// Client calls the Open API with an access token
+type Client struct {
+	Endpoint    string
+	AccessToken string
+	HTTPClient  *http.Client
+	// Debug logs the requests and responses at debug level
+	Debug bool
+}
+
+// Post calls the API at path with a JSON body and returns the data of the
+// response
+func (c *Client) Post(ctx context.Context, path string, body map[string]interface{}) (ads.Map, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/"+strings.TrimPrefix(path, "/"), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Access-Token", c.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	rspBody, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Code      int             `json:"code"`
+		Message   string          `json:"message"`
+		RequestID string          `json:"request_id"`
+		Data      json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rspBody, &r); err != nil {
+		return nil, fmt.Errorf("kuaishou: %s: %w", path, err)
+	}
+	if r.Code != 0 {
+		return nil, &Error{Code: r.Code, Message: r.Message, RequestID: r.RequestID}
+	}
+	if len(r.Data) == 0 || string(r.Data) == "null" {
+		return objx.New(map[string]interface{}{}), nil
+	}
+	if r.Data[0] == '[' {
+		// material calls return their list as data
+		return ads.FromJSON([]byte(`{"details":` + string(r.Data) + `}`))
+	}
+	return ads.FromJSON(r.Data)
+}
+
+// List calls a list call and returns the details of all pages. Calls
+// returning a cursor are paged by the cursor, the others by page number up to
+// their total_count.
+func (c *Client) List(ctx context.Context, path string, body map[string]interface{}) ([]ads.Map, error) {
+	var (
+		objs   []ads.Map
+		cursor string
+	)
+	for page := 1; ; page++ {
+		b := make(map[string]interface{}, len(body)+3)
+		for key, value := range body {
+			b[key] = value
+		}
+		b["page_size"] = pageSize
+		if cursor != "" {
+			b["cursor"] = cursor
+		} else {
+			b["page"] = page
+		}
+
+		data, err := c.Post(ctx, path, b)
+		if err != nil {
+			return nil, err
+		}
+		details := data.Get("details").ObjxMapSlice()
+		objs = append(objs, details...)
+
+		if len(details) == 0 {
+			return objs, nil
+		}
+		if data.Has("cursor") {
+			next := data.Get("cursor").String()
+			if next == "" || next == cursor || next == "-1" {
+				return objs, nil
+			}
+			cursor = next
+			continue
+		}
+		if len(objs) >= httpapi.IntValue(data, "total_count") {
+			return objs, nil
+		}
+	}
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	return httpapi.Doer{Name: "kuaishou", HTTPClient: c.HTTPClient, Debug: c.Debug}.Do(req)
+}
@@ -0,0 +1,477 @@
+// Package kuaishou lists the creatives of a Kuaishou Magnetic Engine (磁力引擎)
+// advertiser with the Open API and maps their videos, cover images, pictures,
+// descriptions, action bar texts and the landing pages of their units into
+// assets.
+package kuaishou
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	OnlyAdcreatives bool
+	// Endpoint is the base url of the Open API, DefaultEndpoint when empty
+	Endpoint string
+}
+
+type KuaishouAdcreatives struct {
+	AdvertiserID   int64
+	Config         Config
+	client         *Client
+	adcreativesFns []ads.AdcreativeMatchFunc
+	accountName    *string
+	log            *zap.SugaredLogger
+}
+
+func NewAdcreatives(accountId string, accessToken string, debug bool) (*KuaishouAdcreatives, error) {
+	id, err := strconv.ParseInt(accountId, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	k := &KuaishouAdcreatives{
+		AdvertiserID: id,
+		client:       &Client{AccessToken: accessToken, Debug: debug},
+		log:          zap.S().With("provider", "KUAISHOU"),
+	}
+
+	k.SetAdcreativesFunc(ads.NotDeleted)
+
+	return k, nil
+}
+
+// materialTypes are the sub types of the creative_material_type of creatives
+var materialTypes = map[int]string{
+	1:  "VERTICAL_VIDEO",
+	2:  "HORIZONTAL_VIDEO",
+	5:  "VERTICAL_IMAGE",
+	6:  "HORIZONTAL_IMAGE",
+	9:  "SMALL_IMAGE",
+	10: "GROUP_IMAGE",
+}
+
+// ref is the creative of a material
+type ref struct {
+	UnitID       string
+	CreativeID   string
+	CreativeName string
+	SubType      string
+	// CoverToken is the image token of the cover of a video
+	CoverToken string
+}
+
+// Assets lists the assets of the advertiser's creatives: landing pages,
+// descriptions, action bar texts, pictures and videos with their covers
+func (k *KuaishouAdcreatives) Assets() (assets []*ads.Asset, err error) {
+	ctx := context.Background()
+
+	creatives, err := k.client.List(ctx, "v1/creative/list", map[string]interface{}{
+		"advertiser_id": k.AdvertiserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	creatives = httpapi.Process(creatives, normalize, k.adcreativesFns)
+	httpapi.PrintJson(k.log, "creatives", creatives)
+
+	var (
+		unitIds      = make(ads.Set[string])
+		imageTokens  = make(ads.Set[string])
+		coverTokens  = make(ads.Set[string])
+		photoIds     = make(ads.Set[string])
+		adsImages    = make(map[string]*ref)
+		adsVideos    = make(map[string]*ref)
+		adsUnits     = make(map[string]*ref)
+		descriptions = make(map[string][]string)
+		actionBars   = make(map[string][]string)
+	)
+
+	for _, creative := range creatives {
+		r := &ref{
+			UnitID:       creative.Get("unit_id").String(),
+			CreativeID:   creative.Get("creative_id").String(),
+			CreativeName: creative.Get("creative_name").String(),
+			SubType:      subType(creative),
+		}
+		unitIds.Add(r.UnitID)
+		if _, ok := adsUnits[r.UnitID]; !ok {
+			adsUnits[r.UnitID] = r
+		}
+
+		if text := creative.Get("description").String(); text != "" && !slices.Contains(descriptions[r.UnitID], text) {
+			descriptions[r.UnitID] = append(descriptions[r.UnitID], text)
+		}
+		if text := creative.Get("action_bar_text").String(); text != "" && !slices.Contains(actionBars[r.UnitID], text) {
+			actionBars[r.UnitID] = append(actionBars[r.UnitID], text)
+		}
+
+		if photoId := creative.Get("photo_id").String(); photoId != "" {
+			// the image token of a video creative is its cover
+			r.CoverToken = creative.Get("image_token").String()
+			if r.CoverToken != "" {
+				coverTokens.Add(r.CoverToken)
+			}
+			photoIds.Add(photoId)
+			if _, ok := adsVideos[photoId]; !ok {
+				adsVideos[photoId] = r
+			}
+			continue
+		}
+
+		for _, token := range append(ads.StringSlice(creative.Get("image_tokens")), creative.Get("image_token").String()) {
+			if token == "" {
+				continue
+			}
+			imageTokens.Add(token)
+			if _, ok := adsImages[token]; !ok {
+				adsImages[token] = r
+			}
+		}
+	}
+
+	units, err := k.units(ctx, unitIds.Slice())
+	if err != nil {
+		return nil, err
+	}
+	httpapi.PrintJson(k.log, "units", units)
+
+	unitNames := make(map[string]string, len(units))
+	pageIndexs := make(map[string]bool)
+	for _, unit := range units {
+		unitId := unit.Get("unit_id").String()
+		unitNames[unitId] = unit.Get("unit_name").String()
+
+		url := unit.Get("url").String()
+		if url == "" || pageIndexs[url] {
+			continue
+		}
+		pageIndexs[url] = true
+
+		r, ok := adsUnits[unitId]
+		if !ok {
+			r = &ref{UnitID: unitId}
+		}
+		assets = append(assets, &ads.Asset{
+			AccountID:      k.accountID(),
+			Name:           unit.Get("unit_name").String(),
+			AssetID:        unitId,
+			AdcreativeID:   r.CreativeID,
+			AdcreativeName: r.CreativeName,
+			PageType:       ads.PTPageUrl,
+			SubType:        "UNIT_URL",
+			SubAssets: []*ads.SubAsset{
+				{
+					Type: ads.SATPageUrl,
+					Url:  url,
+				},
+			},
+			CreatedTime:      parseTime(unit, "create_time"),
+			LastModifiedTime: parseTime(unit, "update_time"),
+		})
+	}
+
+	for _, unitId := range unitIds.Slice() {
+		r := adsUnits[unitId]
+		for _, texts := range []struct {
+			subType string
+			texts   []string
+		}{
+			{"DESCRIPTION", descriptions[unitId]},
+			{"ACTION_BAR", actionBars[unitId]},
+		} {
+			if len(texts.texts) == 0 {
+				continue
+			}
+			assets = append(assets, &ads.Asset{
+				AccountID:      k.accountID(),
+				Name:           unitNames[unitId],
+				AssetID:        unitId + "_" + texts.subType,
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: r.CreativeName,
+				PageType:       ads.PTText,
+				SubType:        texts.subType,
+				Texts:          texts.texts,
+			})
+		}
+	}
+
+	images := make(map[string]ads.Map)
+	if len(imageTokens) > 0 || len(coverTokens) > 0 {
+		list, err := k.images(ctx, append(imageTokens.Slice(), coverTokens.Slice()...))
+		if err != nil {
+			return nil, err
+		}
+		httpapi.PrintJson(k.log, "images", list)
+
+		for _, image := range list {
+			token := image.Get("image_token").String()
+			images[token] = image
+
+			r, ok := adsImages[token]
+			if !ok {
+				// covers are sub assets of their videos
+				if _, cover := coverTokens[token]; cover {
+					continue
+				}
+				r = &ref{}
+			}
+			assets = append(assets, &ads.Asset{
+				AccountID:      k.accountID(),
+				Name:           r.CreativeName,
+				AssetID:        token,
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: r.CreativeName,
+				PageType:       ads.PTImage,
+				SubType:        r.SubType,
+				Signature:      image.Get("signature").String(),
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATImage,
+						Url:  image.Get("url").String(),
+					},
+				},
+				Width:  httpapi.IntValue(image, "width"),
+				Height: httpapi.IntValue(image, "height"),
+			})
+		}
+	}
+
+	if len(photoIds) > 0 {
+		videos, err := k.videos(ctx, photoIds.Slice())
+		if err != nil {
+			return nil, err
+		}
+		httpapi.PrintJson(k.log, "videos", videos)
+
+		for _, video := range videos {
+			r, ok := adsVideos[video.Get("photo_id").String()]
+			if !ok {
+				r = &ref{}
+			}
+
+			cover := video.Get("cover_url").String()
+			if image, ok := images[r.CoverToken]; ok {
+				cover = image.Get("url").String()
+			}
+			assets = append(assets, &ads.Asset{
+				AccountID:      k.accountID(),
+				Name:           video.Get("photo_name").String(),
+				AssetID:        video.Get("photo_id").String(),
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: r.CreativeName,
+				PageType:       ads.PTVideo,
+				SubType:        r.SubType,
+				Signature:      video.Get("signature").String(),
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATImage,
+						Url:  cover,
+					},
+					{
+						Type: ads.SATVideo,
+						Url:  video.Get("url").String(),
+					},
+				},
+				Width:       httpapi.IntValue(video, "width"),
+				Height:      httpapi.IntValue(video, "height"),
+				CreatedTime: parseTime(video, "upload_time"),
+			})
+		}
+	}
+
+	name := k.AccountName()
+	for _, asset := range assets {
+		asset.AccountName = name
+	}
+	return assets, nil
+}
+
+// units lists the units of the creatives with their landing pages
+func (k *KuaishouAdcreatives) units(ctx context.Context, ids []string) ([]ads.Map, error) {
+	var units []ads.Map
+	for _, batch := range httpapi.Batches(ids, pageSize) {
+		objs, err := k.client.List(ctx, "v1/ad_unit/list", map[string]interface{}{
+			"advertiser_id": k.AdvertiserID,
+			"unit_ids":      toInts(batch),
+		})
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, objs...)
+	}
+	return units, nil
+}
+
+// images gets the images of the tokens, all images of the advertiser unless
+// only the materials of creatives are listed
+func (k *KuaishouAdcreatives) images(ctx context.Context, tokens []string) ([]ads.Map, error) {
+	if !k.Config.OnlyAdcreatives {
+		return k.client.List(ctx, "v2/file/ad/image/list", map[string]interface{}{
+			"advertiser_id": k.AdvertiserID,
+		})
+	}
+
+	images := make([]ads.Map, 0, len(tokens))
+	for _, token := range tokens {
+		image, err := k.client.Post(ctx, "v2/file/ad/image/get", map[string]interface{}{
+			"advertiser_id": k.AdvertiserID,
+			"image_token":   token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// videos gets the videos of the photo ids, all videos of the advertiser unless
+// only the materials of creatives are listed
+func (k *KuaishouAdcreatives) videos(ctx context.Context, photoIds []string) ([]ads.Map, error) {
+	if !k.Config.OnlyAdcreatives {
+		return k.client.List(ctx, "v1/file/ad/video/list", map[string]interface{}{
+			"advertiser_id": k.AdvertiserID,
+		})
+	}
+
+	var videos []ads.Map
+	for _, batch := range httpapi.Batches(photoIds, pageSize) {
+		data, err := k.client.Post(ctx, "v1/file/ad/video/get", map[string]interface{}{
+			"advertiser_id": k.AdvertiserID,
+			"photo_ids":     batch,
+		})
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, data.Get("details").ObjxMapSlice()...)
+	}
+	return videos, nil
+}
+
+// AccountName returns the corporation name of the advertiser, empty when it
+// cannot be read
+func (k *KuaishouAdcreatives) AccountName() string {
+	if k.accountName != nil {
+		return *k.accountName
+	}
+
+	data, err := k.client.Post(context.Background(), "v1/advertiser/info", map[string]interface{}{
+		"advertiser_id": k.AdvertiserID,
+	})
+	if err != nil {
+		k.log.Warnw("get advertiser error", "account", k.AdvertiserID, "error", err)
+	}
+	name := data.Get("corporation_name").String()
+
+	k.accountName = &name
+	return name
+}
+
+// SetAdcreativesFunc ...
+func (k *KuaishouAdcreatives) SetAdcreativesFunc(match ads.AdcreativeMatchFunc) {
+	k.adcreativesFns = append(k.adcreativesFns, match)
+}
+
+// ClearAdcreativesFuncs ...
+func (k *KuaishouAdcreatives) ClearAdcreativesFuncs() {
+	k.adcreativesFns = nil
+}
+
+// OnlyAdcreatives ...
+func (k *KuaishouAdcreatives) OnlyAdcreatives(on bool) {
+	k.Config.OnlyAdcreatives = on
+}
+
+// Configure applies provider settings: endpoint replaces the url of the Open
+// API
+func (k *KuaishouAdcreatives) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		switch key {
+		case "endpoint":
+			k.Config.Endpoint, k.client.Endpoint = value, value
+		default:
+			return fmt.Errorf("kuaishou: unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// statuses are the configured_status of the put_status of creatives
+var statuses = map[int]string{
+	1: "AD_STATUS_NORMAL",
+	2: "AD_STATUS_SUSPEND",
+}
+
+// normalize sets the is_deleted, configured_status, created_time and
+// last_modified_time fields of GDT adcreatives from a creative
+func normalize(creative ads.Map) {
+	putStatus := httpapi.IntValue(creative, "put_status")
+	creative.Set("is_deleted", putStatus == 3)
+	if status, ok := statuses[putStatus]; ok {
+		creative.Set("configured_status", status)
+	}
+	if t := parseTime(creative, "create_time"); !t.IsZero() {
+		creative.Set("created_time", t.Unix())
+	}
+	if t := parseTime(creative, "update_time"); !t.IsZero() {
+		creative.Set("last_modified_time", t.Unix())
+	}
+}
+
+func subType(creative ads.Map) string {
+	n := httpapi.IntValue(creative, "creative_material_type")
+	if name, ok := materialTypes[n]; ok {
+		return name
+	}
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func (k *KuaishouAdcreatives) accountID() string {
+	return strconv.FormatInt(k.AdvertiserID, 10)
+}
+
+var (
+	_ ads.GetAdcreatives = (*KuaishouAdcreatives)(nil)
+	_ ads.Configurable   = (*KuaishouAdcreatives)(nil)
+)
+
+func init() {
+	ads.RegisterProvider("KUAISHOU", func(accountId string, accessToken string, debug bool) (ads.GetAdcreatives, error) {
+		return NewAdcreatives(accountId, accessToken, debug)
+	})
+	ads.DescribeProvider(ads.ProviderInfo{
+		Name:         "KUAISHOU",
+		Description:  "Kuaishou Magnetic Engine (磁力引擎) creatives",
+		Capabilities: []ads.Capability{ads.CapOnlyAdcreatives, ads.CapDownload},
+		PageTypes:    []ads.PageType{ads.PTPageUrl, ads.PTVideo, ads.PTImage, ads.PTText},
+		Credentials:  []string{"access_token"},
+		Settings: []ads.Setting{
+			{Name: "endpoint", Description: "base url of the API", Default: DefaultEndpoint},
+		},
+	})
+}
+
+// parseTime reads a time of the API
+func parseTime(obj ads.Map, key string) time.Time {
+	return httpapi.ParseTime(obj, key, httpapi.DateTime, httpapi.CST)
+}
+
+func toInts(ids []string) []int64 {
+	ints := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+			ints = append(ints, n)
+		}
+	}
+	return ints
+}
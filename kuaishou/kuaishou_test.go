@@ -0,0 +1,122 @@
+package kuaishou
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/apitest"
+)
+
+// server serves the data of the paths for the request bodies
+func server(t *testing.T, routes map[string]apitest.Route) *httptest.Server {
+	return apitest.Server(t, apitest.Config{
+		Auth: func(r *http.Request) bool {
+			return r.Method == http.MethodPost && r.Header.Get("Access-Token") == "token"
+		},
+		Envelope: func(data interface{}) interface{} {
+			return apitest.Object{"code": 0, "message": "OK", "request_id": "req", "data": data}
+		},
+	}, routes)
+}
+
+func open(t *testing.T, srv *httptest.Server) *KuaishouAdcreatives {
+	return apitest.Open(t, NewAdcreatives, "1001", srv, nil)
+}
+
+func TestAssets(t *testing.T) {
+	var cursors, unitPages []interface{}
+	srv := server(t, map[string]apitest.Route{
+		"/v1/creative/list": func(r *apitest.Request) interface{} {
+			cursors = append(cursors, r.Body["cursor"])
+			if r.Body["cursor"] == nil {
+				return apitest.Object{"cursor": "abc", "details": []apitest.Object{
+					{"creative_id": 21, "unit_id": 31, "creative_name": "video", "photo_id": "p1", "image_token": "cov1", "description": "watch", "action_bar_text": "Buy", "put_status": 1, "creative_material_type": 1, "create_time": "2024-03-01 10:00:00"},
+				}}
+			}
+			return apitest.Object{"cursor": "-1", "details": []apitest.Object{
+				{"creative_id": 22, "unit_id": 32, "creative_name": "image", "image_tokens": []string{"img1"}, "description": "look", "put_status": 2, "creative_material_type": 6},
+				{"creative_id": 23, "unit_id": 33, "creative_name": "deleted", "description": "gone", "put_status": 3},
+			}}
+		},
+		"/v1/ad_unit/list": func(r *apitest.Request) interface{} {
+			unitPages = append(unitPages, r.Body["page"])
+			if r.Body["page"] == float64(1) {
+				return apitest.Object{"total_count": 2, "details": []apitest.Object{{"unit_id": 31, "unit_name": "unit a", "url": "https://example.com/a", "create_time": "2024-03-01 09:00:00"}}}
+			}
+			return apitest.Object{"total_count": 2, "details": []apitest.Object{{"unit_id": 32, "unit_name": "unit b", "url": "https://example.com/b"}}}
+		},
+		"/v2/file/ad/image/list": func(r *apitest.Request) interface{} {
+			return apitest.Object{"total_count": 2, "details": []apitest.Object{
+				{"image_token": "img1", "url": "https://example.com/1.jpg", "width": 1280, "height": 720, "signature": "sig1"},
+				{"image_token": "cov1", "url": "https://example.com/cover.jpg"},
+			}}
+		},
+		"/v1/file/ad/video/list": func(r *apitest.Request) interface{} {
+			return apitest.Object{"total_count": 1, "details": []apitest.Object{
+				{"photo_id": "p1", "photo_name": "clip", "url": "https://example.com/p1.mp4", "cover_url": "https://example.com/p1.jpg", "width": 720, "height": 1280, "upload_time": "2024-02-01 08:00:00"},
+			}}
+		},
+		"/v1/advertiser/info": func(r *apitest.Request) interface{} {
+			return apitest.Object{"corporation_name": "corporation"}
+		},
+	})
+
+	assets, err := open(t, srv).Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cursors) != 2 || cursors[0] != nil || cursors[1] != "abc" {
+		t.Errorf("creative cursors %v", cursors)
+	}
+	if len(unitPages) != 2 {
+		t.Errorf("unit pages %v", unitPages)
+	}
+
+	got := apitest.Index(t, assets, "1001", "corporation")
+	for _, a := range assets {
+		if a.AdcreativeID == "23" {
+			t.Errorf("asset %v of a deleted creative", ads.KeyOf(a))
+		}
+	}
+	if got.Len() != 7 {
+		t.Errorf("listed %d assets, want 7", got.Len())
+	}
+
+	if a := got.Get("31", ads.PTPageUrl); a.PrimaryUrl() != "https://example.com/a" || a.AdcreativeID != "21" || a.CreatedTime.IsZero() {
+		t.Errorf("landing page %+v", a)
+	}
+	for id, text := range map[string]string{"31_DESCRIPTION": "watch", "31_ACTION_BAR": "Buy", "32_DESCRIPTION": "look"} {
+		if a := got.Get(id, ads.PTText); len(a.Texts) != 1 || a.Texts[0] != text {
+			t.Errorf("text %s %+v", id, a)
+		}
+	}
+	if a := got.Get("img1", ads.PTImage); a.PrimaryUrl() != "https://example.com/1.jpg" || a.Width != 1280 || a.SubType != "HORIZONTAL_IMAGE" || a.Signature != "sig1" {
+		t.Errorf("image %+v", a)
+	}
+	if got.Has("cov1", ads.PTImage) {
+		t.Error("cover listed as an image")
+	}
+	if a := got.Get("p1", ads.PTVideo); a.ImageUrl() != "https://example.com/cover.jpg" || a.PrimaryUrl() != "https://example.com/p1.mp4" || a.SubType != "VERTICAL_VIDEO" || a.CreatedTime.IsZero() {
+		t.Errorf("video %+v", a)
+	}
+}
+
+func TestError(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/v1/creative/list": func(*apitest.Request) interface{} {
+			return apitest.Status{Code: http.StatusOK, Body: apitest.Object{"code": 401000, "message": "invalid access token", "request_id": "req1"}}
+		},
+	})
+
+	_, err := open(t, srv).Assets()
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v is not an API error", err)
+	}
+	if apiErr.Code != 401000 || apiErr.Message != "invalid access token" || apiErr.RequestID != "req1" {
+		t.Errorf("error %+v", apiErr)
+	}
+}
@@ -0,0 +1,106 @@
+package ads
+
+import (
+	"time"
+)
+
+// And matches adcreatives matched by every func, all adcreatives without funcs.
+// Providers combine the funcs set with SetAdcreativesFunc this way, an
+// adcreative is processed once when it matches them all, Or and Not build
+// alternatives and exclusions.
+func And(fns ...AdcreativeMatchFunc) AdcreativeMatchFunc {
+	return func(adcreative Map) bool {
+		for _, fn := range fns {
+			if !fn(adcreative) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches adcreatives matched by any func, none without funcs
+func Or(fns ...AdcreativeMatchFunc) AdcreativeMatchFunc {
+	return func(adcreative Map) bool {
+		for _, fn := range fns {
+			if fn(adcreative) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches adcreatives not matched by fn
+func Not(fn AdcreativeMatchFunc) AdcreativeMatchFunc {
+	return func(adcreative Map) bool {
+		return !fn(adcreative)
+	}
+}
+
+// NotDeleted skips adcreatives flagged is_deleted, the default func of
+// providers
+func NotDeleted(adcreative Map) bool {
+	return !adcreative.Get("is_deleted").Bool()
+}
+
+// ActiveOnly matches adcreatives not deleted whose configured_status is
+// AD_STATUS_NORMAL, adcreatives without a status are active
+func ActiveOnly(adcreative Map) bool {
+	if !NotDeleted(adcreative) {
+		return false
+	}
+	status := adcreative.Get("configured_status").Str()
+	return status == "" || status == "AD_STATUS_NORMAL"
+}
+
+// CreatedBetween matches adcreatives created in [from, to), a zero bound is
+// open. created_time is read as unix seconds or an RFC 3339 or date string,
+// adcreatives without it never match a bounded range.
+func CreatedBetween(from, to time.Time) AdcreativeMatchFunc {
+	return func(adcreative Map) bool {
+		if from.IsZero() && to.IsZero() {
+			return true
+		}
+
+		created, ok := createdTime(adcreative)
+		if !ok {
+			return false
+		}
+		return (from.IsZero() || !created.Before(from)) && (to.IsZero() || created.Before(to))
+	}
+}
+
+func createdTime(adcreative Map) (time.Time, bool) {
+	v := adcreative.Get("created_time")
+	switch {
+	case v.IsFloat64():
+		return time.Unix(int64(v.Float64()), 0), v.Float64() > 0
+	case v.IsInt64():
+		return time.Unix(v.Int64(), 0), v.Int64() > 0
+	case v.IsInt():
+		return time.Unix(int64(v.Int()), 0), v.Int() > 0
+	case v.IsStr():
+		return ParseDate(v.Str())
+	}
+	return time.Time{}, false
+}
+
+// dateLayouts are the layouts read by ParseDate
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseDate parses an RFC 3339 time, or a date with an optional time in the
+// local time zone
+func ParseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,108 @@
+package ads
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/objx"
+)
+
+func yes(Map) bool { return true }
+func no(Map) bool  { return false }
+
+func TestAndOr(t *testing.T) {
+	adcr := objx.New(map[string]interface{}{})
+
+	for _, tt := range []struct {
+		name string
+		fn   AdcreativeMatchFunc
+		want bool
+	}{
+		{"and none", And(), true},
+		{"and all", And(yes, yes), true},
+		{"and one fails", And(yes, no), false},
+		{"or none", Or(), false},
+		{"or one", Or(no, yes), true},
+		{"or all fail", Or(no, no), false},
+		{"not yes", Not(yes), false},
+		{"not no", Not(no), true},
+		{"not and none", Not(And()), false},
+		{"not or none", Not(Or()), true},
+	} {
+		if got := tt.fn(adcr); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNotDeleted(t *testing.T) {
+	if !NotDeleted(objx.New(map[string]interface{}{})) {
+		t.Error("adcreative without is_deleted is deleted")
+	}
+	if NotDeleted(objx.New(map[string]interface{}{"is_deleted": true})) {
+		t.Error("deleted adcreative matched")
+	}
+}
+
+func TestActiveOnly(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		adcr map[string]interface{}
+		want bool
+	}{
+		{"no status", map[string]interface{}{}, true},
+		{"normal", map[string]interface{}{"configured_status": "AD_STATUS_NORMAL"}, true},
+		{"suspended", map[string]interface{}{"configured_status": "AD_STATUS_SUSPEND"}, false},
+		{"deleted without status", map[string]interface{}{"is_deleted": true}, false},
+		{"deleted normal", map[string]interface{}{"configured_status": "AD_STATUS_NORMAL", "is_deleted": true}, false},
+	} {
+		if got := ActiveOnly(objx.New(tt.adcr)); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreatedBetween(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+	between := CreatedBetween(from, to)
+
+	for _, tt := range []struct {
+		name    string
+		created interface{}
+		want    bool
+	}{
+		{"unix from", from.Unix(), true},
+		{"unix float from", float64(from.Unix()), true},
+		{"unix before from", from.Unix() - 1, false},
+		{"unix before to", to.Unix() - 1, true},
+		{"unix to", to.Unix(), false},
+		{"rfc3339 from", from.Format(time.RFC3339), true},
+		{"rfc3339 before from", from.Add(-time.Second).Format(time.RFC3339), false},
+		{"rfc3339 to", to.Format(time.RFC3339), false},
+		{"date from", "2024-03-01", true},
+		{"date before to", "2024-03-31", true},
+		{"date to", "2024-04-01", false},
+		{"datetime before to", "2024-03-31 23:59:59", true},
+		{"zero", int64(0), false},
+		{"garbage", "yesterday", false},
+	} {
+		adcr := objx.New(map[string]interface{}{"created_time": tt.created})
+		if got := between(adcr); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if between(objx.New(map[string]interface{}{})) {
+		t.Error("adcreative without created_time matched a bounded range")
+	}
+	if !CreatedBetween(time.Time{}, time.Time{})(objx.New(map[string]interface{}{})) {
+		t.Error("open range did not match an adcreative without created_time")
+	}
+	if !CreatedBetween(from, time.Time{})(objx.New(map[string]interface{}{"created_time": to.Unix()})) {
+		t.Error("open upper bound did not match")
+	}
+	if !CreatedBetween(time.Time{}, to)(objx.New(map[string]interface{}{"created_time": from.Unix()})) {
+		t.Error("open lower bound did not match")
+	}
+}
@@ -0,0 +1,114 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+)
+
+const (
+	// DefaultEndpoint is the Graph API
+	DefaultEndpoint = "https://graph.facebook.com"
+	// DefaultVersion is the Graph API version called
+	DefaultVersion = "v19.0"
+)
+
+// pageSize is the limit of list calls
+const pageSize = 100
+
+// Error is an error returned by the Graph API
+type Error struct {
+	Message   string `json:"message"`
+	Type      string `json:"type"`
+	Code      int    `json:"code"`
+	Subcode   int    `json:"error_subcode"`
+	FBTraceID string `json:"fbtrace_id"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("meta: %s (%s code %d/%d, trace %s)", e.Message, e.Type, e.Code, e.Subcode, e.FBTraceID)
+}
+
+// Client calls the Graph API with an access token
+type Client struct {
+	Endpoint    string
+	Version     string
+	AccessToken string
+	HTTPClient  *http.Client
+	// Debug logs the requests and responses at debug level
+	Debug bool
+}
+
+// Get reads the node or edge at path
+func (c *Client) Get(ctx context.Context, path string, params url.Values) (ads.Map, error) {
+	q := url.Values{}
+	for key, values := range params {
+		q[key] = values
+	}
+	q.Set("access_token", c.AccessToken)
+
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	version := c.Version
+	if version == "" {
+		version = DefaultVersion
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/"+version+"/"+strings.TrimPrefix(path, "/")+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Error *Error `json:"error"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("meta: %s: %w", path, err)
+	}
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	return ads.FromJSON(body)
+}
+
+// List reads all pages of the edge at path, following the after cursors
+func (c *Client) List(ctx context.Context, path string, params url.Values) ([]ads.Map, error) {
+	var objs []ads.Map
+
+	q := url.Values{}
+	for key, values := range params {
+		q[key] = values
+	}
+	q.Set("limit", strconv.Itoa(pageSize))
+	for {
+		page, err := c.Get(ctx, path, q)
+		if err != nil {
+			return nil, err
+		}
+		data := page.Get("data").ObjxMapSlice()
+		objs = append(objs, data...)
+
+		after := page.Get("paging.cursors.after").String()
+		if len(data) == 0 || after == "" || page.Get("paging.next").String() == "" {
+			return objs, nil
+		}
+		q.Set("after", after)
+	}
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	return httpapi.Doer{Name: "meta", HTTPClient: c.HTTPClient, Debug: c.Debug, ErrorObjects: true}.Do(req)
+}
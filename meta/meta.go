@@ -0,0 +1,473 @@
+// Package meta lists the ad creatives of a Meta (Facebook and Instagram) ad
+// account with the Marketing API. The images and videos of their
+// object_story_spec and asset_feed_spec are resolved to urls, their bodies,
+// titles and descriptions are text assets and their links page assets.
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+	"go.uber.org/zap"
+)
+
+// batchSize is the number of ids read by a call
+const batchSize = 50
+
+type Config struct {
+	OnlyAdcreatives bool
+	// Endpoint is the url of the Graph API, DefaultEndpoint when empty
+	Endpoint string
+	// Version is the Graph API version, DefaultVersion when empty
+	Version string
+}
+
+// MetaAdcreatives lists the ad creatives of an ad account, the account id is
+// given with or without its act_ prefix
+type MetaAdcreatives struct {
+	AccountID      string
+	Config         Config
+	client         *Client
+	adcreativesFns []ads.AdcreativeMatchFunc
+	accountName    *string
+	log            *zap.SugaredLogger
+}
+
+func NewAdcreatives(accountId string, accessToken string, debug bool) (*MetaAdcreatives, error) {
+	accountId = strings.TrimPrefix(accountId, "act_")
+	if accountId == "" {
+		return nil, fmt.Errorf("meta: no ad account id")
+	}
+	m := &MetaAdcreatives{
+		AccountID: accountId,
+		client:    &Client{AccessToken: accessToken, Debug: debug},
+		log:       zap.S().With("provider", "META"),
+	}
+
+	m.SetAdcreativesFunc(ads.NotDeleted)
+
+	return m, nil
+}
+
+// creativeFields are the fields of listed ad creatives
+var creativeFields = strings.Join([]string{
+	"id", "name", "status", "title", "body", "image_hash", "video_id",
+	"thumbnail_url", "link_url",
+	"object_story_spec{link_data{link,message,name,description,image_hash,picture,call_to_action," +
+		"child_attachments{link,name,description,image_hash,picture,video_id}}," +
+		"video_data{video_id,image_url,title,message,link_description,call_to_action}}",
+	"asset_feed_spec{images{hash},videos{video_id,thumbnail_url},bodies{text},titles{text},descriptions{text},link_urls{website_url}}",
+}, ",")
+
+// ref is the ad creative of a material
+type ref struct {
+	CreativeID   string
+	CreativeName string
+	// Cover is the thumbnail url of a video
+	Cover string
+}
+
+// Assets lists the assets of the account's ad creatives: links, bodies,
+// titles, descriptions, images and videos
+func (m *MetaAdcreatives) Assets() (assets []*ads.Asset, err error) {
+	ctx := context.Background()
+
+	creatives, err := m.client.List(ctx, m.act("adcreatives"), url.Values{"fields": {creativeFields}})
+	if err != nil {
+		return nil, err
+	}
+	creatives = httpapi.Process(creatives, normalize, m.adcreativesFns)
+	httpapi.PrintJson(m.log, "adcreatives", creatives)
+
+	var (
+		hashes     = make(ads.Set[string])
+		videoIds   = make(ads.Set[string])
+		adsImages  = make(map[string]*ref)
+		adsVideos  = make(map[string]*ref)
+		pageIndexs = make(map[string]bool)
+	)
+
+	for _, creative := range creatives {
+		r := &ref{
+			CreativeID:   creative.Get("id").String(),
+			CreativeName: creative.Get("name").String(),
+		}
+		spec := specOf(creative)
+
+		for i, link := range spec.links {
+			if pageIndexs[link] {
+				continue
+			}
+			pageIndexs[link] = true
+			assets = append(assets, &ads.Asset{
+				AccountID:      m.AccountID,
+				Name:           r.CreativeName,
+				AssetID:        r.CreativeID + "_" + strconv.Itoa(i),
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: r.CreativeName,
+				PageType:       ads.PTPageUrl,
+				SubType:        "LINK",
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATPageUrl,
+						Url:  link,
+					},
+				},
+			})
+		}
+
+		for _, texts := range []struct {
+			subType string
+			texts   []string
+		}{
+			{"BODY", spec.bodies},
+			{"TITLE", spec.titles},
+			{"DESCRIPTION", spec.descriptions},
+		} {
+			if len(texts.texts) == 0 {
+				continue
+			}
+			assets = append(assets, &ads.Asset{
+				AccountID:      m.AccountID,
+				Name:           r.CreativeName,
+				AssetID:        r.CreativeID + "_" + texts.subType,
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: r.CreativeName,
+				PageType:       ads.PTText,
+				SubType:        texts.subType,
+				Texts:          texts.texts,
+			})
+		}
+
+		for _, hash := range spec.images {
+			hashes.Add(hash)
+			if _, ok := adsImages[hash]; !ok {
+				adsImages[hash] = r
+			}
+		}
+		for _, id := range spec.videos {
+			videoIds.Add(id)
+			if _, ok := adsVideos[id]; !ok {
+				adsVideos[id] = &ref{CreativeID: r.CreativeID, CreativeName: r.CreativeName, Cover: spec.covers[id]}
+			}
+		}
+	}
+
+	if len(hashes) > 0 {
+		images, err := m.images(ctx, hashes.Slice())
+		if err != nil {
+			return nil, err
+		}
+		httpapi.PrintJson(m.log, "images", images)
+
+		for _, image := range images {
+			r, ok := adsImages[image.Get("hash").String()]
+			if !ok {
+				r = &ref{}
+			}
+			assets = append(assets, &ads.Asset{
+				AccountID:      m.AccountID,
+				Name:           image.Get("name").String(),
+				AssetID:        image.Get("hash").String(),
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: r.CreativeName,
+				PageType:       ads.PTImage,
+				SubType:        "IMAGE",
+				Signature:      image.Get("hash").String(),
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATImage,
+						Url:  image.Get("url").String(),
+					},
+				},
+				Width:            httpapi.IntValue(image, "width"),
+				Height:           httpapi.IntValue(image, "height"),
+				CreatedTime:      parseTime(image, "created_time"),
+				LastModifiedTime: parseTime(image, "updated_time"),
+			})
+		}
+	}
+
+	if len(videoIds) > 0 {
+		videos, err := m.videos(ctx, videoIds.Slice())
+		if err != nil {
+			return nil, err
+		}
+		httpapi.PrintJson(m.log, "videos", videos)
+
+		for _, video := range videos {
+			r, ok := adsVideos[video.Get("id").String()]
+			if !ok {
+				r = &ref{}
+			}
+			cover := video.Get("picture").String()
+			if r.Cover != "" {
+				cover = r.Cover
+			}
+			assets = append(assets, &ads.Asset{
+				AccountID:      m.AccountID,
+				Name:           video.Get("title").String(),
+				AssetID:        video.Get("id").String(),
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: r.CreativeName,
+				PageType:       ads.PTVideo,
+				SubType:        "VIDEO",
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATImage,
+						Url:  cover,
+					},
+					{
+						Type: ads.SATVideo,
+						Url:  video.Get("source").String(),
+					},
+				},
+				CreatedTime:      parseTime(video, "created_time"),
+				LastModifiedTime: parseTime(video, "updated_time"),
+			})
+		}
+	}
+
+	name := m.AccountName()
+	for _, asset := range assets {
+		asset.AccountName = name
+	}
+	return assets, nil
+}
+
+// spec is the materials of an ad creative
+type spec struct {
+	links, bodies, titles, descriptions []string
+	images                              []string
+	// videos are the video ids, covers the thumbnail urls the creative sets
+	videos []string
+	covers map[string]string
+}
+
+// specOf collects the materials of the creative fields, its object story
+// spec and its asset feed spec
+func specOf(creative ads.Map) *spec {
+	s := &spec{covers: make(map[string]string)}
+	add := func(values *[]string, value string) {
+		if value != "" && !slices.Contains(*values, value) {
+			*values = append(*values, value)
+		}
+	}
+	addVideo := func(id, cover string) {
+		if id == "" {
+			return
+		}
+		add(&s.videos, id)
+		if cover != "" {
+			s.covers[id] = cover
+		}
+	}
+
+	add(&s.links, creative.Get("link_url").String())
+	add(&s.bodies, creative.Get("body").String())
+	add(&s.titles, creative.Get("title").String())
+	add(&s.images, creative.Get("image_hash").String())
+	addVideo(creative.Get("video_id").String(), creative.Get("thumbnail_url").String())
+
+	link := creative.Get("object_story_spec.link_data").ObjxMap()
+	add(&s.links, link.Get("link").String())
+	add(&s.links, link.Get("call_to_action.value.link").String())
+	add(&s.bodies, link.Get("message").String())
+	add(&s.titles, link.Get("name").String())
+	add(&s.descriptions, link.Get("description").String())
+	add(&s.images, link.Get("image_hash").String())
+	for _, child := range link.Get("child_attachments").ObjxMapSlice() {
+		add(&s.links, child.Get("link").String())
+		add(&s.titles, child.Get("name").String())
+		add(&s.descriptions, child.Get("description").String())
+		add(&s.images, child.Get("image_hash").String())
+		addVideo(child.Get("video_id").String(), child.Get("picture").String())
+	}
+
+	video := creative.Get("object_story_spec.video_data").ObjxMap()
+	add(&s.links, video.Get("call_to_action.value.link").String())
+	add(&s.bodies, video.Get("message").String())
+	add(&s.titles, video.Get("title").String())
+	add(&s.descriptions, video.Get("link_description").String())
+	addVideo(video.Get("video_id").String(), video.Get("image_url").String())
+
+	feed := creative.Get("asset_feed_spec").ObjxMap()
+	for _, image := range feed.Get("images").ObjxMapSlice() {
+		add(&s.images, image.Get("hash").String())
+	}
+	for _, v := range feed.Get("videos").ObjxMapSlice() {
+		addVideo(v.Get("video_id").String(), v.Get("thumbnail_url").String())
+	}
+	for _, body := range feed.Get("bodies").ObjxMapSlice() {
+		add(&s.bodies, body.Get("text").String())
+	}
+	for _, title := range feed.Get("titles").ObjxMapSlice() {
+		add(&s.titles, title.Get("text").String())
+	}
+	for _, description := range feed.Get("descriptions").ObjxMapSlice() {
+		add(&s.descriptions, description.Get("text").String())
+	}
+	for _, u := range feed.Get("link_urls").ObjxMapSlice() {
+		add(&s.links, u.Get("website_url").String())
+	}
+	return s
+}
+
+// images reads the images of the hashes, all images of the account unless
+// only the materials of creatives are listed
+func (m *MetaAdcreatives) images(ctx context.Context, hashes []string) ([]ads.Map, error) {
+	fields := "hash,name,url,width,height,created_time,updated_time"
+	if !m.Config.OnlyAdcreatives {
+		return m.client.List(ctx, m.act("adimages"), url.Values{"fields": {fields}})
+	}
+
+	var images []ads.Map
+	for _, batch := range httpapi.Batches(hashes, batchSize) {
+		b, err := json.Marshal(batch)
+		if err != nil {
+			return nil, err
+		}
+		objs, err := m.client.List(ctx, m.act("adimages"), url.Values{
+			"fields": {fields},
+			"hashes": {string(b)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, objs...)
+	}
+	return images, nil
+}
+
+// videos reads the videos of the ids, all videos of the account unless only
+// the materials of creatives are listed
+func (m *MetaAdcreatives) videos(ctx context.Context, ids []string) ([]ads.Map, error) {
+	fields := "id,title,source,picture,created_time,updated_time"
+	if !m.Config.OnlyAdcreatives {
+		return m.client.List(ctx, m.act("advideos"), url.Values{"fields": {fields}})
+	}
+
+	var videos []ads.Map
+	for _, batch := range httpapi.Batches(ids, batchSize) {
+		objs, err := m.client.Get(ctx, "", url.Values{
+			"ids":    {strings.Join(batch, ",")},
+			"fields": {fields},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// the nodes are keyed by id
+		for _, id := range batch {
+			if video := objs.Get(id).ObjxMap(); len(video) > 0 {
+				videos = append(videos, video)
+			}
+		}
+	}
+	return videos, nil
+}
+
+// AccountName returns the name of the ad account, empty when it cannot be
+// read
+func (m *MetaAdcreatives) AccountName() string {
+	if m.accountName != nil {
+		return *m.accountName
+	}
+
+	account, err := m.client.Get(context.Background(), m.act(""), url.Values{"fields": {"name"}})
+	if err != nil {
+		m.log.Warnw("get ad account error", "account", m.AccountID, "error", err)
+	}
+	name := account.Get("name").String()
+
+	m.accountName = &name
+	return name
+}
+
+// SetAdcreativesFunc ...
+func (m *MetaAdcreatives) SetAdcreativesFunc(match ads.AdcreativeMatchFunc) {
+	m.adcreativesFns = append(m.adcreativesFns, match)
+}
+
+// ClearAdcreativesFuncs ...
+func (m *MetaAdcreatives) ClearAdcreativesFuncs() {
+	m.adcreativesFns = nil
+}
+
+// OnlyAdcreatives ...
+func (m *MetaAdcreatives) OnlyAdcreatives(on bool) {
+	m.Config.OnlyAdcreatives = on
+}
+
+// Configure applies provider settings: endpoint replaces the url of the Graph
+// API and version pins its version, such as v19.0
+func (m *MetaAdcreatives) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		switch key {
+		case "endpoint":
+			m.Config.Endpoint, m.client.Endpoint = value, value
+		case "version":
+			if value != "" && !strings.HasPrefix(value, "v") {
+				return fmt.Errorf("meta: invalid Graph API version %q", value)
+			}
+			m.Config.Version, m.client.Version = value, value
+		default:
+			return fmt.Errorf("meta: unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// normalize sets the is_deleted and configured_status fields of GDT
+// adcreatives from an ad creative
+func normalize(creative ads.Map) {
+	status := creative.Get("status").String()
+	creative.Set("is_deleted", status == "DELETED")
+	if status == "ACTIVE" {
+		creative.Set("configured_status", "AD_STATUS_NORMAL")
+	} else if status != "" {
+		creative.Set("configured_status", status)
+	}
+}
+
+// act returns the path of an edge of the ad account
+func (m *MetaAdcreatives) act(edge string) string {
+	if edge == "" {
+		return "act_" + m.AccountID
+	}
+	return "act_" + m.AccountID + "/" + edge
+}
+
+var (
+	_ ads.GetAdcreatives = (*MetaAdcreatives)(nil)
+	_ ads.Configurable   = (*MetaAdcreatives)(nil)
+)
+
+func init() {
+	ads.RegisterProvider("META", func(accountId string, accessToken string, debug bool) (ads.GetAdcreatives, error) {
+		return NewAdcreatives(accountId, accessToken, debug)
+	})
+	ads.DescribeProvider(ads.ProviderInfo{
+		Name:         "META",
+		Description:  "Meta Marketing API ad creatives",
+		Capabilities: []ads.Capability{ads.CapOnlyAdcreatives, ads.CapDownload},
+		PageTypes:    []ads.PageType{ads.PTPageUrl, ads.PTVideo, ads.PTImage, ads.PTText},
+		Credentials:  []string{"access_token"},
+		Settings: []ads.Setting{
+			{Name: "endpoint", Description: "base url of the API", Default: DefaultEndpoint},
+			{Name: "version", Description: "Graph API version", Default: DefaultVersion},
+		},
+	})
+}
+
+// parseTime reads a time of the Graph API
+func parseTime(obj ads.Map, key string) time.Time {
+	return httpapi.ParseTime(obj, key, "2006-01-02T15:04:05-0700", time.UTC)
+}
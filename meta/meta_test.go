@@ -0,0 +1,119 @@
+package meta
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/apitest"
+)
+
+// server serves the responses of the Graph API paths for the queries
+func server(t *testing.T, routes map[string]apitest.Route) *httptest.Server {
+	return apitest.Server(t, apitest.Config{
+		Auth: func(r *http.Request) bool { return r.URL.Query().Get("access_token") == "token" },
+	}, routes)
+}
+
+// page is a page of an edge, next is the after cursor of the next page
+func page(data []apitest.Object, next string) apitest.Object {
+	p := apitest.Object{"data": data}
+	if next != "" {
+		p["paging"] = apitest.Object{"cursors": apitest.Object{"after": next}, "next": "https://graph.facebook.com/next"}
+	}
+	return p
+}
+
+func open(t *testing.T, srv *httptest.Server) *MetaAdcreatives {
+	return apitest.Open(t, NewAdcreatives, "act_1001", srv, nil)
+}
+
+func TestAssets(t *testing.T) {
+	var afters []string
+	srv := server(t, map[string]apitest.Route{
+		"/v19.0/act_1001/adcreatives": func(r *apitest.Request) interface{} {
+			afters = append(afters, r.Query.Get("after"))
+			if r.Query.Get("after") == "" {
+				return page([]apitest.Object{{
+					"id": "21", "name": "link ad", "status": "ACTIVE",
+					"object_story_spec": apitest.Object{"link_data": apitest.Object{
+						"link": "https://example.com/a", "message": "body a", "name": "title a", "description": "description a", "image_hash": "h1",
+						"call_to_action": apitest.Object{"value": apitest.Object{"link": "https://example.com/cta"}},
+					}},
+				}}, "c1")
+			}
+			return page([]apitest.Object{
+				{
+					"id": "22", "name": "video ad", "status": "PAUSED",
+					"object_story_spec": apitest.Object{"video_data": apitest.Object{"video_id": "v1", "image_url": "https://example.com/thumb.jpg", "title": "title b", "message": "body b"}},
+				},
+				{"id": "23", "name": "deleted", "status": "DELETED", "body": "gone", "link_url": "https://example.com/gone"},
+			}, "")
+		},
+		"/v19.0/act_1001/adimages": func(r *apitest.Request) interface{} {
+			return page([]apitest.Object{{"hash": "h1", "name": "a.jpg", "url": "https://example.com/a.jpg", "width": 1080, "height": 1080, "created_time": "2024-03-01T10:00:00+0000"}}, "")
+		},
+		"/v19.0/act_1001/advideos": func(r *apitest.Request) interface{} {
+			return page([]apitest.Object{{"id": "v1", "title": "clip", "source": "https://example.com/v1.mp4", "picture": "https://example.com/v1.jpg"}}, "")
+		},
+		"/v19.0/act_1001": func(r *apitest.Request) interface{} {
+			return apitest.Object{"id": "act_1001", "name": "ad account"}
+		},
+	})
+
+	assets, err := open(t, srv).Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(afters) != 2 || afters[0] != "" || afters[1] != "c1" {
+		t.Errorf("adcreative cursors %v", afters)
+	}
+
+	got := apitest.Index(t, assets, "1001", "ad account")
+	for _, a := range assets {
+		if a.AdcreativeID == "23" {
+			t.Errorf("asset %v of a deleted ad creative", ads.KeyOf(a))
+		}
+	}
+	if got.Len() != 9 {
+		t.Errorf("listed %d assets, want 9", got.Len())
+	}
+
+	for id, link := range map[string]string{"21_0": "https://example.com/a", "21_1": "https://example.com/cta"} {
+		if a := got.Get(id, ads.PTPageUrl); a.PrimaryUrl() != link {
+			t.Errorf("link %s %+v", id, a)
+		}
+	}
+	for id, text := range map[string]string{"21_BODY": "body a", "21_TITLE": "title a", "21_DESCRIPTION": "description a", "22_BODY": "body b", "22_TITLE": "title b"} {
+		if a := got.Get(id, ads.PTText); len(a.Texts) != 1 || a.Texts[0] != text {
+			t.Errorf("text %s %+v", id, a)
+		}
+	}
+	if a := got.Get("h1", ads.PTImage); a.PrimaryUrl() != "https://example.com/a.jpg" || a.Width != 1080 || a.AdcreativeID != "21" || a.CreatedTime.IsZero() {
+		t.Errorf("image %+v", a)
+	}
+	if a := got.Get("v1", ads.PTVideo); a.ImageUrl() != "https://example.com/thumb.jpg" || a.PrimaryUrl() != "https://example.com/v1.mp4" || a.AdcreativeID != "22" {
+		t.Errorf("video %+v", a)
+	}
+}
+
+func TestError(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/v19.0/act_1001/adcreatives": func(*apitest.Request) interface{} {
+			return apitest.Status{Code: http.StatusBadRequest, Body: apitest.Object{"error": apitest.Object{
+				"message": "Invalid OAuth access token.", "type": "OAuthException", "code": 190, "error_subcode": 463, "fbtrace_id": "trace",
+			}}}
+		},
+	})
+
+	_, err := open(t, srv).Assets()
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v is not an API error", err)
+	}
+	if apiErr.Code != 190 || apiErr.Subcode != 463 || apiErr.Type != "OAuthException" || apiErr.FBTraceID != "trace" {
+		t.Errorf("error %+v", apiErr)
+	}
+}
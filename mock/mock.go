@@ -0,0 +1,465 @@
+// Package mock generates reproducible synthetic assets for load and UI
+// testing, without calling any ad network. The same seed and settings give
+// the same accounts, adcreatives and assets, each account is generated on its
+// own so the order accounts are fetched in does not matter. Failures, slow
+// pages and truncated pages can be injected to exercise the callers' error
+// handling.
+package mock
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/stretchr/objx"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	OnlyAdcreatives bool
+	// Seed makes the generated data reproducible
+	Seed int64
+	// Accounts is the number of accounts listed by Accounts
+	Accounts int
+	// Creatives is the number of adcreatives of every account
+	Creatives int
+	// AssetsPerCreative is the maximum number of assets of an adcreative
+	AssetsPerCreative int
+	// Mix weights the page types of the generated assets
+	Mix map[ads.PageType]int
+	// Reuse is the ratio of assets reusing a material of an earlier
+	// adcreative of the account
+	Reuse float64
+	// Texts is the number of text components of a text asset
+	Texts int
+	// Deleted and Suspended are the ratios of deleted and suspended
+	// adcreatives
+	Deleted   float64
+	Suspended float64
+	// PageSize is the number of adcreatives of a page
+	PageSize int
+	// Latency is the time spent on every page
+	Latency time.Duration
+	// TimeoutRate is the ratio of pages failing with a timeout, and
+	// PartialRate of pages missing adcreatives
+	TimeoutRate float64
+	PartialRate float64
+}
+
+// DefaultConfig generates 3 accounts of 100 adcreatives
+var DefaultConfig = Config{
+	Seed:              1,
+	Accounts:          3,
+	Creatives:         100,
+	AssetsPerCreative: 4,
+	Mix: map[ads.PageType]int{
+		ads.PTImage:   5,
+		ads.PTVideo:   2,
+		ads.PTText:    2,
+		ads.PTPageUrl: 1,
+	},
+	Reuse:     0.2,
+	Texts:     2,
+	Deleted:   0.05,
+	Suspended: 0.2,
+	PageSize:  100,
+}
+
+// epoch is the earliest creation time of the adcreatives
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type MockAdcreatives struct {
+	AccountID      string
+	Config         Config
+	adcreativesFns []ads.AdcreativeMatchFunc
+	log            *zap.SugaredLogger
+}
+
+func NewAdcreatives(accountId string) *MockAdcreatives {
+	m := &MockAdcreatives{
+		AccountID: accountId,
+		Config:    DefaultConfig,
+		log:       zap.S().With("provider", "MOCK"),
+	}
+
+	m.SetAdcreativesFunc(ads.NotDeleted)
+
+	return m
+}
+
+// Assets generates the assets of the account page by page, an injected
+// timeout fails the whole call and a partial page drops its last
+// adcreatives
+func (m *MockAdcreatives) Assets() (assets []*ads.Asset, err error) {
+	var (
+		conf  = m.Config
+		gen   = m.newGenerator()
+		fail  = rand.New(rand.NewSource(m.seed("fail")))
+		match = ads.And(m.adcreativesFns...)
+	)
+
+	pageSize := max(conf.PageSize, 1)
+	for page, start := 1, 0; start < conf.Creatives; page, start = page+1, start+pageSize {
+		end := min(start+pageSize, conf.Creatives)
+
+		if conf.Latency > 0 {
+			time.Sleep(conf.Latency)
+		}
+		if fail.Float64() < conf.TimeoutRate {
+			return nil, fmt.Errorf("mock: account %s page %d: %w", m.AccountID, page, context.DeadlineExceeded)
+		}
+		// the creatives of a page are generated even when dropped, the next
+		// pages stay the same
+		creatives := make([]*creative, 0, end-start)
+		for i := start; i < end; i++ {
+			creatives = append(creatives, gen.creative(i))
+		}
+		if fail.Float64() < conf.PartialRate {
+			n := fail.Intn(len(creatives))
+			m.log.Warnw("partial page", "account", m.AccountID, "page", page, "creatives", n, "page_size", len(creatives))
+			creatives = creatives[:n]
+		}
+
+		for _, c := range creatives {
+			if match(c.adcreative) {
+				assets = append(assets, c.assets...)
+			}
+		}
+	}
+
+	if !conf.OnlyAdcreatives {
+		assets = append(assets, gen.library()...)
+	}
+	return assets, nil
+}
+
+// Accounts lists the generated accounts, whatever account the provider was
+// opened with
+func (m *MockAdcreatives) Accounts() ([]*ads.Account, error) {
+	r := rand.New(rand.NewSource(m.Config.Seed))
+	accounts := make([]*ads.Account, 0, m.Config.Accounts)
+	for i := 0; i < m.Config.Accounts; i++ {
+		id := strconv.Itoa(10000 + i + 1)
+		accounts = append(accounts, &ads.Account{
+			ID:      id,
+			Name:    accountName(id),
+			Status:  "ACCOUNT_STATUS_NORMAL",
+			Balance: r.Int63n(100000000),
+		})
+	}
+	return accounts, nil
+}
+
+// seed derives the seed of a random source of the account
+func (m *MockAdcreatives) seed(salt string) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s/%s", m.Config.Seed, m.AccountID, salt)
+	return int64(h.Sum64())
+}
+
+// SetAdcreativesFunc ...
+func (m *MockAdcreatives) SetAdcreativesFunc(match ads.AdcreativeMatchFunc) {
+	m.adcreativesFns = append(m.adcreativesFns, match)
+}
+
+// ClearAdcreativesFuncs ...
+func (m *MockAdcreatives) ClearAdcreativesFuncs() {
+	m.adcreativesFns = nil
+}
+
+// OnlyAdcreatives skips the library materials of no adcreative
+func (m *MockAdcreatives) OnlyAdcreatives(on bool) {
+	m.Config.OnlyAdcreatives = on
+}
+
+// Configure applies provider settings: seed, accounts, creatives,
+// assets_per_creative, texts and page_size are integers, reuse, deleted,
+// suspended, timeout_rate and partial_rate ratios between 0 and 1, latency a
+// duration and mix a comma separated list of page type weights such as
+// image=5,video=2,text=2,page=1
+func (m *MockAdcreatives) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		var err error
+		switch key {
+		case "seed":
+			m.Config.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "accounts":
+			m.Config.Accounts, err = strconv.Atoi(value)
+		case "creatives":
+			m.Config.Creatives, err = strconv.Atoi(value)
+		case "assets_per_creative":
+			m.Config.AssetsPerCreative, err = strconv.Atoi(value)
+		case "texts":
+			m.Config.Texts, err = strconv.Atoi(value)
+		case "page_size":
+			m.Config.PageSize, err = strconv.Atoi(value)
+		case "reuse":
+			m.Config.Reuse, err = ratio(value)
+		case "deleted":
+			m.Config.Deleted, err = ratio(value)
+		case "suspended":
+			m.Config.Suspended, err = ratio(value)
+		case "timeout_rate":
+			m.Config.TimeoutRate, err = ratio(value)
+		case "partial_rate":
+			m.Config.PartialRate, err = ratio(value)
+		case "latency":
+			m.Config.Latency, err = time.ParseDuration(value)
+		case "mix":
+			m.Config.Mix, err = parseMix(value)
+		default:
+			return fmt.Errorf("mock: unknown setting %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("mock: setting %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func ratio(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f < 0 || f > 1 {
+		return 0, fmt.Errorf("ratio %s not between 0 and 1", s)
+	}
+	return f, nil
+}
+
+// pageTypeNames are the names of page types in the mix setting, as in asset
+// filters
+var pageTypeNames = map[string]ads.PageType{
+	"page":  ads.PTPageUrl,
+	"video": ads.PTVideo,
+	"image": ads.PTImage,
+	"text":  ads.PTText,
+}
+
+func parseMix(s string) (map[ads.PageType]int, error) {
+	mix := make(map[ads.PageType]int)
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q, want type=weight", part)
+		}
+		pt, ok := pageTypeNames[strings.ToLower(name)]
+		if !ok {
+			var err error
+			if pt, err = ads.ParsePageType(name); err != nil {
+				return nil, err
+			}
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight %q", part)
+		}
+		mix[pt] = w
+	}
+	return mix, nil
+}
+
+func accountName(id string) string {
+	return "Mock account " + id
+}
+
+var (
+	_ ads.GetAdcreatives = (*MockAdcreatives)(nil)
+	_ ads.AccountLister  = (*MockAdcreatives)(nil)
+	_ ads.Configurable   = (*MockAdcreatives)(nil)
+)
+
+func init() {
+	ads.RegisterProvider("MOCK", func(accountId string, _ string, _ bool) (ads.GetAdcreatives, error) {
+		return NewAdcreatives(accountId), nil
+	})
+	ads.DescribeProvider(ads.ProviderInfo{
+		Name:         "MOCK",
+		Description:  "synthetic assets for load and UI testing",
+		Capabilities: []ads.Capability{ads.CapAccounts, ads.CapOnlyAdcreatives},
+		PageTypes:    []ads.PageType{ads.PTPageUrl, ads.PTVideo, ads.PTImage, ads.PTText},
+		Settings: []ads.Setting{
+			{Name: "seed", Description: "seed of the generated data", Default: "1"},
+			{Name: "accounts", Description: "number of accounts listed", Default: "3"},
+			{Name: "creatives", Description: "adcreatives of an account", Default: "100"},
+			{Name: "assets_per_creative", Description: "maximum assets of an adcreative", Default: "4"},
+			{Name: "mix", Description: "page type weights", Default: "image=5,video=2,text=2,page=1"},
+			{Name: "reuse", Description: "ratio of reused materials", Default: "0.2"},
+			{Name: "texts", Description: "text components of a text asset", Default: "2"},
+			{Name: "deleted", Description: "ratio of deleted adcreatives", Default: "0.05"},
+			{Name: "suspended", Description: "ratio of suspended adcreatives", Default: "0.2"},
+			{Name: "page_size", Description: "adcreatives of a page", Default: "100"},
+			{Name: "latency", Description: "time spent on every page"},
+			{Name: "timeout_rate", Description: "ratio of pages failing with a timeout"},
+			{Name: "partial_rate", Description: "ratio of pages missing adcreatives"},
+		},
+	})
+}
+
+// creative is a generated adcreative with its assets
+type creative struct {
+	adcreative ads.Map
+	assets     []*ads.Asset
+}
+
+// generator generates the adcreatives of an account in order, reused assets
+// are picked among the materials generated before
+type generator struct {
+	m     *MockAdcreatives
+	r     *rand.Rand
+	types []ads.PageType
+	pool  map[ads.PageType][]*ads.Asset
+	seq   int
+}
+
+func (m *MockAdcreatives) newGenerator() *generator {
+	g := &generator{
+		m:    m,
+		r:    rand.New(rand.NewSource(m.seed("data"))),
+		pool: make(map[ads.PageType][]*ads.Asset),
+	}
+	for pt := ads.PTPageUrl; pt <= ads.PTText; pt++ {
+		for i := 0; i < m.Config.Mix[pt]; i++ {
+			g.types = append(g.types, pt)
+		}
+	}
+	return g
+}
+
+// creative generates the i-th adcreative, the adcreatives must be generated
+// in order
+func (g *generator) creative(i int) *creative {
+	var (
+		conf     = g.m.Config
+		id       = fmt.Sprintf("%s%06d", g.m.AccountID, i+1)
+		name     = fmt.Sprintf("%s %d", words[g.r.Intn(len(words))], i+1)
+		created  = epoch.Add(time.Duration(g.r.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+		modified = created.Add(time.Duration(g.r.Int63n(int64(30 * 24 * time.Hour)))).Truncate(time.Second)
+		status   = "AD_STATUS_NORMAL"
+		deleted  = g.r.Float64() < conf.Deleted
+	)
+	if g.r.Float64() < conf.Suspended {
+		status = "AD_STATUS_SUSPEND"
+	}
+
+	c := &creative{
+		adcreative: objx.New(map[string]interface{}{
+			"adcreative_id":      id,
+			"adcreative_name":    name,
+			"account_id":         g.m.AccountID,
+			"configured_status":  status,
+			"is_deleted":         deleted,
+			"created_time":       created.Unix(),
+			"last_modified_time": modified.Unix(),
+		}),
+	}
+	if len(g.types) == 0 {
+		return c
+	}
+
+	n := 1 + g.r.Intn(max(conf.AssetsPerCreative, 1))
+	for j := 0; j < n; j++ {
+		pt := g.types[g.r.Intn(len(g.types))]
+
+		var asset *ads.Asset
+		if pool := g.pool[pt]; len(pool) > 0 && g.r.Float64() < conf.Reuse {
+			reused := *pool[g.r.Intn(len(pool))]
+			asset = &reused
+		} else {
+			asset = g.material(pt, created)
+			g.pool[pt] = append(g.pool[pt], asset)
+			copied := *asset
+			asset = &copied
+		}
+		asset.AdcreativeID = id
+		asset.AdcreativeName = name
+		asset.Name = name
+		asset.LastModifiedTime = modified
+		c.assets = append(c.assets, asset)
+	}
+	return c
+}
+
+// library generates the materials of no adcreative, a tenth of the
+// adcreatives
+func (g *generator) library() (assets []*ads.Asset) {
+	created := epoch
+	for i := 0; i < g.m.Config.Creatives/10; i++ {
+		pt := ads.PTImage
+		if g.r.Intn(3) == 0 {
+			pt = ads.PTVideo
+		}
+		asset := g.material(pt, created)
+		asset.Name = fmt.Sprintf("library %d", i+1)
+		assets = append(assets, asset)
+	}
+	return assets
+}
+
+// sizes are the sizes of the generated images and videos
+var sizes = [][2]int{{1080, 1920}, {1280, 720}, {800, 800}, {1200, 628}, {720, 1280}}
+
+// material generates a new asset of the page type
+func (g *generator) material(pt ads.PageType, created time.Time) *ads.Asset {
+	g.seq++
+	var (
+		account = g.m.AccountID
+		id      = fmt.Sprintf("%s%07d", account, g.seq)
+		url     = fmt.Sprintf("https://mock.example.com/%s/%s", account, id)
+		size    = sizes[g.r.Intn(len(sizes))]
+	)
+	asset := &ads.Asset{
+		AccountID:   account,
+		AccountName: accountName(account),
+		AssetID:     id,
+		PageType:    pt,
+		CreatedTime: created,
+	}
+
+	switch pt {
+	case ads.PTImage:
+		asset.SubType = "IMAGE"
+		asset.SubAssets = []*ads.SubAsset{{Type: ads.SATImage, Url: url + ".jpg"}}
+		asset.Width, asset.Height = size[0], size[1]
+		asset.Signature = fmt.Sprintf("%016x%016x", g.r.Uint64(), g.r.Uint64())
+	case ads.PTVideo:
+		asset.SubType = "VIDEO"
+		asset.SubAssets = []*ads.SubAsset{
+			{Type: ads.SATImage, Url: url + ".jpg"},
+			{Type: ads.SATVideo, Url: url + ".mp4"},
+		}
+		asset.Width, asset.Height = size[0], size[1]
+		asset.Signature = fmt.Sprintf("%016x%016x", g.r.Uint64(), g.r.Uint64())
+	case ads.PTText:
+		asset.SubType = "TITLE"
+		for i := 0; i < max(g.m.Config.Texts, 1); i++ {
+			asset.Texts = append(asset.Texts, g.sentence())
+		}
+	case ads.PTPageUrl:
+		asset.SubType = "LANDING_PAGE"
+		asset.SubAssets = []*ads.SubAsset{{Type: ads.SATPageUrl, Url: url + ".html"}}
+	}
+	return asset
+}
+
+// words make up the generated names and texts
+var words = []string{
+	"summer", "sale", "new", "free", "shipping", "limited", "offer", "today",
+	"best", "price", "quality", "fresh", "style", "download", "play", "now",
+	"discover", "exclusive", "deal", "save",
+}
+
+func (g *generator) sentence() string {
+	n := 3 + g.r.Intn(6)
+	s := make([]string, n)
+	for i := range s {
+		s[i] = words[g.r.Intn(len(words))]
+	}
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,212 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hnhuaxi/ads"
+)
+
+// open opens an account with the default config and settings, all the
+// adcreatives are kept
+func open(t *testing.T, account string, settings map[string]string) *MockAdcreatives {
+	t.Helper()
+
+	m := NewAdcreatives(account)
+	m.ClearAdcreativesFuncs()
+	if err := m.Configure(settings); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func assets(t *testing.T, m *MockAdcreatives) []*ads.Asset {
+	t.Helper()
+
+	assets, err := m.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return assets
+}
+
+func TestSeed(t *testing.T) {
+	a := assets(t, open(t, "1", map[string]string{"seed": "7"}))
+	if len(a) == 0 {
+		t.Fatal("no assets")
+	}
+
+	// another account generated before does not change the account
+	assets(t, open(t, "2", map[string]string{"seed": "7"}))
+	if b := assets(t, open(t, "1", map[string]string{"seed": "7"})); !reflect.DeepEqual(a, b) {
+		t.Error("same seed generated different assets")
+	}
+
+	if b := assets(t, open(t, "1", map[string]string{"seed": "8"})); reflect.DeepEqual(a, b) {
+		t.Error("another seed generated the same assets")
+	}
+	for _, asset := range assets(t, open(t, "2", map[string]string{"seed": "7"})) {
+		if asset.AccountID != "2" || !strings.HasPrefix(asset.AssetID, "2") || asset.AccountName != "Mock account 2" {
+			t.Fatalf("asset %v of account 2", ads.KeyOf(asset))
+		}
+	}
+
+	m := open(t, "1", map[string]string{"accounts": "5"})
+	accounts, _ := m.Accounts()
+	again, _ := open(t, "9", map[string]string{"accounts": "5"}).Accounts()
+	if len(accounts) != 5 || !reflect.DeepEqual(accounts, again) || accounts[0].ID != "10001" {
+		t.Errorf("accounts %+v", accounts)
+	}
+}
+
+func TestMix(t *testing.T) {
+	for _, tt := range []struct {
+		mix  string
+		want map[ads.PageType]float64
+	}{
+		{mix: "image=1", want: map[ads.PageType]float64{ads.PTImage: 1}},
+		{mix: "image=3,text=1", want: map[ads.PageType]float64{ads.PTImage: 0.75, ads.PTText: 0.25}},
+		{mix: "video=1,PTPageUrl=1,image=0", want: map[ads.PageType]float64{ads.PTVideo: 0.5, ads.PTPageUrl: 0.5}},
+	} {
+		m := open(t, "1", map[string]string{"mix": tt.mix, "creatives": "1000", "reuse": "0"})
+		m.OnlyAdcreatives(true)
+		all := assets(t, m)
+
+		counts := make(map[ads.PageType]int)
+		for _, asset := range all {
+			counts[asset.PageType]++
+		}
+		for pt, n := range counts {
+			if got := float64(n) / float64(len(all)); math.Abs(got-tt.want[pt]) > 0.05 {
+				t.Errorf("mix %s: %s ratio %.2f, want %.2f", tt.mix, pt, got, tt.want[pt])
+			}
+		}
+	}
+}
+
+func TestReuse(t *testing.T) {
+	for _, reuse := range []float64{0, 0.2, 0.6} {
+		m := open(t, "1", map[string]string{"reuse": strconv.FormatFloat(reuse, 'f', -1, 64), "creatives": "1000"})
+		m.OnlyAdcreatives(true)
+		all := assets(t, m)
+
+		var reused int
+		seen := make(map[ads.AssetKey]bool)
+		for _, asset := range all {
+			if key := ads.KeyOf(asset); seen[key] {
+				reused++
+			} else {
+				seen[key] = true
+			}
+		}
+		if got := float64(reused) / float64(len(all)); math.Abs(got-reuse) > 0.05 {
+			t.Errorf("reuse %.1f: %.2f of %d assets reused", reuse, got, len(all))
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	m := open(t, "1", map[string]string{"deleted": "0", "suspended": "1"})
+	m.OnlyAdcreatives(true)
+	m.SetAdcreativesFunc(ads.ActiveOnly)
+	if all := assets(t, m); len(all) != 0 {
+		t.Errorf("%d assets of suspended adcreatives", len(all))
+	}
+
+	m = NewAdcreatives("1")
+	if err := m.Configure(map[string]string{"deleted": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, asset := range assets(t, m) {
+		if asset.AdcreativeID != "" {
+			t.Fatalf("asset %v of a deleted adcreative", ads.KeyOf(asset))
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	m := open(t, "1", map[string]string{"timeout_rate": "1"})
+	all, err := m.Assets()
+	if !errors.Is(err, context.DeadlineExceeded) || all != nil {
+		t.Errorf("assets %d, error %v", len(all), err)
+	}
+}
+
+func TestPartialPages(t *testing.T) {
+	settings := map[string]string{"creatives": "200", "page_size": "20"}
+	full := assets(t, open(t, "1", settings))
+	settings["partial_rate"] = "1"
+	partial := assets(t, open(t, "1", settings))
+
+	// the adcreatives kept of a page are its first ones
+	perPage := make(map[int][]int)
+	for _, asset := range partial {
+		if asset.AdcreativeID == "" {
+			continue
+		}
+		i, err := strconv.Atoi(strings.TrimPrefix(asset.AdcreativeID, "1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := perPage[(i-1)/20]; len(ids) == 0 || ids[len(ids)-1] != i {
+			perPage[(i-1)/20] = append(ids, i)
+		}
+	}
+	for page, ids := range perPage {
+		if len(ids) >= 20 {
+			t.Errorf("page %d complete", page)
+		}
+		for j, id := range ids {
+			if id != page*20+j+1 {
+				t.Errorf("page %d adcreatives %v", page, ids)
+				break
+			}
+		}
+	}
+
+	// the adcreatives kept are generated as without failures
+	byKey := make(map[string]*ads.Asset)
+	for _, asset := range full {
+		byKey[asset.AdcreativeID+"/"+ads.KeyOf(asset).String()] = asset
+	}
+	for _, asset := range partial {
+		if want := byKey[asset.AdcreativeID+"/"+ads.KeyOf(asset).String()]; !reflect.DeepEqual(asset, want) {
+			t.Fatalf("asset %v of a partial page differs", ads.KeyOf(asset))
+		}
+	}
+	if len(partial) >= len(full) {
+		t.Errorf("partial pages listed %d assets of %d", len(partial), len(full))
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	m := NewAdcreatives("1")
+	if err := m.Configure(map[string]string{
+		"seed": "3", "creatives": "10", "page_size": "5", "latency": "1ms", "mix": "image=2, text=1", "reuse": "0.5",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c := m.Config
+	if c.Seed != 3 || c.Creatives != 10 || c.PageSize != 5 || c.Latency.Milliseconds() != 1 || c.Reuse != 0.5 ||
+		!reflect.DeepEqual(c.Mix, map[ads.PageType]int{ads.PTImage: 2, ads.PTText: 1}) {
+		t.Errorf("config %+v", c)
+	}
+
+	for key, value := range map[string]string{
+		"seed": "x", "reuse": "1.5", "deleted": "-0.1", "mix": "image", "latency": "soon", "size": "1",
+	} {
+		if err := m.Configure(map[string]string{key: value}); err == nil {
+			t.Errorf("setting %s=%s accepted", key, value)
+		}
+	}
+	for _, mix := range []string{"image", "image=x", "image=-1", "banner=1"} {
+		if _, err := parseMix(mix); err == nil {
+			t.Errorf("mix %s parsed", mix)
+		}
+	}
+}
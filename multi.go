@@ -0,0 +1,185 @@
+package ads
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// MultiSource is a provider instance of a Multi
+type MultiSource struct {
+	// Provider is the name tagged on the assets without a provider
+	Provider  string
+	AccountID string
+	GetAdcreatives
+}
+
+// ProviderError is the error of a source of a Multi
+type ProviderError struct {
+	Provider  string
+	AccountID string
+	Err       error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s account %s: %v", e.Provider, e.AccountID, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError lists the sources of a Multi that failed, the assets of the
+// others are still returned
+type MultiError struct {
+	Errors []*ProviderError
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d of the providers failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Multi lists the assets of several provider instances concurrently, for an
+// inventory across ad networks. The match funcs and OnlyAdcreatives are
+// passed to every source.
+type Multi struct {
+	// Concurrency is the number of sources listed at once, all when 0
+	Concurrency int
+	sources     []*MultiSource
+}
+
+// NewMulti creates a Multi of the sources
+func NewMulti(sources ...*MultiSource) *Multi {
+	return &Multi{sources: sources}
+}
+
+// Add adds a provider instance, its assets are tagged with provider
+func (m *Multi) Add(provider, accountId string, get GetAdcreatives) {
+	m.sources = append(m.sources, &MultiSource{Provider: provider, AccountID: accountId, GetAdcreatives: get})
+}
+
+// Open opens a registered provider and adds it
+func (m *Multi) Open(provider, accountId, accessToken string, debug bool) (GetAdcreatives, error) {
+	get, err := Open(provider, accountId, accessToken, debug)
+	if err != nil {
+		return nil, err
+	}
+	m.Add(provider, accountId, get)
+	return get, nil
+}
+
+// Sources returns the provider instances in the order they were added
+func (m *Multi) Sources() []*MultiSource {
+	return m.sources
+}
+
+// Assets lists the assets of every source and merges them in the order the
+// sources were added. A failing or panicking source does not stop the
+// others, its error is returned in a *MultiError along with the assets of the
+// others.
+func (m *Multi) Assets() ([]*Asset, error) {
+	var (
+		wg      sync.WaitGroup
+		results = make([][]*Asset, len(m.sources))
+		errs    = make([]error, len(m.sources))
+		n       = m.Concurrency
+	)
+	if n <= 0 {
+		n = max(len(m.sources), 1)
+	}
+
+	sem := make(chan struct{}, n)
+	for i, source := range m.sources {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, source *MultiSource) {
+			defer func() {
+				// a panicking provider fails its source, not the process
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("panic: %v", r)
+					zap.S().Errorw("provider panic", "provider", source.Provider, "account", source.AccountID, "panic", r, "stack", string(debug.Stack()))
+				}
+				<-sem
+				wg.Done()
+			}()
+
+			assets, err := source.Assets()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			for _, asset := range assets {
+				if asset.Provider == "" {
+					asset.Provider = source.Provider
+				}
+			}
+			results[i] = assets
+		}(i, source)
+	}
+	wg.Wait()
+
+	var (
+		all    []*Asset
+		failed []*ProviderError
+	)
+	for i, source := range m.sources {
+		if errs[i] != nil {
+			failed = append(failed, &ProviderError{Provider: source.Provider, AccountID: source.AccountID, Err: errs[i]})
+			continue
+		}
+		all = append(all, results[i]...)
+	}
+	if len(failed) > 0 {
+		return all, &MultiError{Errors: failed}
+	}
+	return all, nil
+}
+
+// SetAdcreativesFunc adds the func to every source
+func (m *Multi) SetAdcreativesFunc(match AdcreativeMatchFunc) {
+	for _, source := range m.sources {
+		source.SetAdcreativesFunc(match)
+	}
+}
+
+// ClearAdcreativesFuncs clears the funcs of every source
+func (m *Multi) ClearAdcreativesFuncs() {
+	for _, source := range m.sources {
+		source.ClearAdcreativesFuncs()
+	}
+}
+
+// OnlyAdcreatives switches every source
+func (m *Multi) OnlyAdcreatives(on bool) {
+	for _, source := range m.sources {
+		source.OnlyAdcreatives(on)
+	}
+}
+
+// ProviderErrors returns the per source errors of an error returned by
+// Assets
+func ProviderErrors(err error) []*ProviderError {
+	var merr *MultiError
+	if errors.As(err, &merr) {
+		return merr.Errors
+	}
+	return nil
+}
+
+var _ GetAdcreatives = (*Multi)(nil)
@@ -0,0 +1,128 @@
+package ads
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// source is a GetAdcreatives listing fixed assets
+type source struct {
+	assets []*Asset
+	err    error
+	panic  bool
+	delay  time.Duration
+	fns    int
+	only   bool
+}
+
+func (s *source) Assets() ([]*Asset, error) {
+	time.Sleep(s.delay)
+	if s.panic {
+		panic("boom")
+	}
+	return s.assets, s.err
+}
+
+func (s *source) SetAdcreativesFunc(AdcreativeMatchFunc) { s.fns++ }
+func (s *source) ClearAdcreativesFuncs()                 { s.fns = 0 }
+func (s *source) OnlyAdcreatives(on bool)                { s.only = on }
+
+func assetIDs(assets []*Asset) string {
+	ids := make([]string, 0, len(assets))
+	for _, a := range assets {
+		ids = append(ids, a.Provider+":"+a.AssetID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestMultiOrder(t *testing.T) {
+	m := NewMulti()
+	// the first source finishes last, the assets keep the order of the sources
+	m.Add("GDT", "1", &source{delay: 20 * time.Millisecond, assets: []*Asset{{AssetID: "a"}, {AssetID: "b"}}})
+	m.Add("META", "2", &source{assets: []*Asset{{AssetID: "c"}, {AssetID: "d", Provider: "FILE"}}})
+	m.Add("KUAISHOU", "3", &source{})
+
+	assets, err := m.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := assetIDs(assets); got != "GDT:a,GDT:b,META:c,FILE:d" {
+		t.Errorf("assets = %s", got)
+	}
+}
+
+func TestMultiConcurrency(t *testing.T) {
+	m := NewMulti()
+	m.Concurrency = 1
+	for _, id := range []string{"a", "b", "c"} {
+		m.Add("GDT", id, &source{assets: []*Asset{{AssetID: id}}})
+	}
+
+	assets, err := m.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := assetIDs(assets); got != "GDT:a,GDT:b,GDT:c" {
+		t.Errorf("assets = %s", got)
+	}
+}
+
+func TestMultiErrors(t *testing.T) {
+	errDenied := errors.New("denied")
+	m := NewMulti()
+	m.Add("GDT", "1", &source{assets: []*Asset{{AssetID: "a"}}})
+	m.Add("META", "2", &source{err: errDenied})
+	m.Add("BAIDU", "3", &source{panic: true})
+	m.Add("KUAISHOU", "4", &source{assets: []*Asset{{AssetID: "b"}}})
+
+	assets, err := m.Assets()
+	if got := assetIDs(assets); got != "GDT:a,KUAISHOU:b" {
+		t.Errorf("assets = %s", got)
+	}
+
+	var merr *MultiError
+	if !errors.As(err, &merr) {
+		t.Fatalf("error = %v", err)
+	}
+	if !errors.Is(err, errDenied) {
+		t.Errorf("error %v does not wrap the source error", err)
+	}
+
+	errs := ProviderErrors(err)
+	if len(errs) != 2 {
+		t.Fatalf("provider errors = %v", errs)
+	}
+	if errs[0].Provider != "META" || errs[0].AccountID != "2" || errs[0].Err != errDenied {
+		t.Errorf("error of META = %+v", errs[0])
+	}
+	if errs[1].Provider != "BAIDU" || errs[1].AccountID != "3" || !strings.Contains(errs[1].Err.Error(), "boom") {
+		t.Errorf("error of the panic = %+v", errs[1])
+	}
+
+	if ProviderErrors(errDenied) != nil {
+		t.Error("provider errors of a plain error")
+	}
+}
+
+func TestMultiForwards(t *testing.T) {
+	a, b := &source{}, &source{}
+	m := NewMulti(&MultiSource{Provider: "GDT", AccountID: "1", GetAdcreatives: a})
+	m.Add("META", "2", b)
+
+	m.SetAdcreativesFunc(NotDeleted)
+	m.OnlyAdcreatives(true)
+	for _, s := range []*source{a, b} {
+		if s.fns != 1 || !s.only {
+			t.Errorf("source = %+v", s)
+		}
+	}
+	m.ClearAdcreativesFuncs()
+	if a.fns != 0 || b.fns != 0 {
+		t.Error("funcs not cleared")
+	}
+	if len(m.Sources()) != 2 {
+		t.Errorf("sources = %v", m.Sources())
+	}
+}
@@ -0,0 +1,126 @@
+package oceanengine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+	"github.com/stretchr/objx"
+)
+
+// DefaultEndpoint is the Open API of Ocean Engine
+const DefaultEndpoint = "https://ad.oceanengine.com/open_api"
+
+// pageSize is the page size of list calls
+const pageSize = 100
+
+// Error is an error code returned by the API
+type Error struct {
+	Code      int
+	Message   string
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("oceanengine: code %d: %s (request %s)", e.Code, e.Message, e.RequestID)
+}
+
+// Client calls the Open API with an access token
+type Client struct {
+	Endpoint    string
+	AccessToken string
+	HTTPClient  *http.Client
+	// Debug logs the requests and responses at debug level
+	Debug bool
+}
+
+// Get calls the API at path, parameters of other types than string are sent
+// JSON encoded, and returns the data of the response
+func (c *Client) Get(ctx context.Context, path string, params map[string]interface{}) (ads.Map, error) {
+	q := url.Values{}
+	for key, value := range params {
+		switch value := value.(type) {
+		case string:
+			q.Set(key, value)
+		case int:
+			q.Set(key, strconv.Itoa(value))
+		case int64:
+			q.Set(key, strconv.FormatInt(value, 10))
+		default:
+			b, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			q.Set(key, string(b))
+		}
+	}
+
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/"+strings.Trim(path, "/")+"/?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Access-Token", c.AccessToken)
+
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Code      int             `json:"code"`
+		Message   string          `json:"message"`
+		RequestID string          `json:"request_id"`
+		Data      json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("oceanengine: %s: %w", path, err)
+	}
+	if r.Code != 0 {
+		return nil, &Error{Code: r.Code, Message: r.Message, RequestID: r.RequestID}
+	}
+	if len(r.Data) == 0 || string(r.Data) == "null" {
+		return objx.New(map[string]interface{}{}), nil
+	}
+	if r.Data[0] == '[' {
+		// some calls return their list as data
+		return ads.FromJSON([]byte(`{"list":` + string(r.Data) + `}`))
+	}
+	return ads.FromJSON(r.Data)
+}
+
+// List calls a paginated list call and returns the objects of all pages
+func (c *Client) List(ctx context.Context, path string, params map[string]interface{}) ([]ads.Map, error) {
+	var objs []ads.Map
+	for page := 1; ; page++ {
+		p := make(map[string]interface{}, len(params)+2)
+		for key, value := range params {
+			p[key] = value
+		}
+		p["page"], p["page_size"] = page, pageSize
+
+		data, err := c.Get(ctx, path, p)
+		if err != nil {
+			return nil, err
+		}
+		list := data.Get("list").ObjxMapSlice()
+		objs = append(objs, list...)
+
+		if page >= httpapi.IntValue(data, "page_info.total_page") || len(list) == 0 {
+			return objs, nil
+		}
+	}
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	return httpapi.Doer{Name: "oceanengine", HTTPClient: c.HTTPClient, Debug: c.Debug}.Do(req)
+}
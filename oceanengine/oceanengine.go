@@ -0,0 +1,446 @@
+// Package oceanengine lists the creatives of an Ocean Engine (巨量引擎)
+// advertiser with the Open API and resolves their image, video, title and
+// landing page materials into assets. The materials of procedural creatives
+// (程序化创意) are read from their ad.
+package oceanengine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	OnlyAdcreatives bool
+	// Endpoint is the base url of the Open API, DefaultEndpoint when empty
+	Endpoint string
+}
+
+type OceanEngineAdcreatives struct {
+	AdvertiserID   int64
+	Config         Config
+	client         *Client
+	adcreativesFns []ads.AdcreativeMatchFunc
+	accountName    *string
+	log            *zap.SugaredLogger
+}
+
+func NewAdcreatives(accountId string, accessToken string, debug bool) (*OceanEngineAdcreatives, error) {
+	id, err := strconv.ParseInt(accountId, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	o := &OceanEngineAdcreatives{
+		AdvertiserID: id,
+		client:       &Client{AccessToken: accessToken, Debug: debug},
+		log:          zap.S().With("provider", "OCEANENGINE"),
+	}
+
+	o.SetAdcreativesFunc(ads.NotDeleted)
+
+	return o, nil
+}
+
+// creativeFields are the fields of listed creatives
+var creativeFields = []string{
+	"creative_id", "ad_id", "title", "status", "opt_status", "image_mode",
+	"image_id", "image_ids", "video_id", "creative_material_mode",
+	"creative_create_time", "creative_modify_time",
+}
+
+// ref is the creative of a material
+type ref struct {
+	AdID       string
+	CreativeID string
+	ImageMode  string
+}
+
+// Assets lists the assets of the advertiser's creatives: landing pages, titles,
+// images and videos
+func (o *OceanEngineAdcreatives) Assets() (assets []*ads.Asset, err error) {
+	ctx := context.Background()
+
+	creatives, err := o.client.List(ctx, "2/creative/get", map[string]interface{}{
+		"advertiser_id": o.AdvertiserID,
+		"fields":        creativeFields,
+	})
+	if err != nil {
+		return nil, err
+	}
+	creatives = httpapi.Process(creatives, normalize, o.adcreativesFns)
+	httpapi.PrintJson(o.log, "creatives", creatives)
+
+	var (
+		adIds      = make(ads.Set[string])
+		procedural = make(ads.Set[string])
+		imageIds   = make(ads.Set[string])
+		videoIds   = make(ads.Set[string])
+		adsImages  = make(map[string]*ref)
+		adsVideos  = make(map[string]*ref)
+		adsTitles  = make(map[string]*ref)
+		adsFirst   = make(map[string]*ref)
+		titles     = make(map[string][]string)
+	)
+
+	addTitle := func(r *ref, title string) {
+		if title == "" || slices.Contains(titles[r.AdID], title) {
+			return
+		}
+		titles[r.AdID] = append(titles[r.AdID], title)
+		if _, ok := adsTitles[r.AdID]; !ok {
+			adsTitles[r.AdID] = r
+		}
+	}
+	addMaterials := func(r *ref, m ads.Map) {
+		for _, id := range append(ads.StringSlice(m.Get("image_ids")), m.Get("image_id").String()) {
+			if id == "" {
+				continue
+			}
+			imageIds.Add(id)
+			if _, ok := adsImages[id]; !ok {
+				adsImages[id] = r
+			}
+		}
+		if id := m.Get("video_id").String(); id != "" {
+			videoIds.Add(id)
+			if _, ok := adsVideos[id]; !ok {
+				adsVideos[id] = r
+			}
+		}
+	}
+
+	for _, creative := range creatives {
+		r := &ref{
+			AdID:       creative.Get("ad_id").String(),
+			CreativeID: creative.Get("creative_id").String(),
+			ImageMode:  creative.Get("image_mode").String(),
+		}
+		adIds.Add(r.AdID)
+		if _, ok := adsFirst[r.AdID]; !ok {
+			adsFirst[r.AdID] = r
+		}
+		if creative.Get("creative_material_mode").String() == "STATIC_ASSEMBLE" {
+			procedural.Add(r.AdID)
+		}
+		addTitle(r, creative.Get("title").String())
+		addMaterials(r, creative)
+	}
+
+	// procedural creatives combine the titles and images of their ad
+	for _, adId := range procedural.Slice() {
+		detail, err := o.client.Get(ctx, "2/creative/read_v2", map[string]interface{}{
+			"advertiser_id": o.AdvertiserID,
+			"ad_id":         adId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		httpapi.PrintJson(o.log, "procedural", detail)
+
+		first := adsFirst[adId]
+		for _, title := range detail.Get("title_list").ObjxMapSlice() {
+			addTitle(&ref{AdID: adId, CreativeID: first.CreativeID}, title.Get("title").String())
+		}
+		for _, image := range detail.Get("image_list").ObjxMapSlice() {
+			addMaterials(&ref{AdID: adId, CreativeID: first.CreativeID, ImageMode: image.Get("image_mode").String()}, image)
+		}
+	}
+
+	adList, err := o.ads(ctx, adIds.Slice())
+	if err != nil {
+		return nil, err
+	}
+	httpapi.PrintJson(o.log, "ads", adList)
+
+	adNames := make(map[string]string, len(adList))
+	pageIndexs := make(map[string]bool)
+	for _, ad := range adList {
+		adId := ad.Get("id").String()
+		adNames[adId] = ad.Get("name").String()
+
+		url := ad.Get("external_url").String()
+		if url == "" || pageIndexs[url] {
+			continue
+		}
+		pageIndexs[url] = true
+
+		r, ok := adsFirst[adId]
+		if !ok {
+			r = &ref{AdID: adId}
+		}
+		assets = append(assets, &ads.Asset{
+			AccountID:      o.accountID(),
+			Name:           ad.Get("name").String(),
+			AssetID:        adId,
+			AdcreativeID:   r.CreativeID,
+			AdcreativeName: ad.Get("name").String(),
+			PageType:       ads.PTPageUrl,
+			SubType:        "EXTERNAL_URL",
+			SubAssets: []*ads.SubAsset{
+				{
+					Type: ads.SATPageUrl,
+					Url:  url,
+				},
+			},
+			CreatedTime:      parseTime(ad, "ad_create_time"),
+			LastModifiedTime: parseTime(ad, "ad_modify_time"),
+		})
+	}
+
+	for _, adId := range adIds.Slice() {
+		if len(titles[adId]) == 0 {
+			continue
+		}
+		r := adsTitles[adId]
+		assets = append(assets, &ads.Asset{
+			AccountID:      o.accountID(),
+			Name:           adNames[adId],
+			AssetID:        adId,
+			AdcreativeID:   r.CreativeID,
+			AdcreativeName: adNames[adId],
+			PageType:       ads.PTText,
+			SubType:        "TITLE",
+			Texts:          titles[adId],
+		})
+	}
+
+	if len(imageIds) > 0 {
+		images, err := o.materials(ctx, "2/file/image/get", "image_ids", imageIds.Slice())
+		if err != nil {
+			return nil, err
+		}
+		httpapi.PrintJson(o.log, "images", images)
+
+		for _, image := range images {
+			r, ok := adsImages[image.Get("id").String()]
+			if !ok {
+				r = &ref{}
+			}
+			assets = append(assets, &ads.Asset{
+				AccountID:      o.accountID(),
+				Name:           image.Get("filename").String(),
+				AssetID:        image.Get("id").String(),
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: adNames[r.AdID],
+				PageType:       ads.PTImage,
+				SubType:        r.ImageMode,
+				Signature:      image.Get("signature").String(),
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATImage,
+						Url:  image.Get("url").String(),
+					},
+				},
+				Width:       httpapi.IntValue(image, "width"),
+				Height:      httpapi.IntValue(image, "height"),
+				CreatedTime: parseTime(image, "create_time"),
+			})
+		}
+	}
+
+	if len(videoIds) > 0 {
+		videos, err := o.materials(ctx, "2/file/video/get", "video_ids", videoIds.Slice())
+		if err != nil {
+			return nil, err
+		}
+		httpapi.PrintJson(o.log, "videos", videos)
+
+		for _, video := range videos {
+			r, ok := adsVideos[video.Get("id").String()]
+			if !ok {
+				r = &ref{}
+			}
+			assets = append(assets, &ads.Asset{
+				AccountID:      o.accountID(),
+				Name:           video.Get("filename").String(),
+				AssetID:        video.Get("id").String(),
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: adNames[r.AdID],
+				PageType:       ads.PTVideo,
+				SubType:        r.ImageMode,
+				Signature:      video.Get("signature").String(),
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATImage,
+						Url:  video.Get("poster_url").String(),
+					},
+					{
+						Type: ads.SATVideo,
+						Url:  video.Get("url").String(),
+					},
+				},
+				Width:       httpapi.IntValue(video, "width"),
+				Height:      httpapi.IntValue(video, "height"),
+				CreatedTime: parseTime(video, "create_time"),
+			})
+		}
+	}
+
+	name := o.AccountName()
+	for _, asset := range assets {
+		asset.AccountName = name
+	}
+	return assets, nil
+}
+
+// ads lists the ads of the creatives with their landing pages
+func (o *OceanEngineAdcreatives) ads(ctx context.Context, ids []string) ([]ads.Map, error) {
+	var adList []ads.Map
+	for _, batch := range httpapi.Batches(ids, pageSize) {
+		objs, err := o.client.List(ctx, "2/ad/get", map[string]interface{}{
+			"advertiser_id": o.AdvertiserID,
+			"filtering":     map[string]interface{}{"ids": toInts(batch)},
+			"fields":        []string{"id", "name", "external_url", "ad_create_time", "ad_modify_time"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		adList = append(adList, objs...)
+	}
+	return adList, nil
+}
+
+// materials lists the images or videos of the ids, all images or videos of
+// the advertiser unless only the materials of creatives are listed
+func (o *OceanEngineAdcreatives) materials(ctx context.Context, path, key string, ids []string) ([]ads.Map, error) {
+	if !o.Config.OnlyAdcreatives {
+		return o.client.List(ctx, path, map[string]interface{}{
+			"advertiser_id": o.AdvertiserID,
+		})
+	}
+
+	var materials []ads.Map
+	for _, batch := range httpapi.Batches(ids, pageSize) {
+		objs, err := o.client.List(ctx, path, map[string]interface{}{
+			"advertiser_id": o.AdvertiserID,
+			"filtering":     map[string]interface{}{key: batch},
+		})
+		if err != nil {
+			return nil, err
+		}
+		materials = append(materials, objs...)
+	}
+	return materials, nil
+}
+
+// AccountName returns the name of the advertiser, empty when it cannot be
+// read
+func (o *OceanEngineAdcreatives) AccountName() string {
+	if o.accountName != nil {
+		return *o.accountName
+	}
+
+	var name string
+	data, err := o.client.Get(context.Background(), "2/advertiser/info", map[string]interface{}{
+		"advertiser_ids": []int64{o.AdvertiserID},
+		"fields":         []string{"id", "name"},
+	})
+	if err != nil {
+		o.log.Warnw("get advertiser error", "account", o.AdvertiserID, "error", err)
+	}
+	for _, obj := range data.Get("list").ObjxMapSlice() {
+		if obj.Get("id").String() == o.accountID() {
+			name = obj.Get("name").String()
+		}
+	}
+
+	o.accountName = &name
+	return name
+}
+
+// SetAdcreativesFunc ...
+func (o *OceanEngineAdcreatives) SetAdcreativesFunc(match ads.AdcreativeMatchFunc) {
+	o.adcreativesFns = append(o.adcreativesFns, match)
+}
+
+// ClearAdcreativesFuncs ...
+func (o *OceanEngineAdcreatives) ClearAdcreativesFuncs() {
+	o.adcreativesFns = nil
+}
+
+// OnlyAdcreatives ...
+func (o *OceanEngineAdcreatives) OnlyAdcreatives(on bool) {
+	o.Config.OnlyAdcreatives = on
+}
+
+// Configure applies provider settings: endpoint replaces the url of the Open
+// API
+func (o *OceanEngineAdcreatives) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		switch key {
+		case "endpoint":
+			o.Config.Endpoint, o.client.Endpoint = value, value
+		default:
+			return fmt.Errorf("oceanengine: unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// statuses are the configured_status of the opt_status of creatives
+var statuses = map[string]string{
+	"CREATIVE_STATUS_ENABLE":  "AD_STATUS_NORMAL",
+	"CREATIVE_STATUS_DISABLE": "AD_STATUS_SUSPEND",
+}
+
+// normalize sets the is_deleted, configured_status, created_time and
+// last_modified_time fields of GDT adcreatives from a creative
+func normalize(creative ads.Map) {
+	creative.Set("is_deleted", creative.Get("status").String() == "CREATIVE_STATUS_DELETE")
+	if status, ok := statuses[creative.Get("opt_status").String()]; ok {
+		creative.Set("configured_status", status)
+	}
+	if t := parseTime(creative, "creative_create_time"); !t.IsZero() {
+		creative.Set("created_time", t.Unix())
+	}
+	if t := parseTime(creative, "creative_modify_time"); !t.IsZero() {
+		creative.Set("last_modified_time", t.Unix())
+	}
+}
+
+func (o *OceanEngineAdcreatives) accountID() string {
+	return strconv.FormatInt(o.AdvertiserID, 10)
+}
+
+var (
+	_ ads.GetAdcreatives = (*OceanEngineAdcreatives)(nil)
+	_ ads.Configurable   = (*OceanEngineAdcreatives)(nil)
+)
+
+func init() {
+	ads.RegisterProvider("OCEANENGINE", func(accountId string, accessToken string, debug bool) (ads.GetAdcreatives, error) {
+		return NewAdcreatives(accountId, accessToken, debug)
+	})
+	ads.DescribeProvider(ads.ProviderInfo{
+		Name:         "OCEANENGINE",
+		Description:  "Ocean Engine (巨量引擎) creatives",
+		Capabilities: []ads.Capability{ads.CapOnlyAdcreatives, ads.CapDownload},
+		PageTypes:    []ads.PageType{ads.PTPageUrl, ads.PTVideo, ads.PTImage, ads.PTText},
+		Credentials:  []string{"access_token"},
+		Settings: []ads.Setting{
+			{Name: "endpoint", Description: "base url of the API", Default: DefaultEndpoint},
+		},
+	})
+}
+
+// parseTime reads a time of the API
+func parseTime(obj ads.Map, key string) time.Time {
+	return httpapi.ParseTime(obj, key, httpapi.DateTime, httpapi.CST)
+}
+
+func toInts(ids []string) []int64 {
+	ints := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if n, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64); err == nil {
+			ints = append(ints, n)
+		}
+	}
+	return ints
+}
@@ -0,0 +1,172 @@
+package oceanengine
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/apitest"
+)
+
+// server serves the data of the paths in the response of the API
+func server(t *testing.T, routes map[string]apitest.Route) *httptest.Server {
+	return apitest.Server(t, apitest.Config{
+		Auth: func(r *http.Request) bool { return r.Header.Get("Access-Token") == "token" },
+		Envelope: func(data interface{}) interface{} {
+			return apitest.Object{"code": 0, "message": "OK", "request_id": "req", "data": data}
+		},
+	}, routes)
+}
+
+func page(list []apitest.Object, page, total int) interface{} {
+	return apitest.Object{
+		"list":      list,
+		"page_info": apitest.Object{"page": page, "total_page": total},
+	}
+}
+
+func open(t *testing.T, srv *httptest.Server) *OceanEngineAdcreatives {
+	return apitest.Open(t, NewAdcreatives, "1001", srv, nil)
+}
+
+func TestAssets(t *testing.T) {
+	var creativePages []string
+	srv := server(t, map[string]apitest.Route{
+		"/2/creative/get/": func(r *apitest.Request) interface{} {
+			creativePages = append(creativePages, r.Query.Get("page"))
+			if r.Query.Get("page") == "1" {
+				return page([]apitest.Object{
+					{"creative_id": 21, "ad_id": 11, "title": "title a", "opt_status": "CREATIVE_STATUS_ENABLE", "image_mode": "CREATIVE_IMAGE_MODE_LARGE", "image_ids": []string{"img1"}, "creative_create_time": "2024-03-01 10:00:00"},
+				}, 1, 2)
+			}
+			return page([]apitest.Object{
+				{"creative_id": 22, "ad_id": 12, "title": "title b", "opt_status": "CREATIVE_STATUS_DISABLE", "image_mode": "CREATIVE_IMAGE_MODE_VIDEO", "video_id": "v1"},
+				{"creative_id": 23, "ad_id": 13, "title": "deleted", "status": "CREATIVE_STATUS_DELETE"},
+			}, 2, 2)
+		},
+		"/2/ad/get/": func(r *apitest.Request) interface{} {
+			return page([]apitest.Object{
+				{"id": 11, "name": "ad a", "external_url": "https://example.com/a", "ad_create_time": "2024-03-01 09:00:00"},
+				{"id": 12, "name": "ad b", "external_url": "https://example.com/b"},
+			}, 1, 1)
+		},
+		"/2/file/image/get/": func(r *apitest.Request) interface{} {
+			return page([]apitest.Object{
+				{"id": "img1", "filename": "a.jpg", "url": "https://example.com/a.jpg", "width": 640, "height": 480, "signature": "sig1"},
+			}, 1, 1)
+		},
+		"/2/file/video/get/": func(r *apitest.Request) interface{} {
+			return page([]apitest.Object{
+				{"id": "v1", "filename": "b.mp4", "url": "https://example.com/b.mp4", "poster_url": "https://example.com/b.jpg", "width": 720, "height": 1280},
+			}, 1, 1)
+		},
+		"/2/advertiser/info/": func(r *apitest.Request) interface{} {
+			return []apitest.Object{{"id": 1001, "name": "advertiser"}}
+		},
+	})
+
+	assets, err := open(t, srv).Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(creativePages) != 2 || creativePages[0] != "1" || creativePages[1] != "2" {
+		t.Errorf("creative pages %v", creativePages)
+	}
+
+	got := apitest.Index(t, assets, "1001", "advertiser")
+	for _, a := range assets {
+		if a.AdcreativeID == "23" {
+			t.Errorf("asset %v of a deleted creative", ads.KeyOf(a))
+		}
+	}
+	if got.Len() != 6 {
+		t.Errorf("listed %d assets, want 6", got.Len())
+	}
+
+	page := got.Get("11", ads.PTPageUrl)
+	if page.PrimaryUrl() != "https://example.com/a" || page.AdcreativeID != "21" ||
+		!page.CreatedTime.Equal(time.Date(2024, 3, 1, 9, 0, 0, 0, time.FixedZone("CST", 8*60*60))) {
+		t.Errorf("landing page %+v", page)
+	}
+	title := got.Get("12", ads.PTText)
+	if len(title.Texts) != 1 || title.Texts[0] != "title b" || title.AdcreativeName != "ad b" {
+		t.Errorf("title %+v", title)
+	}
+	image := got.Get("img1", ads.PTImage)
+	if image.PrimaryUrl() != "https://example.com/a.jpg" || image.Width != 640 || image.Height != 480 ||
+		image.Signature != "sig1" || image.SubType != "CREATIVE_IMAGE_MODE_LARGE" || image.AdcreativeID != "21" {
+		t.Errorf("image %+v", image)
+	}
+	video := got.Get("v1", ads.PTVideo)
+	if video.ImageUrl() != "https://example.com/b.jpg" || video.PrimaryUrl() != "https://example.com/b.mp4" || video.AdcreativeID != "22" {
+		t.Errorf("video %+v", video)
+	}
+}
+
+func TestAssetsActiveOnly(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/2/creative/get/": func(r *apitest.Request) interface{} {
+			return page([]apitest.Object{
+				{"creative_id": 21, "ad_id": 11, "title": "active", "opt_status": "CREATIVE_STATUS_ENABLE"},
+				{"creative_id": 22, "ad_id": 12, "title": "suspended", "opt_status": "CREATIVE_STATUS_DISABLE"},
+			}, 1, 1)
+		},
+		"/2/ad/get/": func(r *apitest.Request) interface{} {
+			if r.Query.Get("filtering") != `{"ids":[11]}` {
+				t.Errorf("ads filtering %s", r.Query.Get("filtering"))
+			}
+			return page(nil, 1, 0)
+		},
+		"/2/advertiser/info/": func(r *apitest.Request) interface{} {
+			return []apitest.Object{}
+		},
+	})
+
+	o := open(t, srv)
+	o.SetAdcreativesFunc(ads.ActiveOnly)
+	assets, err := o.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 1 || assets[0].Texts[0] != "active" {
+		t.Errorf("assets %+v", assets)
+	}
+}
+
+func TestError(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/2/creative/get/": func(*apitest.Request) interface{} {
+			return apitest.Status{Code: http.StatusOK, Body: apitest.Object{"code": 40100, "message": "access token expired", "request_id": "req1"}}
+		},
+	})
+
+	_, err := open(t, srv).Assets()
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v is not an API error", err)
+	}
+	if apiErr.Code != 40100 || apiErr.Message != "access token expired" || apiErr.RequestID != "req1" {
+		t.Errorf("error %+v", apiErr)
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/2/creative/get/": func(*apitest.Request) interface{} {
+			return apitest.Status{Code: http.StatusServiceUnavailable, Body: "unavailable"}
+		},
+	})
+
+	_, err := open(t, srv).Assets()
+	if err == nil || errors.As(err, new(*Error)) {
+		t.Fatalf("error %v", err)
+	}
+	if want := strconv.Itoa(http.StatusServiceUnavailable); !strings.Contains(err.Error(), want) {
+		t.Errorf("error %v without the status", err)
+	}
+}
@@ -0,0 +1,188 @@
+// Package parquet exports assets as parquet files, import it for side effects
+// to register the "parquet" export format and snapshot reader.
+package parquet
+
+import (
+	"io"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Row is the parquet schema of an asset, sub assets are a repeated group, the
+// page and sub asset types are dictionary encoded strings, parquet-go cannot
+// write ENUM columns, and times are optional millisecond timestamps
+type Row struct {
+	Provider         string     `parquet:"name=provider, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	AccountID        string     `parquet:"name=account_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AccountName      string     `parquet:"name=account_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AdcreativeID     string     `parquet:"name=adcreative_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AdcreativeName   string     `parquet:"name=adcreative_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AssetID          string     `parquet:"name=asset_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Name             string     `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PageType         string     `parquet:"name=page_type, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	SubType          string     `parquet:"name=sub_type, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Texts            []string   `parquet:"name=texts, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	SubAssets        []SubAsset `parquet:"name=sub_assets, type=LIST"`
+	Width            int32      `parquet:"name=width, type=INT32"`
+	Height           int32      `parquet:"name=height, type=INT32"`
+	Signature        string     `parquet:"name=signature, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Version          string     `parquet:"name=version, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PHash            string     `parquet:"name=phash, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CreatedTime      *int64     `parquet:"name=created_time, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+	LastModifiedTime *int64     `parquet:"name=last_modified_time, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+}
+
+// SubAsset is an element of the sub_assets group
+type SubAsset struct {
+	Type string `parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Url  string `parquet:"name=url, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// FromAsset converts an asset to its parquet row
+func FromAsset(a *ads.Asset) Row {
+	row := Row{
+		Provider:         a.Provider,
+		AccountID:        a.AccountID,
+		AccountName:      a.AccountName,
+		AdcreativeID:     a.AdcreativeID,
+		AdcreativeName:   a.AdcreativeName,
+		AssetID:          a.AssetID,
+		Name:             a.Name,
+		PageType:         a.PageType.String(),
+		SubType:          a.SubType,
+		Texts:            a.Texts,
+		Width:            int32(a.Width),
+		Height:           int32(a.Height),
+		Signature:        a.Signature,
+		Version:          a.Version,
+		PHash:            a.PHash,
+		CreatedTime:      timestamp(a.CreatedTime),
+		LastModifiedTime: timestamp(a.LastModifiedTime),
+	}
+
+	for _, sub := range a.SubAssets {
+		row.SubAssets = append(row.SubAssets, SubAsset{Type: sub.Type.String(), Url: sub.Url})
+	}
+	return row
+}
+
+// Asset converts a parquet row back to an asset
+func (row *Row) Asset() (*ads.Asset, error) {
+	pageType, err := ads.ParsePageType(row.PageType)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &ads.Asset{
+		Provider:         row.Provider,
+		AccountID:        row.AccountID,
+		AccountName:      row.AccountName,
+		AdcreativeID:     row.AdcreativeID,
+		AdcreativeName:   row.AdcreativeName,
+		AssetID:          row.AssetID,
+		Name:             row.Name,
+		PageType:         pageType,
+		SubType:          row.SubType,
+		Width:            int(row.Width),
+		Height:           int(row.Height),
+		Signature:        row.Signature,
+		Version:          row.Version,
+		PHash:            row.PHash,
+		CreatedTime:      fromTimestamp(row.CreatedTime),
+		LastModifiedTime: fromTimestamp(row.LastModifiedTime),
+	}
+
+	if len(row.Texts) > 0 {
+		a.Texts = row.Texts
+	}
+	for _, sub := range row.SubAssets {
+		typ, err := ads.ParseSubAssetType(sub.Type)
+		if err != nil {
+			return nil, err
+		}
+		a.SubAssets = append(a.SubAssets, &ads.SubAsset{Type: typ, Url: sub.Url})
+	}
+	return a, nil
+}
+
+func timestamp(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	ms := t.UnixMilli()
+	return &ms
+}
+
+func fromTimestamp(ms *int64) time.Time {
+	if ms == nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(*ms)
+}
+
+// Exporter writes assets as parquet rows, the file is written on Close
+type Exporter struct {
+	w *writer.ParquetWriter
+}
+
+// NewExporter creates a snappy compressed parquet exporter, the options of
+// the tabular formats do not apply
+func NewExporter(w io.Writer, _ ads.ExportOptions) (ads.Exporter, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(Row), 1)
+	if err != nil {
+		return nil, err
+	}
+	return &Exporter{w: pw}, nil
+}
+
+func (e *Exporter) Write(assets []*ads.Asset) error {
+	for _, a := range assets {
+		if err := e.w.Write(FromAsset(a)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes the row groups and writes the footer
+func (e *Exporter) Close() error {
+	return e.w.WriteStop()
+}
+
+// ReadAssets reads the assets of a parquet file written by the Exporter
+func ReadAssets(r io.Reader) ([]*ads.Asset, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytesNoAlloc(b), new(Row), 1)
+	if err != nil {
+		return nil, err
+	}
+	defer pr.ReadStop()
+
+	rows := make([]Row, pr.GetNumRows())
+	if err := pr.Read(&rows); err != nil {
+		return nil, err
+	}
+
+	assets := make([]*ads.Asset, 0, len(rows))
+	for i := range rows {
+		a, err := rows[i].Asset()
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, a)
+	}
+	return assets, nil
+}
+
+func init() {
+	ads.RegisterExporter("parquet", NewExporter)
+	ads.RegisterReader("parquet", ReadAssets)
+}
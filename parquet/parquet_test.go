@@ -0,0 +1,157 @@
+package parquet
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	pq "github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func testAssets() []*ads.Asset {
+	created := time.UnixMilli(1700000000123)
+	modified := time.UnixMilli(1700000600456)
+	return []*ads.Asset{
+		{
+			Provider:       "GDT",
+			AccountID:      "1001",
+			AccountName:    "account",
+			AdcreativeID:   "2001",
+			AdcreativeName: "creative",
+			AssetID:        "3001",
+			Name:           "image",
+			PageType:       ads.PTImage,
+			SubType:        "IMAGE",
+			SubAssets: []*ads.SubAsset{
+				{Type: ads.SATImage, Url: "https://example.com/a.jpg"},
+			},
+			Width:            640,
+			Height:           480,
+			Signature:        "sig",
+			Version:          "v1",
+			PHash:            "ffff0000ffff0000",
+			CreatedTime:      created,
+			LastModifiedTime: modified,
+		},
+		{
+			Provider:       "GDT",
+			AccountID:      "1001",
+			AccountName:    "account",
+			AdcreativeID:   "2001",
+			AdcreativeName: "creative",
+			AssetID:        "3002",
+			Name:           "video",
+			PageType:       ads.PTVideo,
+			SubType:        "VIDEO",
+			SubAssets: []*ads.SubAsset{
+				{Type: ads.SATImage, Url: "https://example.com/cover.jpg"},
+				{Type: ads.SATVideo, Url: "https://example.com/v.mp4"},
+			},
+			CreatedTime: created,
+		},
+		{
+			AccountID: "1002",
+			AssetID:   "3003",
+			PageType:  ads.PTText,
+			SubType:   "TITLE",
+			Texts:     []string{"first", "second"},
+		},
+	}
+}
+
+func write(t *testing.T, assets []*ads.Asset) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	e, err := NewExporter(&buf, ads.ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Write(assets); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	assets := testAssets()
+
+	got, err := ReadAssets(bytes.NewReader(write(t, assets)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(assets) {
+		t.Fatalf("read %d assets, want %d", len(got), len(assets))
+	}
+	for i := range assets {
+		if !reflect.DeepEqual(got[i], assets[i]) {
+			t.Errorf("asset %d = %+v, want %+v", i, got[i], assets[i])
+		}
+	}
+}
+
+func TestRoundTripEmpty(t *testing.T) {
+	got, err := ReadAssets(bytes.NewReader(write(t, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("read %d assets, want none", len(got))
+	}
+}
+
+func TestReadFormat(t *testing.T) {
+	got, err := ads.ReadAssets(bytes.NewReader(write(t, testAssets())), "parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(testAssets()) {
+		t.Fatalf("read %d assets, want %d", len(got), len(testAssets()))
+	}
+}
+
+func TestTypeColumns(t *testing.T) {
+	b := write(t, testAssets())
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytesNoAlloc(b), nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+
+	strs := make(map[string]bool)
+	for _, el := range pr.Footer.Schema {
+		if el.ConvertedType != nil && *el.ConvertedType == pq.ConvertedType_UTF8 {
+			strs[strings.ToLower(el.Name)] = true
+		}
+	}
+	for _, name := range []string{"page_type", "type"} {
+		if !strs[name] {
+			t.Errorf("column %s is not a string", name)
+		}
+	}
+
+	dicts := make(map[string]bool)
+	for _, rg := range pr.Footer.RowGroups {
+		for _, col := range rg.Columns {
+			for _, enc := range col.MetaData.Encodings {
+				if enc == pq.Encoding_PLAIN_DICTIONARY || enc == pq.Encoding_RLE_DICTIONARY {
+					dicts[strings.ToLower(col.MetaData.PathInSchema[len(col.MetaData.PathInSchema)-1])] = true
+				}
+			}
+		}
+	}
+	for _, name := range []string{"page_type", "type"} {
+		if !dicts[name] {
+			t.Errorf("column %s is not dictionary encoded", name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package ads
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// ImageHash is a 64 bit perceptual hash of an image
+type ImageHash uint64
+
+// String returns the hash as 16 hex digits
+func (h ImageHash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// Distance returns the hamming distance between two hashes
+func (h ImageHash) Distance(other ImageHash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+// ParseImageHash parses a hash produced by ImageHash.String
+func ParseImageHash(s string) (ImageHash, error) {
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid image hash %q: %w", s, err)
+	}
+	return ImageHash(v), nil
+}
+
+// AHash computes the average hash of img
+func AHash(img image.Image) ImageHash {
+	pixels := grayscale(img, 8, 8)
+
+	var sum float64
+	for _, p := range pixels {
+		sum += p
+	}
+	mean := sum / float64(len(pixels))
+
+	var h ImageHash
+	for i, p := range pixels {
+		if p > mean {
+			h |= 1 << uint(i)
+		}
+	}
+	return h
+}
+
+// DHash computes the difference hash of img
+func DHash(img image.Image) ImageHash {
+	pixels := grayscale(img, 9, 8)
+
+	var h ImageHash
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if pixels[y*9+x] < pixels[y*9+x+1] {
+				h |= 1 << uint(y*8+x)
+			}
+		}
+	}
+	return h
+}
+
+// PHash computes the DCT based perceptual hash of img
+func PHash(img image.Image) ImageHash {
+	const size = 32
+	pixels := grayscale(img, size, size)
+	coeffs := dct2d(pixels, size)
+
+	// keep the 8x8 low frequencies, the DC term is excluded from the median
+	low := make([]float64, 0, 64)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			low = append(low, coeffs[y*size+x])
+		}
+	}
+
+	threshold := median(low[1:])
+
+	var h ImageHash
+	for i, c := range low {
+		if c > threshold {
+			h |= 1 << uint(i)
+		}
+	}
+	return h
+}
+
+// median returns the median of values, the mean of the middle values of an
+// even count
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// grayscale scales img down to w x h luminance values using box sampling
+func grayscale(img image.Image, w, h int) []float64 {
+	var (
+		b      = img.Bounds()
+		pixels = make([]float64, w*h)
+	)
+
+	if b.Empty() {
+		return pixels
+	}
+
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(b.Min.X+(x+1)*b.Dx()/w, x0+1)
+
+			var (
+				sum   float64
+				count int
+			)
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, bl, _ := img.At(sx, sy).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+					count++
+				}
+			}
+			pixels[y*w+x] = sum / float64(count) / 257
+		}
+	}
+	return pixels
+}
+
+// dct2d runs a type II discrete cosine transform over a n x n matrix
+func dct2d(pixels []float64, n int) []float64 {
+	cos := make([]float64, n*n)
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			cos[k*n+i] = math.Cos(math.Pi / float64(n) * (float64(i) + 0.5) * float64(k))
+		}
+	}
+
+	rows := make([]float64, n*n)
+	for y := 0; y < n; y++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for i := 0; i < n; i++ {
+				sum += pixels[y*n+i] * cos[k*n+i]
+			}
+			rows[y*n+k] = sum
+		}
+	}
+
+	out := make([]float64, n*n)
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for i := 0; i < n; i++ {
+				sum += rows[i*n+x] * cos[k*n+i]
+			}
+			out[k*n+x] = sum
+		}
+	}
+	return out
+}
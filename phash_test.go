@@ -0,0 +1,91 @@
+package ads
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// synthetic draws an image of w x h pixels, f gives the gray level of the
+// relative position of a pixel
+func synthetic(w, h int, f func(x, y float64) float64) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := f((float64(x)+0.5)/float64(w), (float64(y)+0.5)/float64(h))
+			img.SetGray(x, y, color.Gray{Y: uint8(math.Max(0, math.Min(255, v*255)))})
+		}
+	}
+	return img
+}
+
+// waves is a smooth pattern with a bright square
+func waves(x, y float64) float64 {
+	v := 0.5 + 0.3*math.Sin(5*x)*math.Cos(3*y)
+	if x > 0.6 && x < 0.9 && y > 0.1 && y < 0.4 {
+		v += 0.3
+	}
+	return v
+}
+
+// rings is a pattern unrelated to waves
+func rings(x, y float64) float64 {
+	return 0.5 + 0.5*math.Cos(20*math.Hypot(x-0.3, y-0.7))
+}
+
+func TestImageHashes(t *testing.T) {
+	var (
+		img     = synthetic(256, 192, waves)
+		same    = synthetic(256, 192, waves)
+		resized = synthetic(640, 480, waves)
+		small   = synthetic(64, 48, waves)
+		other   = synthetic(256, 192, rings)
+	)
+
+	for name, hash := range map[string]func(image.Image) ImageHash{"AHash": AHash, "DHash": DHash, "PHash": PHash} {
+		h := hash(img)
+		if h == 0 || h == ^ImageHash(0) {
+			t.Errorf("%s of a pattern %s", name, h)
+		}
+		if d := h.Distance(hash(same)); d != 0 {
+			t.Errorf("%s of an identical image at distance %d", name, d)
+		}
+		// within the default threshold of the dedupe command
+		for size, resized := range map[string]image.Image{"enlarged": resized, "shrunk": small} {
+			if d := h.Distance(hash(resized)); d > 6 {
+				t.Errorf("%s of the %s image at distance %d", name, size, d)
+			}
+		}
+		if d := h.Distance(hash(other)); d < 16 {
+			t.Errorf("%s of a different image at distance %d", name, d)
+		}
+	}
+}
+
+func TestImageHashEmpty(t *testing.T) {
+	empty := image.NewGray(image.Rect(0, 0, 0, 0))
+	for name, hash := range map[string]func(image.Image) ImageHash{"AHash": AHash, "DHash": DHash, "PHash": PHash} {
+		if h := hash(empty); h != 0 {
+			t.Errorf("%s of an empty image %s", name, h)
+		}
+	}
+}
+
+func TestParseImageHash(t *testing.T) {
+	for _, h := range []ImageHash{0, 1, 0x8000000000000000, ^ImageHash(0), 0x0123456789abcdef} {
+		s := h.String()
+		if len(s) != 16 {
+			t.Errorf("hash %s of %d digits", s, len(s))
+		}
+		if got, err := ParseImageHash(s); err != nil || got != h {
+			t.Errorf("parsed %s = %s, %v", s, got, err)
+		}
+	}
+	if _, err := ParseImageHash("xyz"); err == nil {
+		t.Error("invalid hash parsed")
+	}
+	if d := ImageHash(0).Distance(^ImageHash(0)); d != 64 {
+		t.Errorf("distance %d", d)
+	}
+}
@@ -0,0 +1,88 @@
+package ads
+
+import (
+	"slices"
+
+	"github.com/hysios/x/providers"
+)
+
+// Capability is an optional feature of a provider
+type Capability string
+
+const (
+	// CapIncremental providers implement IncrementalAdcreatives
+	CapIncremental Capability = "incremental"
+	// CapPushdown providers implement PushdownAdcreatives
+	CapPushdown Capability = "pushdown"
+	// CapAccounts providers implement AccountLister
+	CapAccounts Capability = "accounts"
+	// CapOnlyAdcreatives providers list the materials of no adcreative
+	// unless OnlyAdcreatives is on
+	CapOnlyAdcreatives Capability = "only_adcreatives"
+	// CapDownload providers return image and video urls ads download can
+	// fetch
+	CapDownload Capability = "download"
+)
+
+// Setting describes a setting accepted by the Configure method of a provider
+type Setting struct {
+	Name        string
+	Description string
+	Default     string
+	Required    bool
+}
+
+// ProviderInfo describes a registered provider
+type ProviderInfo struct {
+	Name         string
+	Description  string
+	Capabilities []Capability
+	// PageTypes are the page types of the listed assets
+	PageTypes []PageType
+	// Credentials are the credentials needed to open the provider, such as
+	// access_token, the provider needs none when empty
+	Credentials []string
+	Settings    []Setting
+}
+
+// Has reports whether the provider has the capability
+func (info ProviderInfo) Has(c Capability) bool {
+	return slices.Contains(info.Capabilities, c)
+}
+
+// NeedsToken reports whether the provider is opened with an access token
+func (info ProviderInfo) NeedsToken() bool {
+	return slices.Contains(info.Credentials, "access_token")
+}
+
+var providerInfos providers.Provider[string, ProviderInfo]
+
+// DescribeProvider registers the description of the provider named
+// info.Name, providers call it next to RegisterProvider
+func DescribeProvider(info ProviderInfo) {
+	providerInfos.Register(info.Name, info)
+}
+
+// LookupProvider returns the description of a registered provider, a
+// provider registered without a description is described by its name and
+// the access token it is opened with
+func LookupProvider(name string) (ProviderInfo, bool) {
+	if _, ok := advProviders.Lookup(name); !ok {
+		return ProviderInfo{}, false
+	}
+	if info, ok := providerInfos.Lookup(name); ok {
+		return info, true
+	}
+	return ProviderInfo{Name: name, Credentials: []string{"access_token"}}, true
+}
+
+// Providers returns the descriptions of the registered providers in sorted
+// order
+func Providers() []ProviderInfo {
+	var infos []ProviderInfo
+	for _, name := range ProviderNames() {
+		info, _ := LookupProvider(name)
+		infos = append(infos, info)
+	}
+	return infos
+}
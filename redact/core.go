@@ -0,0 +1,142 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core wraps a zap core to redact the message and fields of every entry, use
+// it with zap.WrapCore(redact.Core)
+func Core(core zapcore.Core) zapcore.Core {
+	return &redactCore{inner: core}
+}
+
+// redactCore keeps the fields added with With and redacts them with the entry
+// fields when written, so fields of entries below the level, like the assets
+// logged at debug level, are never encoded
+type redactCore struct {
+	inner  zapcore.Core
+	fields []zapcore.Field
+}
+
+func (c *redactCore) Enabled(level zapcore.Level) bool {
+	return c.inner.Enabled(level)
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &redactCore{inner: c.inner, fields: all}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = String(ent.Message)
+
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	for _, f := range c.fields {
+		all = append(all, Field(f))
+	}
+	for _, f := range fields {
+		all = append(all, Field(f))
+	}
+	return c.inner.Write(ent, all)
+}
+
+func (c *redactCore) Sync() error {
+	return c.inner.Sync()
+}
+
+// Field redacts a log field, sensitive keys are masked whole, strings, errors
+// and objects have their secrets masked
+func Field(f zapcore.Field) zapcore.Field {
+	switch f.Type {
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return f
+	}
+	if IsSensitive(f.Key) {
+		return zap.String(f.Key, Mask)
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = String(f.String)
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			f.Interface = []byte(String(string(b)))
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, String(err.Error()))
+		}
+	case zapcore.StringerType:
+		return zap.String(f.Key, String(fmt.Sprint(f.Interface)))
+	case zapcore.ReflectType:
+		return zap.Any(f.Key, value(f.Interface))
+	case zapcore.ObjectMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := f.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(enc); err != nil {
+			return zap.String(f.Key+"Error", err.Error())
+		}
+		return zap.Any(f.Key, clean(enc.Fields))
+	case zapcore.ArrayMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := enc.AddArray(f.Key, f.Interface.(zapcore.ArrayMarshaler)); err != nil {
+			return zap.String(f.Key+"Error", err.Error())
+		}
+		return zap.Any(f.Key, clean(enc.Fields[f.Key]))
+	}
+	return f
+}
+
+// value redacts any value through its JSON form
+func value(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return String(fmt.Sprint(v))
+	}
+
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return String(string(b))
+	}
+	return clean(generic)
+}
+
+// clean masks the sensitive members of decoded JSON and the secrets of its
+// strings
+func clean(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			if IsSensitive(key) {
+				v[key] = Mask
+			} else {
+				v[key] = clean(elem)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = clean(elem)
+		}
+		return v
+	case string:
+		return String(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,110 @@
+// Package redact keeps access tokens, secrets and signatures out of logs. It
+// wraps a zap core to mask the values of sensitive fields, the token query
+// parameters, headers and JSON members of messages and strings, and the
+// secret values registered with Register wherever they appear.
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces redacted values
+const Mask = "******"
+
+// minSecretLen is the shortest registered value replaced, shorter values would
+// mask unrelated text
+const minSecretLen = 6
+
+var (
+	mu       sync.RWMutex
+	secrets  = make(map[string]bool)
+	replacer *strings.Replacer
+)
+
+// Register adds secret values to mask wherever they appear
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	changed := false
+	for _, value := range values {
+		if len(value) >= minSecretLen && !secrets[value] {
+			secrets[value] = true
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	// replace longer values first so a secret containing another is masked whole
+	sorted := make([]string, 0, len(secrets))
+	for value := range secrets {
+		sorted = append(sorted, value)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, value := range sorted {
+		pairs = append(pairs, value, Mask)
+	}
+	replacer = strings.NewReplacer(pairs...)
+}
+
+// sensitiveKeys are the field and parameter names whose value is masked
+var sensitiveKeys = []string{
+	"access_token",
+	"refresh_token",
+	"token",
+	"secret",
+	"client_secret",
+	"signature",
+	"sign",
+	"password",
+	"passphrase",
+	"authorization",
+}
+
+// IsSensitive reports if the value of a field or parameter is masked, the
+// keys above, also in camel case like accessToken, and keys ending with _token
+// or _secret
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if key == k || key == strings.ReplaceAll(k, "_", "") {
+			return true
+		}
+	}
+	return strings.HasSuffix(key, "_token") || strings.HasSuffix(key, "_secret")
+}
+
+var (
+	keys = `(?i)(access_?token|refresh_?token|client_?secret|secret|token|signature|sign|password|passphrase)`
+	// access_token=... in urls and forms
+	queryParam = regexp.MustCompile(`\b` + keys + `=([^&\s"'<>]+)`)
+	// "access_token": "..." in JSON payloads
+	jsonMember = regexp.MustCompile(`("` + keys + `"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// Authorization: Bearer ... in dumped headers
+	bearer = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	// Access-Token: ... and other token or secret headers in dumped requests
+	header = regexp.MustCompile(`(?im)^([\w-]*(?:token|secret)[\w-]*:[ \t]*)\S+`)
+)
+
+// String masks the token parameters, JSON members and registered secrets of s
+func String(s string) string {
+	s = queryParam.ReplaceAllString(s, "$1="+Mask)
+	s = jsonMember.ReplaceAllString(s, `$1"`+Mask+`"`)
+	s = bearer.ReplaceAllString(s, "$1 "+Mask)
+	s = header.ReplaceAllString(s, "${1}"+Mask)
+
+	mu.RLock()
+	r := replacer
+	mu.RUnlock()
+	if r != nil {
+		s = r.Replace(s)
+	}
+	return s
+}
@@ -0,0 +1,155 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestString(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"GET /v1.3/adcreatives/get?access_token=abc123&timestamp=1", "GET /v1.3/adcreatives/get?access_token=" + Mask + "&timestamp=1"},
+		{"https://x/?a=1&refreshToken=r-1.2 next", "https://x/?a=1&refreshToken=" + Mask + " next"},
+		{"client_secret=s3cr3t&sign=deadbeef", "client_secret=" + Mask + "&sign=" + Mask},
+		{`{"access_token": "abc", "name": "x"}`, `{"access_token": "` + Mask + `", "name": "x"}`},
+		{`{"refresh_token":"a\"b","expires_in":3600}`, `{"refresh_token":"` + Mask + `","expires_in":3600}`},
+		{`{"Password" : "p"}`, `{"Password" : "` + Mask + `"}`},
+		{"Authorization: Bearer ya29.a0-AfB_x/y=", "Authorization: Bearer " + Mask},
+		{"authorization: basic dXNlcjpwYXNz", "authorization: basic " + Mask},
+		{"POST / HTTP/1.1\r\nAccess-Token: abc\r\nDeveloper-Token: dev\r\nContent-Type: json", "POST / HTTP/1.1\r\nAccess-Token: " + Mask + "\r\nDeveloper-Token: " + Mask + "\r\nContent-Type: json"},
+		{"nothing to hide, tokens=3", "nothing to hide, tokens=3"},
+	} {
+		if got := String(tt.in); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("short", "registered-secret", "registered-secret-longer", "")
+
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"token registered-secret used", "token " + Mask + " used"},
+		{"registered-secret-longer!", Mask + "!"},
+		{"prefix-registered-secret", "prefix-" + Mask},
+		// values shorter than minSecretLen are not registered
+		{"a short text", "a short text"},
+	} {
+		if got := String(tt.in); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	for key, want := range map[string]bool{
+		"access_token":  true,
+		"accessToken":   true,
+		"Authorization": true,
+		"api_token":     true,
+		"app_secret":    true,
+		"signature":     true,
+		"account_id":    false,
+		"tokens":        false,
+		"url":           false,
+	} {
+		if got := IsSensitive(key); got != want {
+			t.Errorf("IsSensitive(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+// credential is logged as an object
+type credential struct {
+	Account string
+	Token   string
+}
+
+func (c credential) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("account", c.Account)
+	enc.AddString("access_token", c.Token)
+	enc.AddString("url", "https://x/?access_token="+c.Token)
+	return nil
+}
+
+func TestCore(t *testing.T) {
+	Register("registered-value")
+
+	obs, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(Core(obs)).With(zap.String("access_token", "with-token"), zap.String("url", "/get?token=abc"))
+
+	log.Info("calling /get?access_token=msg-token",
+		zap.String("secret", "plain"),
+		zap.Error(errors.New("request https://x/?access_token=err-token failed")),
+		zap.Reflect("body", map[string]interface{}{
+			"account_id": 1,
+			"data":       map[string]interface{}{"refresh_token": "nested", "list": []interface{}{"registered-value"}},
+		}),
+		zap.Object("credential", credential{Account: "1001", Token: "obj-token"}),
+		zap.Stringer("dump", stringer("Authorization: Bearer stringer-token")),
+		zap.ByteString("raw", []byte(`{"access_token":"raw-token"}`)),
+		zap.Int("count", 3),
+	)
+	log.Debug("below nothing", zap.String("access_token", "debug-token"))
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("logged %d entries", len(entries))
+	}
+	ent := entries[0]
+	if strings.Contains(ent.Message, "msg-token") {
+		t.Errorf("message = %q", ent.Message)
+	}
+
+	fields := ent.ContextMap()
+	for key, want := range map[string]interface{}{
+		"access_token": Mask,
+		"url":          "/get?token=" + Mask,
+		"secret":       Mask,
+		"error":        "request https://x/?access_token=" + Mask + " failed",
+		"dump":         "Authorization: Bearer " + Mask,
+		"count":        int64(3),
+	} {
+		if fields[key] != want {
+			t.Errorf("field %s = %#v, want %#v", key, fields[key], want)
+		}
+	}
+	if raw, _ := fields["raw"].(string); raw != `{"access_token":"`+Mask+`"}` {
+		t.Errorf("field raw = %#v", fields["raw"])
+	}
+
+	body := fields["body"].(map[string]interface{})
+	data := body["data"].(map[string]interface{})
+	if data["refresh_token"] != Mask || data["list"].([]interface{})[0] != Mask || body["account_id"] == nil {
+		t.Errorf("field body = %#v", body)
+	}
+	cred := fields["credential"].(map[string]interface{})
+	if cred["access_token"] != Mask || cred["url"] != "https://x/?access_token="+Mask || cred["account"] != "1001" {
+		t.Errorf("field credential = %#v", cred)
+	}
+
+	for _, ent := range entries {
+		for _, secret := range []string{"with-token", "msg-token", "err-token", "nested", "registered-value", "obj-token", "stringer-token", "raw-token", "debug-token"} {
+			for key, v := range ent.ContextMap() {
+				if strings.Contains(fmt.Sprint(v), secret) {
+					t.Errorf("field %s leaks %s: %v", key, secret, v)
+				}
+			}
+		}
+	}
+}
+
+type stringer string
+
+func (s stringer) String() string {
+	return string(s)
+}
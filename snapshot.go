@@ -0,0 +1,163 @@
+package ads
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hysios/x/providers"
+)
+
+// ReadAssetsFile reads an exported snapshot, the format is detected from the
+// file extension
+func ReadAssetsFile(filename string) ([]*Asset, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadAssets(file, strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// ReadAssets reads assets exported as json, jsonl, csv or a format with a
+// registered reader
+func ReadAssets(r io.Reader, format string) ([]*Asset, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		var assets []*Asset
+		if err := json.NewDecoder(r).Decode(&assets); err != nil {
+			return nil, fmt.Errorf("read json assets: %w", err)
+		}
+		return assets, nil
+	case "jsonl", "ndjson":
+		return readAssetsJSONL(r)
+	case "csv":
+		return readAssetsCSV(r)
+	default:
+		read, ok := readers.Lookup(strings.ToLower(format))
+		if !ok {
+			return nil, fmt.Errorf("unsupported snapshot format %q", format)
+		}
+		return read(r)
+	}
+}
+
+var readers providers.Provider[string, func(r io.Reader) ([]*Asset, error)]
+
+// RegisterReader registers the snapshot reader of an export format not
+// handled by ReadAssets itself
+func RegisterReader(format string, f func(r io.Reader) ([]*Asset, error)) {
+	readers.Register(format, f)
+}
+
+func readAssetsJSONL(r io.Reader) (assets []*Asset, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var asset Asset
+		if err := json.Unmarshal(scanner.Bytes(), &asset); err != nil {
+			return nil, fmt.Errorf("read jsonl assets line %d: %w", line, err)
+		}
+		assets = append(assets, &asset)
+	}
+	return assets, scanner.Err()
+}
+
+func readAssetsCSV(r io.Reader) (assets []*Asset, err error) {
+	br := bufio.NewReader(r)
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+
+	// the delimiter is configurable on export, guess it from the header
+	if line, err := br.Peek(br.Size()); len(line) > 0 {
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		} else if err == nil {
+			line = nil
+		}
+		reader.Comma = guessComma(line)
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	// an -explode export has a row per sub asset, the rows of an asset are
+	// merged and their sub assets read from the SubAssetType and SubAssetUrl
+	// columns when there is no SubAssets column
+	index := make(map[AssetKey]*Asset)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("read csv assets: %w", err)
+		}
+
+		var (
+			asset Asset
+			sub   SubAsset
+		)
+		for i, name := range header {
+			if i >= len(row) {
+				break
+			}
+			switch name {
+			case "SubAssetType":
+				if row[i] != "" {
+					if sub.Type, err = ParseSubAssetType(row[i]); err != nil {
+						return nil, fmt.Errorf("read csv assets line %d column %s: %w", line, name, err)
+					}
+				}
+				continue
+			case "SubAssetUrl":
+				sub.Url = row[i]
+				continue
+			}
+			c, ok := LookupColumn(name)
+			if !ok || c.Set == nil {
+				continue
+			}
+			if err := c.Set(&asset, row[i]); err != nil {
+				return nil, fmt.Errorf("read csv assets line %d column %s: %w", line, name, err)
+			}
+		}
+
+		a, ok := index[KeyOf(&asset)]
+		if !ok {
+			a = &asset
+			index[KeyOf(a)] = a
+			assets = append(assets, a)
+		}
+		if sub.Url != "" && !slices.ContainsFunc(a.SubAssets, func(s *SubAsset) bool { return *s == sub }) {
+			a.SubAssets = append(a.SubAssets, &sub)
+		}
+	}
+	return
+}
+
+func guessComma(header []byte) rune {
+	comma, most := ',', bytes.Count(header, []byte{','})
+	for _, c := range []rune{';', '\t', '|'} {
+		if n := bytes.Count(header, []byte{byte(c)}); n > most {
+			comma, most = c, n
+		}
+	}
+	return comma
+}
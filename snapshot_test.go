@@ -0,0 +1,84 @@
+package ads
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGuessComma(t *testing.T) {
+	for header, want := range map[string]rune{
+		"":                           ',',
+		"AssetID":                    ',',
+		"AccountID,AssetID,Name":     ',',
+		"AccountID;AssetID;Name":     ';',
+		"AccountID\tAssetID\tName":   '\t',
+		"AccountID|AssetID|Name":     '|',
+		"AccountID;AssetID,Name;Url": ';',
+		"AccountID,AssetID;Name,Url": ',',
+	} {
+		if got := guessComma([]byte(header)); got != want {
+			t.Errorf("comma of %q = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestReadAssetsCSV(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data string
+	}{
+		{"comma", "AccountID,AssetID,PageType,Texts\n1001,22,PTText,\"[\"\"a,b\"\"]\"\n"},
+		{"semicolon", "AccountID;AssetID;PageType;Texts\n1001;22;PTText;\"[\"\"a,b\"\"]\"\n"},
+		{"tab", "AccountID\tAssetID\tPageType\tTexts\n1001\t22\tPTText\t\"[\"\"a,b\"\"]\"\n"},
+		{"bom", "\ufeffAccountID,AssetID,PageType,Texts\n1001,22,PTText,\"[\"\"a,b\"\"]\"\n"},
+		{"bom semicolon crlf", "\ufeffAccountID;AssetID;PageType;Texts\r\n1001;22;PTText;\"[\"\"a,b\"\"]\"\r\n"},
+		{"no trailing newline", "AccountID;AssetID;PageType;Texts\n1001;22;PTText;\"[\"\"a,b\"\"]\""},
+		{"unknown columns", "AccountID,Extra,AssetID,PageType,PrimaryUrl,Texts\n1001,x,22,PTText,https://example.com,\"[\"\"a,b\"\"]\"\n"},
+	} {
+		assets, err := ReadAssets(strings.NewReader(tt.data), "csv")
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		want := []*Asset{{AccountID: "1001", AssetID: "22", PageType: PTText, Texts: []string{"a,b"}}}
+		if !reflect.DeepEqual(assets, want) {
+			t.Errorf("%s: assets\n%s", tt.name, dump(assets))
+		}
+	}
+
+	if assets, err := ReadAssets(strings.NewReader(""), "csv"); err != nil || assets != nil {
+		t.Errorf("empty file = %v, %v", assets, err)
+	}
+	if _, err := ReadAssets(strings.NewReader("AssetID,Width\n1,wide\n"), "csv"); err == nil || !strings.Contains(err.Error(), "line 2 column Width") {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestReadAssetsCSVExplode(t *testing.T) {
+	for _, columns := range [][]string{
+		nil,
+		{"AccountID", "AssetID", "PageType", "Width", "SubAssetIndex", "SubAssetType", "SubAssetUrl"},
+	} {
+		b := exportCSV(t, exportAssets(), ExportOptions{Columns: columns, Explode: true, Comma: ';', BOM: true})
+		if n := bytes.Count(b, []byte("\n")); n != 4 {
+			t.Fatalf("%v: exported %d lines, want a row per sub asset", columns, n)
+		}
+
+		assets, err := ReadAssets(bytes.NewReader(b), "csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(assets) != 2 {
+			t.Fatalf("%v: read %d assets, want 2\n%s", columns, len(assets), dump(assets))
+		}
+		want := exportAssets()[0]
+		if got := assets[0]; !reflect.DeepEqual(got.SubAssets, want.SubAssets) || got.Width != want.Width {
+			t.Errorf("%v: merged asset\n%s", columns, dump(assets[:1]))
+		}
+		if got := assets[1]; got.AssetID != "22" || len(got.SubAssets) != 0 {
+			t.Errorf("%v: asset without sub assets\n%s", columns, dump(assets[1:]))
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ads
 
 import (
+	"bytes"
 	"encoding/json"
 
 	"github.com/stretchr/objx"
@@ -26,3 +27,50 @@ func ToMapSlice(val interface{}) ([]Map, error) {
 
 	return objx.FromJSONSlice(string(b))
 }
+
+// FromJSON decodes a JSON object, integers are decoded as int64 so the large
+// ids of ad platforms keep all their digits, other numbers as float64
+func FromJSON(b []byte) (Map, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return objx.New(numbers(m)), nil
+}
+
+// numbers replaces the json.Numbers of v
+func numbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = numbers(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = numbers(elem)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
+
+// StringSlice returns the elements of a decoded JSON array as strings, numbers
+// formatted as decimals
+func StringSlice(v *objx.Value) []string {
+	elems := v.InterSlice()
+	values := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		if s := objx.New(map[string]interface{}{"v": elem}).Get("v").String(); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
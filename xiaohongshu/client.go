@@ -0,0 +1,108 @@
+package xiaohongshu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+	"github.com/stretchr/objx"
+)
+
+// DefaultEndpoint is the open API of Juguang
+const DefaultEndpoint = "https://adapi.xiaohongshu.com/api/open/jg"
+
+// pageSize is the page size of list calls
+const pageSize = 100
+
+// Error is an error code returned by the API
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("xiaohongshu: code %d: %s", e.Code, e.Message)
+}
+
+// Client calls the open API with an access token
+type Client struct {
+	Endpoint    string
+	AccessToken string
+	HTTPClient  *http.Client
+	// Debug logs the requests and responses at debug level
+	Debug bool
+}
+
+// Post calls the API at path with a JSON body and returns the data of the
+// response
+func (c *Client) Post(ctx context.Context, path string, body map[string]interface{}) (ads.Map, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/"+strings.TrimPrefix(path, "/"), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Access-Token", c.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	rspBody, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Code int             `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rspBody, &r); err != nil {
+		return nil, fmt.Errorf("xiaohongshu: %s: %w", path, err)
+	}
+	if r.Code != 0 {
+		return nil, &Error{Code: r.Code, Message: r.Msg}
+	}
+	if len(r.Data) == 0 || string(r.Data) == "null" {
+		return objx.New(map[string]interface{}{}), nil
+	}
+	return ads.FromJSON(r.Data)
+}
+
+// List calls a paginated list call and returns the objects under key of all
+// pages
+func (c *Client) List(ctx context.Context, path, key string, body map[string]interface{}) ([]ads.Map, error) {
+	var objs []ads.Map
+	for page := 1; ; page++ {
+		b := make(map[string]interface{}, len(body)+1)
+		for k, v := range body {
+			b[k] = v
+		}
+		b["page"] = map[string]int{"page_index": page, "page_size": pageSize}
+
+		data, err := c.Post(ctx, path, b)
+		if err != nil {
+			return nil, err
+		}
+		list := data.Get(key).ObjxMapSlice()
+		objs = append(objs, list...)
+
+		if len(list) == 0 || len(objs) >= httpapi.IntValue(data, "page.total_count") {
+			return objs, nil
+		}
+	}
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	return httpapi.Doer{Name: "xiaohongshu", HTTPClient: c.HTTPClient, Debug: c.Debug}.Do(req)
+}
@@ -0,0 +1,354 @@
+// Package xiaohongshu lists the creatives of a Xiaohongshu Juguang (聚光)
+// advertiser with the open API. Note-based creatives are mapped to the cover,
+// carousel images or video and title of their note, other creatives to their
+// images, videos and titles, and both to their landing pages.
+package xiaohongshu
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+	"github.com/stretchr/objx"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	OnlyAdcreatives bool
+	// Endpoint is the base url of the open API, DefaultEndpoint when empty
+	Endpoint string
+}
+
+type XiaohongshuAdcreatives struct {
+	AdvertiserID   int64
+	Config         Config
+	client         *Client
+	adcreativesFns []ads.AdcreativeMatchFunc
+	accountName    *string
+	log            *zap.SugaredLogger
+}
+
+func NewAdcreatives(accountId string, accessToken string, debug bool) (*XiaohongshuAdcreatives, error) {
+	id, err := strconv.ParseInt(accountId, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	x := &XiaohongshuAdcreatives{
+		AdvertiserID: id,
+		client:       &Client{AccessToken: accessToken, Debug: debug},
+		log:          zap.S().With("provider", "XIAOHONGSHU"),
+	}
+
+	x.SetAdcreativesFunc(ads.NotDeleted)
+
+	return x, nil
+}
+
+// ref is the creative of a note or material
+type ref struct {
+	CreativeID   string
+	CreativeName string
+}
+
+// Assets lists the assets of the advertiser's creatives: landing pages,
+// titles, note covers, carousel images and videos
+func (x *XiaohongshuAdcreatives) Assets() (assets []*ads.Asset, err error) {
+	ctx := context.Background()
+
+	creatives, err := x.client.List(ctx, "creativity/search", "creativity_dtos", map[string]interface{}{
+		"advertiser_id": x.AdvertiserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	creatives = httpapi.Process(creatives, normalize, x.adcreativesFns)
+	httpapi.PrintJson(x.log, "creatives", creatives)
+
+	var (
+		noteIds    = make(ads.Set[string])
+		adsNotes   = make(map[string]*ref)
+		pageIndexs = make(map[string]bool)
+		urlIndexs  = make(map[string]bool)
+	)
+
+	addImage := func(r *ref, id, subType string, v interface{}) {
+		url, width, height := media(v)
+		if url == "" || urlIndexs[url] {
+			return
+		}
+		urlIndexs[url] = true
+		assets = append(assets, &ads.Asset{
+			AccountID:      x.accountID(),
+			Name:           r.CreativeName,
+			AssetID:        id,
+			AdcreativeID:   r.CreativeID,
+			AdcreativeName: r.CreativeName,
+			PageType:       ads.PTImage,
+			SubType:        subType,
+			SubAssets: []*ads.SubAsset{
+				{
+					Type: ads.SATImage,
+					Url:  url,
+				},
+			},
+			Width:  width,
+			Height: height,
+		})
+	}
+	addVideo := func(r *ref, id, subType string, video, cover interface{}) {
+		url, width, height := media(video)
+		if url == "" || urlIndexs[url] {
+			return
+		}
+		urlIndexs[url] = true
+		coverUrl, _, _ := media(cover)
+		assets = append(assets, &ads.Asset{
+			AccountID:      x.accountID(),
+			Name:           r.CreativeName,
+			AssetID:        id,
+			AdcreativeID:   r.CreativeID,
+			AdcreativeName: r.CreativeName,
+			PageType:       ads.PTVideo,
+			SubType:        subType,
+			SubAssets: []*ads.SubAsset{
+				{
+					Type: ads.SATImage,
+					Url:  coverUrl,
+				},
+				{
+					Type: ads.SATVideo,
+					Url:  url,
+				},
+			},
+			Width:  width,
+			Height: height,
+		})
+	}
+	addTitle := func(r *ref, id, subType, title string) {
+		if title == "" {
+			return
+		}
+		assets = append(assets, &ads.Asset{
+			AccountID:      x.accountID(),
+			Name:           r.CreativeName,
+			AssetID:        id,
+			AdcreativeID:   r.CreativeID,
+			AdcreativeName: r.CreativeName,
+			PageType:       ads.PTText,
+			SubType:        subType,
+			Texts:          []string{title},
+		})
+	}
+
+	for _, creative := range creatives {
+		r := &ref{
+			CreativeID:   creative.Get("creativity_id").String(),
+			CreativeName: creative.Get("creativity_name").String(),
+		}
+
+		for _, key := range []string{"landing_page_url", "jump_url"} {
+			url := creative.Get(key).String()
+			if url == "" || pageIndexs[url] {
+				continue
+			}
+			pageIndexs[url] = true
+			assets = append(assets, &ads.Asset{
+				AccountID:      x.accountID(),
+				Name:           r.CreativeName,
+				AssetID:        r.CreativeID + "_" + key,
+				AdcreativeID:   r.CreativeID,
+				AdcreativeName: r.CreativeName,
+				PageType:       ads.PTPageUrl,
+				SubType:        "LANDING_PAGE",
+				SubAssets: []*ads.SubAsset{
+					{
+						Type: ads.SATPageUrl,
+						Url:  url,
+					},
+				},
+				CreatedTime: parseTime(creative, "create_time"),
+			})
+		}
+
+		if noteId := creative.Get("note_id").String(); noteId != "" {
+			noteIds.Add(noteId)
+			if _, ok := adsNotes[noteId]; !ok {
+				adsNotes[noteId] = r
+			}
+			continue
+		}
+
+		addTitle(r, r.CreativeID, "TITLE", creative.Get("title").String())
+		for i, image := range creative.Get("image_urls").InterSlice() {
+			addImage(r, r.CreativeID+"_"+strconv.Itoa(i), "CREATIVE_IMAGE", image)
+		}
+		addVideo(r, r.CreativeID, "CREATIVE_VIDEO", creative.Get("video_url").Data(), creative.Get("video_cover_url").Data())
+	}
+
+	notes, err := x.notes(ctx, noteIds.Slice())
+	if err != nil {
+		return nil, err
+	}
+	httpapi.PrintJson(x.log, "notes", notes)
+
+	for _, note := range notes {
+		noteId := note.Get("note_id").String()
+		r, ok := adsNotes[noteId]
+		if !ok {
+			r = &ref{}
+		}
+
+		addTitle(r, noteId, "NOTE_TITLE", note.Get("title").String())
+		if video := note.Get("video"); !video.IsNil() {
+			cover := note.Get("cover")
+			if cover.IsNil() {
+				cover = note.Get("video.cover")
+			}
+			addVideo(r, noteId, "NOTE_VIDEO", video.Data(), cover.Data())
+			continue
+		}
+
+		addImage(r, noteId+"_cover", "NOTE_COVER", note.Get("cover").Data())
+		for i, image := range note.Get("image_list").InterSlice() {
+			addImage(r, noteId+"_"+strconv.Itoa(i), "NOTE_IMAGE", image)
+		}
+	}
+
+	name := x.AccountName()
+	for _, asset := range assets {
+		asset.AccountName = name
+	}
+	return assets, nil
+}
+
+// notes reads the notes of the ids
+func (x *XiaohongshuAdcreatives) notes(ctx context.Context, ids []string) ([]ads.Map, error) {
+	var notes []ads.Map
+	for _, batch := range httpapi.Batches(ids, pageSize) {
+		objs, err := x.client.List(ctx, "note/list", "notes", map[string]interface{}{
+			"advertiser_id": x.AdvertiserID,
+			"note_ids":      batch,
+		})
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, objs...)
+	}
+	return notes, nil
+}
+
+// media returns the url and size of an image or video, given as an url or an
+// object
+func media(v interface{}) (url string, width, height int) {
+	switch v := v.(type) {
+	case string:
+		return v, 0, 0
+	case map[string]interface{}:
+		m := objx.New(v)
+		return m.Get("url").String(), httpapi.IntValue(m, "width"), httpapi.IntValue(m, "height")
+	}
+	return "", 0, 0
+}
+
+// AccountName returns the name of the advertiser, empty when it cannot be
+// read
+func (x *XiaohongshuAdcreatives) AccountName() string {
+	if x.accountName != nil {
+		return *x.accountName
+	}
+
+	data, err := x.client.Post(context.Background(), "account/info", map[string]interface{}{
+		"advertiser_id": x.AdvertiserID,
+	})
+	if err != nil {
+		x.log.Warnw("get advertiser error", "account", x.AdvertiserID, "error", err)
+	}
+	name := data.Get("advertiser_name").String()
+
+	x.accountName = &name
+	return name
+}
+
+// SetAdcreativesFunc ...
+func (x *XiaohongshuAdcreatives) SetAdcreativesFunc(match ads.AdcreativeMatchFunc) {
+	x.adcreativesFns = append(x.adcreativesFns, match)
+}
+
+// ClearAdcreativesFuncs ...
+func (x *XiaohongshuAdcreatives) ClearAdcreativesFuncs() {
+	x.adcreativesFns = nil
+}
+
+// OnlyAdcreatives has no effect, only the notes and materials of creatives
+// are listed
+func (x *XiaohongshuAdcreatives) OnlyAdcreatives(on bool) {
+	x.Config.OnlyAdcreatives = on
+}
+
+// Configure applies provider settings: endpoint replaces the url of the open
+// API
+func (x *XiaohongshuAdcreatives) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		switch key {
+		case "endpoint":
+			x.Config.Endpoint, x.client.Endpoint = value, value
+		default:
+			return fmt.Errorf("xiaohongshu: unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// normalize sets the configured_status and created_time fields of GDT
+// adcreatives from a creative
+func normalize(creative ads.Map) {
+	if creative.Has("enable") {
+		status := "AD_STATUS_SUSPEND"
+		if httpapi.IntValue(creative, "enable") == 1 {
+			status = "AD_STATUS_NORMAL"
+		}
+		creative.Set("configured_status", status)
+	}
+	if t := parseTime(creative, "create_time"); !t.IsZero() {
+		creative.Set("created_time", t.Unix())
+	}
+}
+
+func (x *XiaohongshuAdcreatives) accountID() string {
+	return strconv.FormatInt(x.AdvertiserID, 10)
+}
+
+var (
+	_ ads.GetAdcreatives = (*XiaohongshuAdcreatives)(nil)
+	_ ads.Configurable   = (*XiaohongshuAdcreatives)(nil)
+)
+
+func init() {
+	ads.RegisterProvider("XIAOHONGSHU", func(accountId string, accessToken string, debug bool) (ads.GetAdcreatives, error) {
+		return NewAdcreatives(accountId, accessToken, debug)
+	})
+	ads.DescribeProvider(ads.ProviderInfo{
+		Name:         "XIAOHONGSHU",
+		Description:  "Xiaohongshu Juguang (聚光) creatives and notes",
+		Capabilities: []ads.Capability{ads.CapDownload},
+		PageTypes:    []ads.PageType{ads.PTPageUrl, ads.PTVideo, ads.PTImage, ads.PTText},
+		Credentials:  []string{"access_token"},
+		Settings: []ads.Setting{
+			{Name: "endpoint", Description: "base url of the API", Default: DefaultEndpoint},
+		},
+	})
+}
+
+// parseTime reads a time in milliseconds or a date
+func parseTime(obj ads.Map, key string) time.Time {
+	if obj.Get(key).IsStr() {
+		return httpapi.ParseTime(obj, key, httpapi.DateTime, httpapi.CST)
+	}
+	if ms := httpapi.IntValue(obj, key); ms > 0 {
+		return time.UnixMilli(int64(ms))
+	}
+	return time.Time{}
+}
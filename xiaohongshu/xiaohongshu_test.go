@@ -0,0 +1,145 @@
+package xiaohongshu
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hnhuaxi/ads"
+	"github.com/hnhuaxi/ads/internal/apitest"
+	"github.com/hnhuaxi/ads/internal/httpapi"
+)
+
+// server serves the data of the open API paths for the request bodies
+func server(t *testing.T, routes map[string]apitest.Route) *httptest.Server {
+	return apitest.Server(t, apitest.Config{
+		Auth: func(r *http.Request) bool { return r.Header.Get("Access-Token") == "token" },
+	}, routes)
+}
+
+// data is a successful response
+func data(v interface{}) apitest.Object {
+	return apitest.Object{"code": 0, "msg": "success", "data": v}
+}
+
+func open(t *testing.T, srv *httptest.Server) *XiaohongshuAdcreatives {
+	return apitest.Open(t, NewAdcreatives, "1001", srv, nil)
+}
+
+func TestAssets(t *testing.T) {
+	var pages []float64
+	srv := server(t, map[string]apitest.Route{
+		"/account/info": func(*apitest.Request) interface{} {
+			return data(apitest.Object{"advertiser_name": "shop"})
+		},
+		"/creativity/search": func(r *apitest.Request) interface{} {
+			page := r.Body["page"].(map[string]interface{})["page_index"].(float64)
+			pages = append(pages, page)
+			if page == 1 {
+				return data(apitest.Object{"page": apitest.Object{"total_count": 3}, "creativity_dtos": []apitest.Object{
+					{
+						"creativity_id": 11, "creativity_name": "note ad", "enable": 1, "note_id": "n1",
+						"landing_page_url": "https://example.com/a", "jump_url": "https://example.com/jump",
+						"create_time": "2024-05-01 10:00:00",
+					},
+					{"creativity_id": 12, "creativity_name": "paused", "enable": 0, "note_id": "n2", "create_time": 1714528800000},
+				}})
+			}
+			return data(apitest.Object{"page": apitest.Object{"total_count": 3}, "creativity_dtos": []apitest.Object{
+				{
+					"creativity_id": 13, "creativity_name": "material ad", "enable": 1, "title": "title c",
+					"image_urls":      []interface{}{"https://example.com/c0.jpg", apitest.Object{"url": "https://example.com/c1.jpg", "width": 800, "height": "600"}},
+					"video_url":       "https://example.com/c.mp4",
+					"video_cover_url": "https://example.com/c-cover.jpg",
+				},
+			}})
+		},
+		"/note/list": func(r *apitest.Request) interface{} {
+			if ids := r.Body["note_ids"].([]interface{}); len(ids) != 2 {
+				t.Errorf("note ids = %v", ids)
+			}
+			return data(apitest.Object{"page": apitest.Object{"total_count": 2}, "notes": []apitest.Object{
+				{"note_id": "n1", "title": "note one", "cover": "https://example.com/n1.jpg", "image_list": []interface{}{"https://example.com/n1-0.jpg"}},
+				{"note_id": "n2", "title": "note two", "video": apitest.Object{"url": "https://example.com/n2.mp4", "width": 720, "height": 1280}, "cover": "https://example.com/n2.jpg"},
+			}})
+		},
+	})
+
+	assets, err := open(t, srv).Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 {
+		t.Errorf("creative pages = %v", pages)
+	}
+
+	got := apitest.Index(t, assets, "1001", "shop")
+	if got.Len() != 11 {
+		t.Errorf("got %d assets, want 11", got.Len())
+	}
+	get := got.Get
+
+	landing := get("11_landing_page_url", ads.PTPageUrl)
+	if want := time.Date(2024, 5, 1, 10, 0, 0, 0, httpapi.CST); !landing.CreatedTime.Equal(want) {
+		t.Errorf("created time = %v, want %v", landing.CreatedTime, want)
+	}
+	if url := get("11_jump_url", ads.PTPageUrl).SubAssets[0].Url; url != "https://example.com/jump" {
+		t.Errorf("jump url = %q", url)
+	}
+
+	if asset := get("n1", ads.PTText); asset.AdcreativeID != "11" || asset.Texts[0] != "note one" {
+		t.Errorf("note title = %+v", asset)
+	}
+	get("n1_cover", ads.PTImage)
+	get("n1_0", ads.PTImage)
+	if video := get("n2", ads.PTVideo); video.AdcreativeID != "12" || video.SubAssets[0].Url != "https://example.com/n2.jpg" || video.Height != 1280 {
+		t.Errorf("note video = %+v", video)
+	}
+	get("n2", ads.PTText)
+
+	get("13", ads.PTText)
+	get("13_0", ads.PTImage)
+	if image := get("13_1", ads.PTImage); image.Width != 800 || image.Height != 600 {
+		t.Errorf("image = %+v", image)
+	}
+	get("13", ads.PTVideo)
+}
+
+func TestAssetsActiveOnly(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/account/info": func(*apitest.Request) interface{} { return data(apitest.Object{}) },
+		"/creativity/search": func(*apitest.Request) interface{} {
+			return data(apitest.Object{"page": apitest.Object{"total_count": 2}, "creativity_dtos": []apitest.Object{
+				{"creativity_id": 11, "enable": 1, "title": "on"},
+				{"creativity_id": 12, "enable": 0, "title": "off"},
+			}})
+		},
+		"/note/list": func(*apitest.Request) interface{} { return data(apitest.Object{}) },
+	})
+
+	x := open(t, srv)
+	x.SetAdcreativesFunc(ads.ActiveOnly)
+	assets, err := x.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 1 || assets[0].AdcreativeID != "11" {
+		t.Errorf("assets = %+v", assets)
+	}
+}
+
+func TestError(t *testing.T) {
+	srv := server(t, map[string]apitest.Route{
+		"/creativity/search": func(*apitest.Request) interface{} {
+			return apitest.Object{"code": 40001, "msg": "invalid access token"}
+		},
+	})
+
+	_, err := open(t, srv).Assets()
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 40001 {
+		t.Errorf("error = %v", err)
+	}
+}